@@ -5,28 +5,230 @@ CREATE DATABASE IF NOT EXISTS cachacadb;
 USE cachacadb;
 
 CREATE TABLE users
+(
+    id              INT          NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    uuid            VARCHAR(256) NOT NULL,
+    email           VARCHAR(100) NOT NULL,
+    -- The last email the user confirmed, either through a verification link or a social login
+    verified_email  VARCHAR(100),
+    password        VARCHAR(255) NOT NULL,
+    name            VARCHAR(100),
+    phone           VARCHAR(20),
+    role            VARCHAR(32)  NOT NULL DEFAULT 'customer',
+    status_code     TINYINT(1)   NOT NULL DEFAULT 0,
+    cpf             CHAR(11) UNIQUE,
+    birth_date      DATE,
+    age_verified_at TIMESTAMP    NULL,
+    avatar          VARCHAR(255),
+    locale          VARCHAR(5)   NOT NULL DEFAULT 'pt-BR',
+    created_at      TIMESTAMP             DEFAULT CURRENT_TIMESTAMP,
+    modified_at     TIMESTAMP             DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE age_confirmations
 (
     id          INT          NOT NULL AUTO_INCREMENT PRIMARY KEY,
-    uuid        VARCHAR(256) NOT NULL,
-    email       VARCHAR(100) NOT NULL,
-    password    VARCHAR(255) NOT NULL,
-    phone       VARCHAR(20),
-    is_adm      BOOLEAN      NOT NULL DEFAULT FALSE,
-    status_code TINYINT(1)   NOT NULL DEFAULT 0,
-    created_at  TIMESTAMP             DEFAULT CURRENT_TIMESTAMP,
-    modified_at TIMESTAMP             DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+    user_id     INT          NOT NULL,
+    cpf         CHAR(11)     NOT NULL,
+    birth_date  DATE         NOT NULL,
+    ip_address  VARCHAR(45),
+    user_agent  VARCHAR(255),
+    accepted_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_age_confirmation_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE refresh_tokens
+(
+    id                      INT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id                 INT       NOT NULL,
+    family_id               CHAR(36)  NOT NULL,
+    token_hash              CHAR(64)  NOT NULL UNIQUE,
+    access_token_id         CHAR(36)  NOT NULL,
+    access_token_expires_at TIMESTAMP NOT NULL,
+    expires_at              TIMESTAMP NOT NULL,
+    revoked_at              TIMESTAMP NULL,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_refresh_tokens_family (family_id),
+    CONSTRAINT fk_refresh_token_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE sessions
+(
+    family_id    CHAR(36)     NOT NULL PRIMARY KEY,
+    user_id      INT          NOT NULL,
+    device       VARCHAR(100) NOT NULL,
+    ip_address   VARCHAR(45)  NOT NULL,
+    user_agent   VARCHAR(255),
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at   TIMESTAMP    NULL,
+    INDEX idx_sessions_user (user_id),
+    CONSTRAINT fk_session_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE password_resets
+(
+    id         INT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT       NOT NULL,
+    token_hash CHAR(64)  NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_password_reset_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE email_verifications
+(
+    id         INT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT       NOT NULL,
+    token_hash CHAR(64)  NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_email_verification_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_two_factor
+(
+    user_id        INT         NOT NULL PRIMARY KEY,
+    secret         VARCHAR(64) NOT NULL,
+    enabled_at     TIMESTAMP   NULL,
+    last_used_step BIGINT      NOT NULL DEFAULT 0,
+    created_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_two_factor_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE recovery_codes
+(
+    id         INT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT       NOT NULL,
+    code_hash  CHAR(64)  NOT NULL,
+    used_at    TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_recovery_code (user_id, code_hash),
+    CONSTRAINT fk_recovery_code_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE login_failures
+(
+    id         INT          NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    email      VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(45)  NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_login_failure_email (email, created_at),
+    INDEX idx_login_failure_ip (ip_address, created_at)
+);
+
+CREATE TABLE oidc_states
+(
+    state_hash    CHAR(64)    NOT NULL PRIMARY KEY,
+    provider      VARCHAR(32) NOT NULL,
+    nonce         VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    expires_at    TIMESTAMP   NOT NULL,
+    created_at    TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE user_identities
+(
+    id         INT          NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT          NOT NULL,
+    provider   VARCHAR(32)  NOT NULL,
+    subject    VARCHAR(255) NOT NULL,
+    email      VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_identity_provider_subject (provider, subject),
+    CONSTRAINT fk_identity_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_consents
+(
+    id           INT          NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id      INT          NOT NULL,
+    consent_type VARCHAR(32)  NOT NULL,
+    version      VARCHAR(32),
+    granted      BOOLEAN      NOT NULL,
+    ip_address   VARCHAR(45)  NOT NULL,
+    user_agent   VARCHAR(255),
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_consents_user_type (user_id, consent_type, id),
+    CONSTRAINT fk_consent_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE email_outbox
+(
+    id              INT          NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    recipient       VARCHAR(255) NOT NULL,
+    subject         VARCHAR(255) NOT NULL,
+    body            MEDIUMTEXT   NOT NULL,
+    status          VARCHAR(16)  NOT NULL DEFAULT 'pending',
+    attempts        INT          NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error      TEXT,
+    created_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at         TIMESTAMP    NULL,
+    INDEX idx_outbox_due (status, next_attempt_at)
+);
+
+CREATE TABLE api_keys
+(
+    id           INT          NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id      INT          NOT NULL,
+    name         VARCHAR(100) NOT NULL,
+    prefix       CHAR(16)     NOT NULL UNIQUE,
+    key_hash     CHAR(64)     NOT NULL,
+    scopes       VARCHAR(512) NOT NULL,
+    last_used_at TIMESTAMP    NULL,
+    last_used_ip VARCHAR(45),
+    expires_at   TIMESTAMP    NULL,
+    revoked_at   TIMESTAMP    NULL,
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_api_keys_user (user_id),
+    CONSTRAINT fk_api_key_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE revoked_tokens
+(
+    token_id   CHAR(36)  NOT NULL PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE categories
+(
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    name       VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE TABLE products
 (
-    id          INT AUTO_INCREMENT PRIMARY KEY,
-    name        VARCHAR(255)   NOT NULL,
-    description TEXT,
-    price       DECIMAL(10, 2) NOT NULL,
-    stock       INT            NOT NULL,
-    status_code TINYINT(1)     NOT NULL DEFAULT 0,
-    created_at  TIMESTAMP               DEFAULT CURRENT_TIMESTAMP,
-    modified_at TIMESTAMP               DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+    id             INT AUTO_INCREMENT PRIMARY KEY,
+    category_id    INT,
+    name           VARCHAR(255)   NOT NULL,
+    description    TEXT,
+    price          DECIMAL(10, 2) NOT NULL,
+    original_price DECIMAL(10, 2),
+    stock          INT            NOT NULL,
+    status_code    TINYINT(1)     NOT NULL DEFAULT 0,
+    created_at     TIMESTAMP               DEFAULT CURRENT_TIMESTAMP,
+    modified_at    TIMESTAMP               DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_product_category FOREIGN KEY (category_id) REFERENCES categories (id) ON DELETE SET NULL
+);
+
+CREATE TABLE product_prices
+(
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    product_id   INT            NOT NULL,
+    type         VARCHAR(20)    NOT NULL,
+    price        DECIMAL(10, 2) NOT NULL,
+    starts_at    TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ends_at      TIMESTAMP      NULL,
+    applied_at   TIMESTAMP      NULL,
+    ended_at     TIMESTAMP      NULL,
+    cancelled_at TIMESTAMP      NULL,
+    created_at   TIMESTAMP               DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_product_price_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
 );
 
 CREATE TABLE products_photos
@@ -49,31 +251,207 @@ CREATE TABLE carts_products
     CONSTRAINT fk_carts_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
 );
 
+CREATE TABLE coupons
+(
+    id                INT AUTO_INCREMENT PRIMARY KEY,
+    code              VARCHAR(50)    NOT NULL UNIQUE,
+    type              VARCHAR(20)    NOT NULL,
+    value             DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    min_order_value   DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    starts_at         TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at        TIMESTAMP      NOT NULL,
+    max_uses          INT            NOT NULL DEFAULT 0,
+    max_uses_per_user INT            NOT NULL DEFAULT 0,
+    used_count        INT            NOT NULL DEFAULT 0,
+    active            BOOLEAN        NOT NULL DEFAULT TRUE,
+    created_at        TIMESTAMP               DEFAULT CURRENT_TIMESTAMP,
+    modified_at       TIMESTAMP               DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE coupons_products
+(
+    coupon_id  INT NOT NULL,
+    product_id INT NOT NULL,
+    PRIMARY KEY (coupon_id, product_id),
+    CONSTRAINT fk_coupon_product_coupon FOREIGN KEY (coupon_id) REFERENCES coupons (id) ON DELETE CASCADE,
+    CONSTRAINT fk_coupon_product_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+CREATE TABLE coupons_categories
+(
+    coupon_id   INT NOT NULL,
+    category_id INT NOT NULL,
+    PRIMARY KEY (coupon_id, category_id),
+    CONSTRAINT fk_coupon_category_coupon FOREIGN KEY (coupon_id) REFERENCES coupons (id) ON DELETE CASCADE,
+    CONSTRAINT fk_coupon_category_category FOREIGN KEY (category_id) REFERENCES categories (id) ON DELETE CASCADE
+);
+
+CREATE TABLE carts_coupons
+(
+    user_id    INT NOT NULL PRIMARY KEY,
+    coupon_id  INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_cart_coupon_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_cart_coupon_coupon FOREIGN KEY (coupon_id) REFERENCES coupons (id) ON DELETE CASCADE
+);
+
+-- Abandoned cart reminders sent for the cart as it was last modified at cart_modified_at.
+-- Modifying the cart starts the count again, and checking out deletes the row.
+CREATE TABLE cart_reminders
+(
+    user_id          INT       NOT NULL PRIMARY KEY,
+    cart_modified_at TIMESTAMP NOT NULL,
+    reminders_sent   INT       NOT NULL DEFAULT 0,
+    last_sent_at     TIMESTAMP NULL,
+    -- When the last reminder could not be sent; the cart is retried after abandoned_after
+    failed_at        TIMESTAMP NULL,
+    CONSTRAINT fk_cart_reminders_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE promotions
+(
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    name         VARCHAR(100)   NOT NULL,
+    type         VARCHAR(20)    NOT NULL,
+    category_id  INT,
+    buy_quantity INT            NOT NULL DEFAULT 0,
+    pay_quantity INT            NOT NULL DEFAULT 0,
+    kit_price    DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    priority     INT            NOT NULL DEFAULT 0,
+    starts_at    TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ends_at      TIMESTAMP      NULL,
+    active       BOOLEAN        NOT NULL DEFAULT TRUE,
+    created_at   TIMESTAMP               DEFAULT CURRENT_TIMESTAMP,
+    modified_at  TIMESTAMP               DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_promotion_category FOREIGN KEY (category_id) REFERENCES categories (id) ON DELETE CASCADE
+);
+
+CREATE TABLE promotions_products
+(
+    promotion_id INT NOT NULL,
+    product_id   INT NOT NULL,
+    quantity     INT NOT NULL DEFAULT 1,
+    PRIMARY KEY (promotion_id, product_id),
+    CONSTRAINT fk_promotion_product_promotion FOREIGN KEY (promotion_id) REFERENCES promotions (id) ON DELETE CASCADE,
+    CONSTRAINT fk_promotion_product_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+CREATE TABLE promotions_tiers
+(
+    promotion_id     INT           NOT NULL,
+    min_quantity     INT           NOT NULL,
+    discount_percent DECIMAL(5, 2) NOT NULL,
+    PRIMARY KEY (promotion_id, min_quantity),
+    CONSTRAINT fk_promotion_tier_promotion FOREIGN KEY (promotion_id) REFERENCES promotions (id) ON DELETE CASCADE
+);
+
 CREATE TABLE orders
 (
-    id          INT AUTO_INCREMENT PRIMARY KEY,
-    user_id     INT NOT NULL,
-    created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    CONSTRAINT fk_order_user FOREIGN KEY (user_id) REFERENCES users (id)
+    id                  INT            AUTO_INCREMENT PRIMARY KEY,
+    user_id             INT            NOT NULL,
+    coupon_id           INT,
+    subtotal            DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    discount            DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    total               DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    status              VARCHAR(20)    NOT NULL DEFAULT 'pending',
+    tracking_code       VARCHAR(50),
+    shipping_name       VARCHAR(100),
+    shipping_zip_code   CHAR(8),
+    shipping_street     VARCHAR(100),
+    shipping_number     VARCHAR(20),
+    shipping_complement VARCHAR(100),
+    shipping_district   VARCHAR(100),
+    shipping_city       VARCHAR(100),
+    shipping_state      CHAR(2),
+    created_at          TIMESTAMP      DEFAULT CURRENT_TIMESTAMP,
+    modified_at         TIMESTAMP      DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_order_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_order_coupon FOREIGN KEY (coupon_id) REFERENCES coupons (id)
 );
 
+CREATE TABLE coupons_usages
+(
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    coupon_id  INT NOT NULL,
+    user_id    INT NOT NULL,
+    order_id   INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_coupon_usage_coupon FOREIGN KEY (coupon_id) REFERENCES coupons (id),
+    CONSTRAINT fk_coupon_usage_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_coupon_usage_order FOREIGN KEY (order_id) REFERENCES orders (id)
+);
 
 CREATE TABLE order_items
 (
     id          INT AUTO_INCREMENT PRIMARY KEY,
-    order_id    INT NOT NULL,
-    product_id  INT NOT NULL,
-    quantity    INT NOT NULL,
+    order_id    INT            NOT NULL,
+    product_id  INT            NOT NULL,
+    quantity    INT            NOT NULL,
+    price       DECIMAL(10, 2) NOT NULL DEFAULT 0,
     created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     CONSTRAINT fk_order_item_order FOREIGN KEY (order_id) REFERENCES orders (id),
     CONSTRAINT fk_order_item_product FOREIGN KEY (product_id) REFERENCES products (id)
 );
 
-INSERT INTO users (uuid, email, password, phone, is_adm, status_code)
+CREATE TABLE product_reviews
+(
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    product_id  INT         NOT NULL,
+    user_id     INT         NOT NULL,
+    rating      TINYINT     NOT NULL,
+    comment     TEXT,
+    status      VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at  TIMESTAMP            DEFAULT CURRENT_TIMESTAMP,
+    modified_at TIMESTAMP            DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_review_product_user (product_id, user_id),
+    CONSTRAINT fk_review_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_review_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE wishlists_products
+(
+    user_id    INT NOT NULL,
+    product_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, product_id),
+    CONSTRAINT fk_wishlist_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_wishlist_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+CREATE TABLE notifications
+(
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT          NOT NULL,
+    type       VARCHAR(30)  NOT NULL,
+    title      VARCHAR(255) NOT NULL,
+    body       TEXT         NOT NULL,
+    link       VARCHAR(255),
+    read_at    TIMESTAMP    NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_notifications_user_read (user_id, read_at),
+    CONSTRAINT fk_notifications_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- Whether the notifications of each type are emailed. Types without a row use their default.
+CREATE TABLE notification_preferences
+(
+    user_id INT         NOT NULL,
+    type    VARCHAR(30) NOT NULL,
+    email   BOOLEAN     NOT NULL,
+    PRIMARY KEY (user_id, type),
+    CONSTRAINT fk_notification_preferences_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+INSERT INTO users (uuid, email, password, phone, role, status_code)
 VALUES (UUID(), 'admin@wilbert.com', '$2a$10$O55dgMZop3M67kLi.GV/RuQQlgNc1G.4yAnqzzzDAJZ02hBR2MVge', '47999999999',
-        TRUE, 1);
+        'super_admin', 1);
+
+INSERT INTO categories (name)
+VALUES ('Prata'),
+       ('Ouro'),
+       ('Amburana'),
+       ('Premium');
 
 INSERT INTO products (name, description, price, stock, status_code)
 VALUES ('Cachaça Ouro da Serra 700ml',
@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"slices"
+	"time"
+)
+
+// APIKey lets scripts call the back office routes on behalf of its owner, limited to its scopes
+// and to what the owner's role still grants. Only the prefix, used to look the key up, and the
+// hash of the whole key are stored.
+type APIKey struct {
+	ID         int64        `json:"id"`
+	UserID     int64        `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"-"`
+	Scopes     []Permission `json:"scopes"`
+	LastUsedAt *time.Time   `json:"last_used_at"`
+	LastUsedIP string       `json:"last_used_ip,omitempty"`
+	ExpiresAt  *time.Time   `json:"expires_at"`
+	RevokedAt  *time.Time   `json:"revoked_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+
+	// OwnerRole and OwnerStatus are the current role and status of the owner
+	OwnerRole   Role       `json:"-"`
+	OwnerStatus UserStatus `json:"-"`
+}
+
+func (k APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+func (k APIKey) HasScope(permission Permission) bool {
+	return slices.Contains(k.Scopes, permission)
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string       `json:"name"`
+	Scopes    []Permission `json:"scopes"`
+	ExpiresAt *time.Time   `json:"expires_at"`
+}
+
+// CreatedAPIKey carries the key itself, shown only once when created
+type CreatedAPIKey struct {
+	Key string `json:"key"`
+	*APIKey
+}
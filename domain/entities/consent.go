@@ -0,0 +1,57 @@
+package entities
+
+import "time"
+
+type ConsentType string
+
+const (
+	ConsentTerms          ConsentType = "terms"
+	ConsentPrivacyPolicy  ConsentType = "privacy_policy"
+	ConsentMarketingEmail ConsentType = "marketing_email"
+	// ConsentMarketingSMS covers marketing messages sent through WhatsApp and SMS
+	ConsentMarketingSMS ConsentType = "marketing_sms"
+)
+
+// Consent records, for compliance audits, a user granting or withdrawing a consent. Consents
+// are never updated: each change adds a new record, and the latest one of each type is in
+// force. Version is the version of the accepted document, for the terms and privacy policy.
+type Consent struct {
+	ID        int64       `json:"id"`
+	UserID    int64       `json:"-"`
+	Type      ConsentType `json:"type"`
+	Version   string      `json:"version,omitempty"`
+	Granted   bool        `json:"granted"`
+	IPAddress string      `json:"ip_address"`
+	UserAgent string      `json:"user_agent"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// LegalVersions are the versions of the terms of use and privacy policy currently published
+type LegalVersions struct {
+	TermsVersion         string `json:"terms_version"`
+	PrivacyPolicyVersion string `json:"privacy_policy_version"`
+}
+
+// ConsentPreferences is the state of the user's consents. RequiresAcceptance tells that the
+// user has not accepted the current version of the terms or of the privacy policy.
+type ConsentPreferences struct {
+	TermsVersion            string        `json:"terms_version,omitempty"`
+	TermsAcceptedAt         *time.Time    `json:"terms_accepted_at,omitempty"`
+	PrivacyPolicyVersion    string        `json:"privacy_policy_version,omitempty"`
+	PrivacyPolicyAcceptedAt *time.Time    `json:"privacy_policy_accepted_at,omitempty"`
+	MarketingEmail          bool          `json:"marketing_email"`
+	MarketingEmailUpdatedAt *time.Time    `json:"marketing_email_updated_at,omitempty"`
+	MarketingSMS            bool          `json:"marketing_sms"`
+	MarketingSMSUpdatedAt   *time.Time    `json:"marketing_sms_updated_at,omitempty"`
+	Current                 LegalVersions `json:"current"`
+	RequiresAcceptance      bool          `json:"requires_acceptance"`
+}
+
+// UpdateConsentRequest changes the user's consents. Omitted fields are left untouched, and the
+// documents can only be accepted in their current version.
+type UpdateConsentRequest struct {
+	TermsVersion         string `json:"terms_version,omitempty"`
+	PrivacyPolicyVersion string `json:"privacy_policy_version,omitempty"`
+	MarketingEmail       *bool  `json:"marketing_email,omitempty"`
+	MarketingSMS         *bool  `json:"marketing_sms,omitempty"`
+}
@@ -0,0 +1,92 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrCouponUnavailable is returned when a coupon can no longer be consumed at checkout
+	ErrCouponUnavailable = errors.New("coupon unavailable")
+
+	// ErrInsufficientStock is returned when a product does not have enough stock at checkout
+	ErrInsufficientStock = errors.New("insufficient stock")
+)
+
+type CouponType string
+
+const (
+	CouponTypePercentage   CouponType = "percentage"
+	CouponTypeFixedAmount  CouponType = "fixed_amount"
+	CouponTypeFreeShipping CouponType = "free_shipping"
+)
+
+func (t CouponType) IsValid() bool {
+	switch t {
+	case CouponTypePercentage, CouponTypeFixedAmount, CouponTypeFreeShipping:
+		return true
+	default:
+		return false
+	}
+}
+
+// Coupon represents a discount code that customers can apply to their carts.
+//
+// MaxUses and MaxUsesPerUser equal to zero mean unlimited usage. When ProductIDs
+// and CategoryIDs are both empty, the coupon applies to every product.
+type Coupon struct {
+	ID             int64      `json:"id"`
+	Code           string     `json:"code"`
+	Type           CouponType `json:"type"`
+	Value          float64    `json:"value"`
+	MinOrderValue  float64    `json:"min_order_value"`
+	StartsAt       time.Time  `json:"starts_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	MaxUses        int        `json:"max_uses"`
+	MaxUsesPerUser int        `json:"max_uses_per_user"`
+	UsedCount      int        `json:"used_count"`
+	ProductIDs     []int64    `json:"product_ids"`
+	CategoryIDs    []int64    `json:"category_ids"`
+	Active         bool       `json:"active"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// AppliesTo reports whether the coupon restrictions allow it to discount the given product.
+func (c *Coupon) AppliesTo(product *Product) bool {
+	if len(c.ProductIDs) == 0 && len(c.CategoryIDs) == 0 {
+		return true
+	}
+
+	for _, id := range c.ProductIDs {
+		if id == product.ID {
+			return true
+		}
+	}
+
+	if product.CategoryID != nil {
+		for _, id := range c.CategoryIDs {
+			if id == *product.CategoryID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type AddCouponRequest struct {
+	Code           string     `json:"code"`
+	Type           CouponType `json:"type"`
+	Value          float64    `json:"value"`
+	MinOrderValue  float64    `json:"min_order_value"`
+	StartsAt       time.Time  `json:"starts_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	MaxUses        int        `json:"max_uses"`
+	MaxUsesPerUser int        `json:"max_uses_per_user"`
+	ProductIDs     []int64    `json:"product_ids"`
+	CategoryIDs    []int64    `json:"category_ids"`
+}
+
+type ApplyCouponRequest struct {
+	Code string `json:"code"`
+}
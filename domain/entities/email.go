@@ -0,0 +1,53 @@
+package entities
+
+import "time"
+
+// Email is a rendered message waiting to be queued in the outbox
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// OutboxStatus is stored in email_outbox.status. Pending emails are retried with an
+// exponential backoff until they are sent, or until they fail too many times and are set
+// aside as failed, for an admin to retry.
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "pending"
+	OutboxStatusSent    OutboxStatus = "sent"
+	OutboxStatusFailed  OutboxStatus = "failed"
+)
+
+func (s OutboxStatus) IsValid() bool {
+	return s == OutboxStatusPending || s == OutboxStatusSent || s == OutboxStatusFailed
+}
+
+// OutboxEmail is an email queued for delivery. The body may hold password reset or
+// verification links, so it is never returned by the API and is cleared once sent.
+type OutboxEmail struct {
+	ID            int64        `json:"id"`
+	Recipient     string       `json:"recipient"`
+	Subject       string       `json:"subject"`
+	Body          string       `json:"-"`
+	Status        OutboxStatus `json:"status"`
+	Attempts      int          `json:"attempts"`
+	NextAttemptAt time.Time    `json:"next_attempt_at"`
+	LastError     string       `json:"last_error,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	SentAt        *time.Time   `json:"sent_at,omitempty"`
+}
+
+type PaginatedOutboxEmails struct {
+	Page   int           `json:"page"`
+	Limit  int           `json:"limit"`
+	Total  int           `json:"total"`
+	Emails []OutboxEmail `json:"emails"`
+}
+
+// EmailTemplates lists the email templates that can be previewed, in each of the locales
+type EmailTemplates struct {
+	Templates []string `json:"templates"`
+	Locales   []string `json:"locales"`
+}
@@ -0,0 +1,18 @@
+package entities
+
+import "slices"
+
+// Locales the emails are translated to. Users without a preference get DefaultLocale.
+const (
+	LocalePortuguese = "pt-BR"
+	LocaleEnglish    = "en"
+	LocaleSpanish    = "es"
+
+	DefaultLocale = LocalePortuguese
+)
+
+var Locales = []string{LocalePortuguese, LocaleEnglish, LocaleSpanish}
+
+func IsValidLocale(locale string) bool {
+	return slices.Contains(Locales, locale)
+}
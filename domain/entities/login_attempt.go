@@ -0,0 +1,9 @@
+package entities
+
+import "time"
+
+// LoginFailures summarizes the failed logins of an email or an IP address within a time window
+type LoginFailures struct {
+	Count  int
+	LastAt *time.Time
+}
@@ -0,0 +1,66 @@
+package entities
+
+import "time"
+
+type NotificationType string
+
+const (
+	// NotificationOrderStatus reports each step of an order. Its email is the order email,
+	// which is always sent.
+	NotificationOrderStatus    NotificationType = "order_status"
+	NotificationBackInStock    NotificationType = "back_in_stock"
+	NotificationReviewApproved NotificationType = "review_approved"
+)
+
+// NotificationTypes are the types whose email the users can turn on and off
+var NotificationTypes = []NotificationType{NotificationBackInStock, NotificationReviewApproved}
+
+func (t NotificationType) IsValid() bool {
+	switch t {
+	case NotificationOrderStatus, NotificationBackInStock, NotificationReviewApproved:
+		return true
+	default:
+		return false
+	}
+}
+
+// EmailByDefault tells whether the notifications of the type are emailed to the users who did
+// not choose
+func (t NotificationType) EmailByDefault() bool {
+	return t == NotificationBackInStock
+}
+
+// Notification is shown in the user's notification center, in the user's language at the time
+// it was created. Link points to the page of the order, product or review it is about.
+type Notification struct {
+	ID        int64            `json:"id"`
+	UserID    int64            `json:"-"`
+	Type      NotificationType `json:"type"`
+	Title     string           `json:"title"`
+	Body      string           `json:"body"`
+	Link      string           `json:"link,omitempty"`
+	ReadAt    *time.Time       `json:"read_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type PaginatedNotifications struct {
+	Page          int            `json:"page"`
+	Limit         int            `json:"limit"`
+	Total         int            `json:"total"`
+	Unread        int            `json:"unread"`
+	Notifications []Notification `json:"notifications"`
+}
+
+type UnreadNotifications struct {
+	Unread int `json:"unread"`
+}
+
+// NotificationPreference tells whether the notifications of the type are also emailed
+type NotificationPreference struct {
+	Type  NotificationType `json:"type"`
+	Email bool             `json:"email"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences"`
+}
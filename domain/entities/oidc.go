@@ -0,0 +1,33 @@
+package entities
+
+import "time"
+
+// OIDCState tracks an OpenID Connect login between the redirect to the provider and the
+// callback. It can only be used once. Only the hash of the state sent to the provider is stored.
+type OIDCState struct {
+	StateHash    string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// OIDCClaims are the claims of a verified ID token used to find or create the user
+type OIDCClaims struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+}
+
+// UserIdentity links a user to their account on an OpenID Connect provider
+type UserIdentity struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"-"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
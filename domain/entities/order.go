@@ -0,0 +1,63 @@
+package entities
+
+// OrderStatus is stored in orders.status. New orders wait for payment; the back office then
+// moves them along orderTransitions, and each change is emailed to the customer.
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending: {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:    {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped: {OrderStatusDelivered},
+}
+
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanBecome tells whether an order can move from this status to the next one
+func (s OrderStatus) CanBecome(next OrderStatus) bool {
+	for _, status := range orderTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ShippingAddress is where an order is delivered. It is copied into the order at checkout, so
+// that later changes do not alter past orders.
+type ShippingAddress struct {
+	RecipientName string `json:"recipient_name"`
+	ZipCode       string `json:"zip_code"`
+	Street        string `json:"street"`
+	Number        string `json:"number"`
+	Complement    string `json:"complement,omitempty"`
+	District      string `json:"district"`
+	City          string `json:"city"`
+	State         string `json:"state"`
+}
+
+type CheckoutRequest struct {
+	ShippingAddress ShippingAddress `json:"shipping_address"`
+}
+
+// UpdateOrderStatusRequest moves an order to the next status. The tracking code is required
+// when the order is shipped.
+type UpdateOrderStatusRequest struct {
+	Status       OrderStatus `json:"status"`
+	TrackingCode string      `json:"tracking_code,omitempty"`
+}
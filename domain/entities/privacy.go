@@ -0,0 +1,37 @@
+package entities
+
+import "time"
+
+// UserDataExport gathers the personal data the store keeps about a user, delivered on the
+// user's request as required by the LGPD
+type UserDataExport struct {
+	GeneratedAt      time.Time         `json:"generated_at"`
+	Profile          PublicUser        `json:"profile"`
+	AgeConfirmations []AgeConfirmation `json:"age_confirmations"`
+	Consents         []Consent         `json:"consents"`
+	LinkedAccounts   []UserIdentity    `json:"linked_accounts"`
+	Sessions         []Session         `json:"sessions"`
+	APIKeys          []APIKey          `json:"api_keys"`
+	Cart             []*CartItem       `json:"cart"`
+	Wishlist         []WishlistItem    `json:"wishlist"`
+	Orders           []Order           `json:"orders"`
+	Reviews          []Review          `json:"reviews"`
+	Notifications    []Notification    `json:"notifications"`
+}
+
+// Sections splits the export into named parts, one file each in the ZIP archive
+func (e UserDataExport) Sections() map[string]any {
+	return map[string]any{
+		"profile":           e.Profile,
+		"age_confirmations": e.AgeConfirmations,
+		"consents":          e.Consents,
+		"linked_accounts":   e.LinkedAccounts,
+		"sessions":          e.Sessions,
+		"api_keys":          e.APIKeys,
+		"cart":              e.Cart,
+		"wishlist":          e.Wishlist,
+		"orders":            e.Orders,
+		"reviews":           e.Reviews,
+		"notifications":     e.Notifications,
+	}
+}
@@ -6,35 +6,83 @@ import (
 )
 
 // Product represents a product in the catalog.
+//
+// Price is the current selling price. While the product is on sale, OriginalPrice holds its
+// regular price, so the storefront can display "de R$ X por R$ Y". AverageRating and
+// RatingCount only consider approved reviews.
 type Product struct {
-	ID          int64    `json:"id"`
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Photos      []string `json:"photos"`
-	Price       float32  `json:"price"`
-	Stock       int      `json:"stock"`
+	ID            int64    `json:"id"`
+	CategoryID    *int64   `json:"category_id,omitempty"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	Photos        []string `json:"photos"`
+	Price         float32  `json:"price"`
+	OriginalPrice *float32 `json:"original_price,omitempty"`
+	Stock         int      `json:"stock"`
+	AverageRating float64  `json:"average_rating"`
+	RatingCount   int      `json:"rating_count"`
 }
 
 // CartItem links a user to a product with a quantity.
 type CartItem struct {
-	ID         int64     `json:"id"`
-	UserID     int64     `json:"user_id"`
-	Product    *Product  `json:"product,omitempty"`
-	ProductID  int64     `json:"product_id"`
-	Quantity   int       `json:"quantity"`
-	CreatedAt  time.Time `json:"created_at"`
-	ModifiedAt time.Time `json:"modified_at"`
+	ID         int64             `json:"id"`
+	UserID     int64             `json:"user_id"`
+	Product    *Product          `json:"product,omitempty"`
+	ProductID  int64             `json:"product_id"`
+	Quantity   int               `json:"quantity"`
+	UnitPrice  float64           `json:"unit_price"`
+	Subtotal   float64           `json:"subtotal"`
+	Discount   float64           `json:"discount"`
+	Total      float64           `json:"total"`
+	Promotion  *AppliedPromotion `json:"promotion,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	ModifiedAt time.Time         `json:"modified_at"`
+}
+
+// Cart is the priced view of a user's cart, with the discounts of the automatic
+// promotions and of the applied coupon.
+type Cart struct {
+	Items             []*CartItem        `json:"items"`
+	Promotions        []AppliedPromotion `json:"promotions"`
+	Coupon            *Coupon            `json:"coupon,omitempty"`
+	CouponMessage     string             `json:"coupon_message,omitempty"`
+	Subtotal          float64            `json:"subtotal"`
+	PromotionDiscount float64            `json:"promotion_discount"`
+	CouponDiscount    float64            `json:"coupon_discount"`
+	Discount          float64            `json:"discount"`
+	FreeShipping      bool               `json:"free_shipping"`
+	Total             float64            `json:"total"`
+}
+
+// AbandonedCart is a cart untouched since ModifiedAt, the last time one of its items changed,
+// with the reminders already sent for it
+type AbandonedCart struct {
+	UserID        int64
+	ModifiedAt    time.Time
+	RemindersSent int
+}
+
+// CartReminderPolicy tells when a cart counts as abandoned, and how many reminders to send.
+// Reminders are AbandonedAfter apart.
+type CartReminderPolicy struct {
+	AbandonedAfter time.Duration
+	MaxReminders   int
 }
 
 // Order represents a completed purchase made by a user.
 type Order struct {
-	ID          int64       `json:"id"`
-	UserID      int64       `json:"user_id"`
-	TotalAmount float64     `json:"total_amount"`
-	Status      string      `json:"status"`
-	CreatedAt   time.Time   `json:"created_at"`
-	ModifiedAt  time.Time   `json:"modified_at"`
-	Items       []OrderItem `json:"items,omitempty"`
+	ID              int64            `json:"id"`
+	UserID          int64            `json:"user_id"`
+	CouponID        *int64           `json:"coupon_id,omitempty"`
+	Subtotal        float64          `json:"subtotal"`
+	Discount        float64          `json:"discount"`
+	TotalAmount     float64          `json:"total_amount"`
+	Status          OrderStatus      `json:"status"`
+	TrackingCode    string           `json:"tracking_code,omitempty"`
+	ShippingAddress *ShippingAddress `json:"shipping_address,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	ModifiedAt      time.Time        `json:"modified_at"`
+	Items           []OrderItem      `json:"items,omitempty"`
 }
 
 // OrderItem represents a single product inside an order.
@@ -49,6 +97,7 @@ type OrderItem struct {
 	ModifiedAt time.Time `json:"modified_at,omitempty"`
 }
 type AddProductRequest struct {
+	CategoryID  *int64  `json:"category_id"`
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
 	Price       float32 `json:"price"`
@@ -69,6 +118,7 @@ type DeleteProductResponse struct {
 }
 
 type UpdateProductRequest struct {
+	CategoryID  *int64                  `json:"category_id"`
 	Name        string                  `json:"name"`
 	Description string                  `json:"description"`
 	Price       float32                 `json:"price"`
@@ -0,0 +1,35 @@
+package entities
+
+import "time"
+
+type ProductPriceType string
+
+const (
+	// ProductPriceTypeRegular permanently changes the product price once it starts
+	ProductPriceTypeRegular ProductPriceType = "regular"
+
+	// ProductPriceTypeSale temporarily changes the product price between its start and end
+	ProductPriceTypeSale ProductPriceType = "sale"
+)
+
+// ProductPrice is an entry of a product price history, either already applied or scheduled.
+type ProductPrice struct {
+	ID          int64            `json:"id"`
+	ProductID   int64            `json:"product_id"`
+	Type        ProductPriceType `json:"type"`
+	Price       float64          `json:"price"`
+	StartsAt    time.Time        `json:"starts_at"`
+	EndsAt      *time.Time       `json:"ends_at,omitempty"`
+	AppliedAt   *time.Time       `json:"applied_at,omitempty"`
+	EndedAt     *time.Time       `json:"ended_at,omitempty"`
+	CancelledAt *time.Time       `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// SchedulePriceRequest schedules a new regular price or, when EndsAt is set, a temporary sale price.
+// A missing StartsAt applies the price immediately.
+type SchedulePriceRequest struct {
+	Price    float64    `json:"price"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
@@ -0,0 +1,116 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+type PromotionType string
+
+const (
+	// PromotionTypeBuyXGetY charges only PayQuantity for every BuyQuantity units ("leve 3 pague 2")
+	PromotionTypeBuyXGetY PromotionType = "buy_x_get_y"
+
+	// PromotionTypeQuantityTier discounts a percentage of a product line based on its quantity
+	PromotionTypeQuantityTier PromotionType = "quantity_tier"
+
+	// PromotionTypeKit sells a fixed set of products for a fixed price
+	PromotionTypeKit PromotionType = "kit"
+)
+
+func (t PromotionType) IsValid() bool {
+	switch t {
+	case PromotionTypeBuyXGetY, PromotionTypeQuantityTier, PromotionTypeKit:
+		return true
+	default:
+		return false
+	}
+}
+
+// Promotion is an admin-managed rule evaluated automatically when pricing carts.
+//
+// Buy-X-get-Y and quantity tier promotions apply to the products of CategoryID and to the
+// products listed in Products. Kit promotions require every product in Products, each with
+// its own quantity, and sell the set for KitPrice.
+type Promotion struct {
+	ID          int64              `json:"id"`
+	Name        string             `json:"name"`
+	Type        PromotionType      `json:"type"`
+	CategoryID  *int64             `json:"category_id,omitempty"`
+	Products    []PromotionProduct `json:"products"`
+	Tiers       []PromotionTier    `json:"tiers"`
+	BuyQuantity int                `json:"buy_quantity,omitempty"`
+	PayQuantity int                `json:"pay_quantity,omitempty"`
+	KitPrice    float64            `json:"kit_price,omitempty"`
+	Priority    int                `json:"priority"`
+	StartsAt    time.Time          `json:"starts_at"`
+	EndsAt      *time.Time         `json:"ends_at,omitempty"`
+	Active      bool               `json:"active"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+type PromotionProduct struct {
+	ProductID int64 `json:"product_id"`
+	Quantity  int   `json:"quantity"`
+}
+
+type PromotionTier struct {
+	MinQuantity     int     `json:"min_quantity"`
+	DiscountPercent float64 `json:"discount_percent"`
+}
+
+// AppliesTo reports whether the given product is covered by the promotion scope
+func (p *Promotion) AppliesTo(product *Product) bool {
+	if p.Type != PromotionTypeKit && p.CategoryID != nil && product.CategoryID != nil && *p.CategoryID == *product.CategoryID {
+		return true
+	}
+
+	for _, pp := range p.Products {
+		if pp.ProductID == product.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description returns a customer friendly explanation of the promotion rule
+func (p *Promotion) Description() string {
+	switch p.Type {
+	case PromotionTypeBuyXGetY:
+		return fmt.Sprintf("Leve %d pague %d", p.BuyQuantity, p.PayQuantity)
+	case PromotionTypeQuantityTier:
+		if len(p.Tiers) == 0 {
+			return p.Name
+		}
+		tier := p.Tiers[0]
+		return fmt.Sprintf("A partir de %d unidades, %.0f%% de desconto", tier.MinQuantity, tier.DiscountPercent)
+	case PromotionTypeKit:
+		return fmt.Sprintf("Kit %s por R$ %.2f", p.Name, p.KitPrice)
+	default:
+		return p.Name
+	}
+}
+
+// AppliedPromotion explains a promotion discount granted to a cart or to a cart line
+type AppliedPromotion struct {
+	PromotionID int64         `json:"promotion_id"`
+	Name        string        `json:"name"`
+	Type        PromotionType `json:"type"`
+	Description string        `json:"description"`
+	Discount    float64       `json:"discount"`
+}
+
+type AddPromotionRequest struct {
+	Name        string             `json:"name"`
+	Type        PromotionType      `json:"type"`
+	CategoryID  *int64             `json:"category_id"`
+	Products    []PromotionProduct `json:"products"`
+	Tiers       []PromotionTier    `json:"tiers"`
+	BuyQuantity int                `json:"buy_quantity"`
+	PayQuantity int                `json:"pay_quantity"`
+	KitPrice    float64            `json:"kit_price"`
+	Priority    int                `json:"priority"`
+	StartsAt    time.Time          `json:"starts_at"`
+	EndsAt      *time.Time         `json:"ends_at"`
+}
@@ -0,0 +1,49 @@
+package entities
+
+import "time"
+
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "pending"
+	ReviewStatusApproved ReviewStatus = "approved"
+	ReviewStatusHidden   ReviewStatus = "hidden"
+)
+
+func (s ReviewStatus) IsValid() bool {
+	switch s {
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusHidden:
+		return true
+	default:
+		return false
+	}
+}
+
+// Review is a rating from 1 to 5, with an optional comment, left by a customer who bought the product.
+// Only approved reviews are public.
+type Review struct {
+	ID         int64        `json:"id"`
+	ProductID  int64        `json:"product_id"`
+	UserID     int64        `json:"user_id"`
+	Rating     int          `json:"rating"`
+	Comment    string       `json:"comment"`
+	Status     ReviewStatus `json:"status"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ModifiedAt time.Time    `json:"modified_at"`
+}
+
+type ReviewRequest struct {
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment"`
+}
+
+type ModerateReviewRequest struct {
+	Status ReviewStatus `json:"status"`
+}
+
+type PaginatedReviews struct {
+	Page    int      `json:"page"`
+	Limit   int      `json:"limit"`
+	Total   int      `json:"total"`
+	Reviews []Review `json:"reviews"`
+}
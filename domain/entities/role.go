@@ -0,0 +1,116 @@
+package entities
+
+import "slices"
+
+// Role is stored in users.role. Each role grants a fixed set of permissions; customers have none.
+type Role string
+
+const (
+	RoleCustomer      Role = "customer"
+	RoleCatalogEditor Role = "catalog_editor"
+	RoleOrderOperator Role = "order_operator"
+	RoleSupport       Role = "support"
+	RoleSuperAdmin    Role = "super_admin"
+)
+
+// Permission names an action on a resource, checked by the routes that perform it
+type Permission string
+
+const (
+	PermissionProductWrite   Permission = "product:write"
+	PermissionProductDelete  Permission = "product:delete"
+	PermissionPriceWrite     Permission = "price:write"
+	PermissionPromotionWrite Permission = "promotion:write"
+	PermissionCouponWrite    Permission = "coupon:write"
+	PermissionReviewModerate Permission = "review:moderate"
+	PermissionOrderRead      Permission = "order:read"
+	PermissionOrderWrite     Permission = "order:write"
+	PermissionUserRead       Permission = "user:read"
+	PermissionUserWrite      Permission = "user:write"
+	PermissionUserDelete     Permission = "user:delete"
+	PermissionSessionRevoke  Permission = "session:revoke"
+	PermissionRoleAssign     Permission = "role:assign"
+	PermissionEmailManage    Permission = "email:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleCustomer: {},
+	RoleCatalogEditor: {
+		PermissionProductWrite,
+		PermissionProductDelete,
+		PermissionPriceWrite,
+		PermissionPromotionWrite,
+		PermissionCouponWrite,
+	},
+	RoleOrderOperator: {
+		PermissionOrderRead,
+		PermissionOrderWrite,
+	},
+	RoleSupport: {
+		PermissionOrderRead,
+		PermissionReviewModerate,
+		PermissionUserRead,
+		PermissionUserWrite,
+		PermissionSessionRevoke,
+		PermissionEmailManage,
+	},
+	RoleSuperAdmin: {
+		PermissionProductWrite,
+		PermissionProductDelete,
+		PermissionPriceWrite,
+		PermissionPromotionWrite,
+		PermissionCouponWrite,
+		PermissionReviewModerate,
+		PermissionOrderRead,
+		PermissionOrderWrite,
+		PermissionUserRead,
+		PermissionUserWrite,
+		PermissionUserDelete,
+		PermissionSessionRevoke,
+		PermissionRoleAssign,
+		PermissionEmailManage,
+	},
+}
+
+// Roles lists every role, from the least to the most privileged
+var Roles = []Role{RoleCustomer, RoleCatalogEditor, RoleOrderOperator, RoleSupport, RoleSuperAdmin}
+
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+func (r Role) Permissions() []Permission {
+	return rolePermissions[r]
+}
+
+func (r Role) HasPermission(permission Permission) bool {
+	return slices.Contains(rolePermissions[r], permission)
+}
+
+// Covers tells whether the role grants every permission of the other role, so that staff
+// cannot act on the accounts of those with more privileges
+func (r Role) Covers(other Role) bool {
+	for _, permission := range rolePermissions[other] {
+		if !r.HasPermission(permission) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsStaff tells whether the role grants any permission, that is, access to the back office
+func (r Role) IsStaff() bool {
+	return len(rolePermissions[r]) > 0
+}
+
+// RoleInfo describes a role and its permissions to the back office
+type RoleInfo struct {
+	Role        Role         `json:"role"`
+	Permissions []Permission `json:"permissions"`
+}
+
+type AssignRoleRequest struct {
+	Role Role `json:"role"`
+}
@@ -0,0 +1,28 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a login on a device. Its ID is the family of the refresh tokens issued to it, and
+// the access tokens carry it, so revoking the session logs the device out.
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     int64      `json:"-"`
+	Device     string     `json:"device"`
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"-"`
+	// Current tells whether the session is the one making the request
+	Current bool `json:"current"`
+}
+
+// SessionClient describes the device starting or refreshing a session
+type SessionClient struct {
+	IPAddress string
+	UserAgent string
+}
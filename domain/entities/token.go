@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrRefreshTokenReused is returned when a refresh token that was already rotated is used again
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// RefreshToken is a server-side record of a refresh token. Every rotation creates a new token
+// in the same family and revokes the previous one, along with the access token issued with it.
+type RefreshToken struct {
+	ID                   int64
+	UserID               int64
+	FamilyID             uuid.UUID
+	TokenHash            string
+	AccessTokenID        uuid.UUID
+	AccessTokenExpiresAt time.Time
+	ExpiresAt            time.Time
+	RevokedAt            *time.Time
+	CreatedAt            time.Time
+}
+
+// AuthTokens is the pair of tokens handed to the client on login, registration and refresh
+type AuthTokens struct {
+	AccessToken  string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
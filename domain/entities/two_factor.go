@@ -0,0 +1,38 @@
+package entities
+
+import "time"
+
+// TwoFactor is the TOTP secret of a user. It stays pending, with a nil EnabledAt, until the user
+// confirms the enrollment with a valid code. LastUsedStep keeps a code from being used twice.
+type TwoFactor struct {
+	UserID       int64
+	Secret       string
+	EnabledAt    *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+func (t TwoFactor) IsEnabled() bool {
+	return t.EnabledAt != nil
+}
+
+// TwoFactorSetup is returned on enrollment so the user can add the account to an authenticator app
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QRCode string `json:"qr_code"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// RecoveryCodes are shown only once, since only their hashes are stored
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
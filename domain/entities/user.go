@@ -1,21 +1,142 @@
 package entities
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserStatus is stored in users.status_code. New accounts stay unverified until the user
+// confirms their email. Disabled accounts cannot log in. Erased accounts were anonymized on the
+// user's request and are only kept for their orders.
+type UserStatus int
+
+const (
+	UserStatusUnverified UserStatus = iota
+	UserStatusVerified
+	UserStatusDisabled
+	UserStatusErased
+)
 
 type User struct {
-	ID       int       `json:"id"`
-	UUID     uuid.UUID `json:"uuid"`
-	Email    string    `json:"email"`
-	Password string    `json:"password"`
-	Phone    string    `json:"phone"`
-	IsAdm    bool      `json:"is_adm"`
+	ID            int        `json:"id"`
+	UUID          uuid.UUID  `json:"uuid"`
+	Email         string     `json:"email"`
+	Password      string     `json:"-"`
+	Name          string     `json:"name"`
+	Phone         string     `json:"phone"`
+	Role          Role       `json:"role"`
+	Status        UserStatus `json:"status"`
+	CPF           *string    `json:"cpf,omitempty"`
+	BirthDate     *time.Time `json:"birth_date,omitempty"`
+	AgeVerifiedAt *time.Time `json:"age_verified_at,omitempty"`
+	// Avatar is the filename of the profile picture, stored along with the product images
+	Avatar string `json:"-"`
+	// Locale is the language the user's emails are written in
+	Locale string `json:"locale"`
+}
+
+// DisplayName is how the user is addressed, by name or else by email
+func (u User) DisplayName() string {
+	if u.Name != "" {
+		return u.Name
+	}
+
+	return u.Email
+}
+
+func (u User) IsVerified() bool {
+	return u.Status == UserStatusVerified
+}
+
+// IsDisabled tells whether the user cannot log in, which erased users never can
+func (u User) IsDisabled() bool {
+	return u.Status == UserStatusDisabled || u.Status == UserStatusErased
+}
+
+func (u User) IsErased() bool {
+	return u.Status == UserStatusErased
+}
+
+// PublicUser is the user as returned by the API, without credentials
+type PublicUser struct {
+	ID            int        `json:"id"`
+	UUID          uuid.UUID  `json:"uuid"`
+	Email         string     `json:"email"`
+	Name          string     `json:"name"`
+	Phone         string     `json:"phone"`
+	Role          Role       `json:"role"`
+	Status        UserStatus `json:"status"`
+	CPF           *string    `json:"cpf,omitempty"`
+	BirthDate     *time.Time `json:"birth_date,omitempty"`
+	AgeVerifiedAt *time.Time `json:"age_verified_at,omitempty"`
+	AvatarURL     string     `json:"avatar_url,omitempty"`
+	Locale        string     `json:"locale"`
+}
+
+// Public returns the user without credentials. avatarURL is the URL of the user's avatar, if
+// any.
+func (u User) Public(avatarURL string) PublicUser {
+	return PublicUser{
+		ID:            u.ID,
+		UUID:          u.UUID,
+		Email:         u.Email,
+		Name:          u.Name,
+		Phone:         u.Phone,
+		Role:          u.Role,
+		Status:        u.Status,
+		CPF:           u.CPF,
+		BirthDate:     u.BirthDate,
+		AgeVerifiedAt: u.AgeVerifiedAt,
+		AvatarURL:     avatarURL,
+		Locale:        u.Locale,
+	}
+}
+
+// UserFilter narrows the user listing of the back office. Search matches the email or phone.
+type UserFilter struct {
+	Search string
+	Role   Role
+	Status *UserStatus
+	Limit  int
+	Offset int
+}
+
+type PaginatedUsers struct {
+	Page  int          `json:"page"`
+	Limit int          `json:"limit"`
+	Total int          `json:"total"`
+	Users []PublicUser `json:"users"`
+}
+
+// UpdateProfileRequest changes the user's profile. CPF and BirthDate ("2000-01-31") are left
+// untouched when omitted and removed when empty, and Locale is left untouched when omitted.
+type UpdateProfileRequest struct {
+	Name      string  `json:"name"`
+	CPF       *string `json:"cpf,omitempty"`
+	BirthDate *string `json:"birth_date,omitempty"`
+	Locale    *string `json:"locale,omitempty"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
 }
 
 type UserCredentials struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Name     string `json:"name,omitempty"`
 	Phone    string `json:"phone,omitempty"`
-	IsAdm    bool   `json:"is_adm,omitempty"`
+	// Locale is the language of the user's emails, pt-BR by default
+	Locale string `json:"locale,omitempty"`
+	// OTP is the authenticator code, or a recovery code, of users with two-factor authentication
+	OTP string `json:"otp,omitempty"`
+	// On registration, the versions of the terms and privacy policy the user accepted, which
+	// must be the current ones, and the marketing channels the user opted in to
+	TermsVersion         string `json:"terms_version,omitempty"`
+	PrivacyPolicyVersion string `json:"privacy_policy_version,omitempty"`
+	MarketingEmail       bool   `json:"marketing_email,omitempty"`
+	MarketingSMS         bool   `json:"marketing_sms,omitempty"`
 }
 
 type ChangePasswordRequest struct {
@@ -24,3 +145,51 @@ type ChangePasswordRequest struct {
 	NewPassword             string `json:"new_password"`
 	NewPasswordConfirmation string `json:"new_password_confirmation"`
 }
+
+// PasswordReset is a single-use password reset token sent by email. Only its hash is stored.
+type PasswordReset struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token                   string `json:"token"`
+	NewPassword             string `json:"new_password"`
+	NewPasswordConfirmation string `json:"new_password_confirmation"`
+}
+
+// EmailVerification is a single-use email verification token. Only its hash is stored.
+type EmailVerification struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// AgeConfirmation records, for compliance audits, when and from where a user declared to be of
+// legal age to buy alcoholic beverages
+type AgeConfirmation struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	CPF        string    `json:"cpf"`
+	BirthDate  time.Time `json:"birth_date"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	AcceptedAt time.Time `json:"accepted_at"`
+}
+
+type AgeVerificationRequest struct {
+	CPF         string `json:"cpf"`
+	BirthDate   string `json:"birth_date"`
+	AcceptTerms bool   `json:"accept_terms"`
+}
@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+// WishlistItem is a product favorited by a user
+type WishlistItem struct {
+	UserID    int64     `json:"user_id"`
+	ProductID int64     `json:"product_id"`
+	Product   *Product  `json:"product,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
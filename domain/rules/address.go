@@ -0,0 +1,33 @@
+package rules
+
+import (
+	"slices"
+	"strings"
+)
+
+// Address rules
+const (
+	ZipCodeDigits       = 8
+	AddressFieldMaxLen  = 100
+	AddressNumberMaxLen = 20
+)
+
+var brazilianStates = []string{
+	"AC", "AL", "AP", "AM", "BA", "CE", "DF", "ES", "GO", "MA", "MT", "MS", "MG", "PA",
+	"PB", "PR", "PE", "PI", "RJ", "RN", "RS", "RO", "RR", "SC", "SP", "SE", "TO",
+}
+
+// NormalizeZipCode strips the punctuation of a CEP, keeping only its digits
+func NormalizeZipCode(zipCode string) string {
+	return onlyDigits(zipCode)
+}
+
+// IsValidZipCode tells whether the CEP, formatted or not, has 8 digits
+func IsValidZipCode(zipCode string) bool {
+	return len(NormalizeZipCode(zipCode)) == ZipCodeDigits
+}
+
+// IsValidState tells whether the state is the abbreviation of a Brazilian state, as in "SP"
+func IsValidState(state string) bool {
+	return slices.Contains(brazilianStates, strings.ToUpper(state))
+}
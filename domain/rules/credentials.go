@@ -3,6 +3,7 @@ package rules
 import (
 	"net/mail"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Password rules
@@ -14,7 +15,7 @@ const (
 // Name rules
 const (
 	NameMinLetters = 3
-	NameMaxLetters = 32
+	NameMaxLetters = 100
 )
 
 func IsValidEmail(email string) bool {
@@ -41,10 +42,19 @@ func IsValidPassword(password string) bool {
 	return letters >= PasswordMinLetters && letters <= PasswordMaxLetters && number && special
 }
 
+// IsValidName tells whether the name has a valid length and only letters, spaces, apostrophes,
+// hyphens and periods, as in "Maria D'Ávila" or "João P. Silva-Neto"
 func IsValidName(name string) bool {
-	if len(name) < NameMinLetters || len(name) > NameMaxLetters {
+	letters := utf8.RuneCountInString(name)
+	if letters < NameMinLetters || letters > NameMaxLetters {
 		return false
 	}
 
+	for _, char := range name {
+		if !unicode.IsLetter(char) && char != ' ' && char != '\'' && char != '-' && char != '.' {
+			return false
+		}
+	}
+
 	return true
 }
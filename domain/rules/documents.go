@@ -0,0 +1,74 @@
+package rules
+
+import (
+	"strings"
+	"time"
+)
+
+// LegalAge is the minimum age to buy alcoholic beverages in Brazil
+const LegalAge = 18
+
+// NormalizeCPF strips the punctuation of a CPF, keeping only its digits
+func NormalizeCPF(cpf string) string {
+	return onlyDigits(cpf)
+}
+
+// onlyDigits keeps the ASCII digits of s. Other Unicode digits, such as fullwidth ones, are
+// dropped, since they are neither valid in documents nor one byte long.
+func onlyDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// IsValidCPF validates the length and both check digits of a CPF, formatted or not
+func IsValidCPF(cpf string) bool {
+	cpf = NormalizeCPF(cpf)
+	if len(cpf) != 11 {
+		return false
+	}
+
+	repeated := true
+	for i := 1; i < len(cpf); i++ {
+		if cpf[i] != cpf[0] {
+			repeated = false
+			break
+		}
+	}
+
+	if repeated {
+		return false
+	}
+
+	digits := make([]int, len(cpf))
+	for i, r := range cpf {
+		digits[i] = int(r - '0')
+	}
+
+	return cpfCheckDigit(digits[:9]) == digits[9] && cpfCheckDigit(digits[:10]) == digits[10]
+}
+
+// cpfCheckDigit computes the check digit following the given digits
+func cpfCheckDigit(digits []int) int {
+	sum := 0
+	weight := len(digits) + 1
+	for _, digit := range digits {
+		sum += digit * weight
+		weight--
+	}
+
+	rest := sum * 10 % 11
+	if rest == 10 {
+		return 0
+	}
+
+	return rest
+}
+
+// IsAdult tells whether someone born on the given date is of legal age at the given time
+func IsAdult(birthDate, now time.Time) bool {
+	return !birthDate.AddDate(LegalAge, 0, 0).After(now)
+}
@@ -0,0 +1,23 @@
+package rules
+
+// Order rules
+const (
+	TrackingCodeMinLen = 4
+	TrackingCodeMaxLen = 50
+)
+
+// IsValidTrackingCode tells whether the tracking code, already in upper case, is made of letters,
+// digits and hyphens, as the carriers' codes such as "AA123456789BR" are, and fits its column
+func IsValidTrackingCode(code string) bool {
+	if len(code) < TrackingCodeMinLen || len(code) > TrackingCodeMaxLen {
+		return false
+	}
+
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+
+	return true
+}
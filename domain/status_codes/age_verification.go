@@ -0,0 +1,41 @@
+package status_codes
+
+type VerifyAgeStatus int
+
+const (
+	VerifyAgeStatusSuccess VerifyAgeStatus = iota
+	VerifyAgeStatusInvalidCPF
+	VerifyAgeStatusCPFAlreadyExists
+	VerifyAgeStatusInvalidBirthDate
+	VerifyAgeStatusUnderage
+	VerifyAgeStatusTermsNotAccepted
+	VerifyAgeStatusAlreadyVerified
+	VerifyAgeStatusError
+)
+
+func (s VerifyAgeStatus) String() string {
+	switch s {
+	case VerifyAgeStatusSuccess:
+		return "Maioridade confirmada com sucesso!"
+	case VerifyAgeStatusInvalidCPF:
+		return "CPF inválido"
+	case VerifyAgeStatusCPFAlreadyExists:
+		return "CPF já cadastrado em outra conta"
+	case VerifyAgeStatusInvalidBirthDate:
+		return "Data de nascimento inválida"
+	case VerifyAgeStatusUnderage:
+		return "A venda de bebidas alcoólicas é proibida para menores de 18 anos"
+	case VerifyAgeStatusTermsNotAccepted:
+		return "É necessário declarar que você é maior de idade"
+	case VerifyAgeStatusAlreadyVerified:
+		return "Maioridade já confirmada"
+	case VerifyAgeStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s VerifyAgeStatus) Int() int {
+	return int(s)
+}
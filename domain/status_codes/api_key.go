@@ -0,0 +1,63 @@
+package status_codes
+
+type CreateAPIKeyStatus int
+
+const (
+	CreateAPIKeyStatusSuccess CreateAPIKeyStatus = iota
+	CreateAPIKeyStatusInvalidName
+	CreateAPIKeyStatusInvalidScopes
+	CreateAPIKeyStatusInvalidExpiration
+	CreateAPIKeyStatusTwoFactorRequired
+	CreateAPIKeyStatusTooManyKeys
+	CreateAPIKeyStatusError
+)
+
+func (s CreateAPIKeyStatus) String() string {
+	switch s {
+	case CreateAPIKeyStatusSuccess:
+		return "Chave de API criada. Guarde-a em local seguro, ela não será exibida novamente"
+	case CreateAPIKeyStatusInvalidName:
+		return "Nome da chave inválido"
+	case CreateAPIKeyStatusInvalidScopes:
+		return "Permissões inválidas. Informe ao menos uma permissão do seu cargo"
+	case CreateAPIKeyStatusInvalidExpiration:
+		return "A data de expiração deve estar no futuro"
+	case CreateAPIKeyStatusTwoFactorRequired:
+		return "Ative a autenticação em dois fatores para criar chaves de API"
+	case CreateAPIKeyStatusTooManyKeys:
+		return "Limite de chaves de API atingido. Revogue uma chave antes de criar outra"
+	case CreateAPIKeyStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s CreateAPIKeyStatus) Int() int {
+	return int(s)
+}
+
+type RevokeAPIKeyStatus int
+
+const (
+	RevokeAPIKeyStatusSuccess RevokeAPIKeyStatus = iota
+	RevokeAPIKeyStatusNotFound
+	RevokeAPIKeyStatusError
+)
+
+func (s RevokeAPIKeyStatus) String() string {
+	switch s {
+	case RevokeAPIKeyStatusSuccess:
+		return "Chave de API revogada"
+	case RevokeAPIKeyStatusNotFound:
+		return "Chave de API não encontrada"
+	case RevokeAPIKeyStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s RevokeAPIKeyStatus) Int() int {
+	return int(s)
+}
@@ -16,6 +16,11 @@ const (
 	BuyProductsStatusCartEmpty
 	BuyProductsStatusInvalidProduct
 	BuyProductsStatusOutOfStock
+	BuyProductsStatusCouponUnavailable
+	BuyProductsStatusUnverifiedUser
+	BuyProductsStatusAgeNotVerified
+	BuyProductsStatusInvalidAddress
+	BuyProductsStatusTermsNotAccepted
 	BuyProductsStatusError
 )
 
@@ -50,6 +55,16 @@ func (s BuyProductsStatus) String() string {
 		return "Carrinho contém produtos inválidos"
 	case BuyProductsStatusOutOfStock:
 		return "Produtos sem estoque"
+	case BuyProductsStatusCouponUnavailable:
+		return "O cupom aplicado não está mais disponível"
+	case BuyProductsStatusUnverifiedUser:
+		return "Confirme seu e-mail antes de finalizar a compra"
+	case BuyProductsStatusAgeNotVerified:
+		return "Confirme sua maioridade antes de finalizar a compra"
+	case BuyProductsStatusInvalidAddress:
+		return "Endereço de entrega inválido"
+	case BuyProductsStatusTermsNotAccepted:
+		return "Aceite os termos de uso e a política de privacidade antes de finalizar a compra"
 	case BuyProductsStatusError:
 		return "Erro interno no servidor"
 	default:
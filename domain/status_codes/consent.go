@@ -0,0 +1,32 @@
+package status_codes
+
+type UpdateConsentStatus int
+
+const (
+	UpdateConsentStatusSuccess UpdateConsentStatus = iota
+	UpdateConsentStatusOutdatedTerms
+	UpdateConsentStatusOutdatedPrivacyPolicy
+	UpdateConsentStatusNothingToUpdate
+	UpdateConsentStatusError
+)
+
+func (s UpdateConsentStatus) String() string {
+	switch s {
+	case UpdateConsentStatusSuccess:
+		return "Preferências atualizadas com sucesso"
+	case UpdateConsentStatusOutdatedTerms:
+		return "A versão informada dos termos de uso não é a atual"
+	case UpdateConsentStatusOutdatedPrivacyPolicy:
+		return "A versão informada da política de privacidade não é a atual"
+	case UpdateConsentStatusNothingToUpdate:
+		return "Nenhuma preferência informada"
+	case UpdateConsentStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s UpdateConsentStatus) Int() int {
+	return int(s)
+}
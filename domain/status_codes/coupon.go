@@ -0,0 +1,112 @@
+package status_codes
+
+type AddCouponStatus int
+
+const (
+	AddCouponStatusSuccess AddCouponStatus = iota
+	AddCouponStatusInvalidCode
+	AddCouponStatusCodeAlreadyExists
+	AddCouponStatusInvalidType
+	AddCouponStatusInvalidValue
+	AddCouponStatusInvalidPeriod
+	AddCouponStatusInvalidLimits
+	AddCouponStatusError
+)
+
+func (s AddCouponStatus) String() string {
+	switch s {
+	case AddCouponStatusSuccess:
+		return "Cupom criado com sucesso"
+	case AddCouponStatusInvalidCode:
+		return "Código inválido"
+	case AddCouponStatusCodeAlreadyExists:
+		return "Já existe um cupom com esse código"
+	case AddCouponStatusInvalidType:
+		return "Tipo de cupom inválido"
+	case AddCouponStatusInvalidValue:
+		return "Valor do cupom inválido"
+	case AddCouponStatusInvalidPeriod:
+		return "Período de validade inválido"
+	case AddCouponStatusInvalidLimits:
+		return "Limites de uso inválidos"
+	case AddCouponStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s AddCouponStatus) Int() int {
+	return int(s)
+}
+
+type DeleteCouponStatus int
+
+const (
+	DeleteCouponStatusSuccess DeleteCouponStatus = iota
+	DeleteCouponStatusNotFound
+	DeleteCouponStatusError
+)
+
+func (s DeleteCouponStatus) String() string {
+	switch s {
+	case DeleteCouponStatusSuccess:
+		return "Cupom desativado com sucesso"
+	case DeleteCouponStatusNotFound:
+		return "Cupom não encontrado"
+	case DeleteCouponStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s DeleteCouponStatus) Int() int {
+	return int(s)
+}
+
+type ApplyCouponStatus int
+
+const (
+	ApplyCouponStatusSuccess ApplyCouponStatus = iota
+	ApplyCouponStatusNotFound
+	ApplyCouponStatusNotStarted
+	ApplyCouponStatusExpired
+	ApplyCouponStatusUsageLimitReached
+	ApplyCouponStatusUserLimitReached
+	ApplyCouponStatusMinOrderValue
+	ApplyCouponStatusNotApplicable
+	ApplyCouponStatusCartEmpty
+	ApplyCouponStatusError
+)
+
+func (s ApplyCouponStatus) String() string {
+	switch s {
+	case ApplyCouponStatusSuccess:
+		return "Cupom aplicado com sucesso"
+	case ApplyCouponStatusNotFound:
+		return "Cupom não encontrado"
+	case ApplyCouponStatusNotStarted:
+		return "Cupom ainda não está válido"
+	case ApplyCouponStatusExpired:
+		return "Cupom expirado"
+	case ApplyCouponStatusUsageLimitReached:
+		return "Cupom esgotado"
+	case ApplyCouponStatusUserLimitReached:
+		return "Você já utilizou esse cupom o número máximo de vezes"
+	case ApplyCouponStatusMinOrderValue:
+		return "Valor mínimo do pedido não atingido"
+	case ApplyCouponStatusNotApplicable:
+		return "Cupom não se aplica aos produtos do carrinho"
+	case ApplyCouponStatusCartEmpty:
+		return "Carrinho vazio"
+	case ApplyCouponStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s ApplyCouponStatus) Int() int {
+	return int(s)
+}
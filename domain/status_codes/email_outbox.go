@@ -0,0 +1,54 @@
+package status_codes
+
+type RetryEmailStatus int
+
+const (
+	RetryEmailStatusSuccess RetryEmailStatus = iota
+	RetryEmailStatusNotFound
+	RetryEmailStatusError
+)
+
+func (s RetryEmailStatus) String() string {
+	switch s {
+	case RetryEmailStatusSuccess:
+		return "Email reenfileirado para envio"
+	case RetryEmailStatusNotFound:
+		return "Email com falha não encontrado"
+	case RetryEmailStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s RetryEmailStatus) Int() int {
+	return int(s)
+}
+
+type PreviewEmailStatus int
+
+const (
+	PreviewEmailStatusSuccess PreviewEmailStatus = iota
+	PreviewEmailStatusNotFound
+	PreviewEmailStatusInvalidLocale
+	PreviewEmailStatusError
+)
+
+func (s PreviewEmailStatus) String() string {
+	switch s {
+	case PreviewEmailStatusSuccess:
+		return "Pré-visualização gerada com sucesso"
+	case PreviewEmailStatusNotFound:
+		return "Template de email não encontrado"
+	case PreviewEmailStatusInvalidLocale:
+		return "Idioma inválido"
+	case PreviewEmailStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s PreviewEmailStatus) Int() int {
+	return int(s)
+}
@@ -0,0 +1,56 @@
+package status_codes
+
+type VerifyEmailStatus int
+type ResendVerificationStatus int
+
+const (
+	VerifyEmailStatusSuccess VerifyEmailStatus = iota
+	VerifyEmailStatusInvalidToken
+	VerifyEmailStatusExpiredToken
+	VerifyEmailStatusError
+)
+
+const (
+	ResendVerificationStatusSuccess ResendVerificationStatus = iota
+	ResendVerificationStatusAlreadyVerified
+	ResendVerificationStatusTooSoon
+	ResendVerificationStatusError
+)
+
+func (s VerifyEmailStatus) String() string {
+	switch s {
+	case VerifyEmailStatusSuccess:
+		return "E-mail confirmado com sucesso!"
+	case VerifyEmailStatusInvalidToken:
+		return "Link de confirmação inválido ou já utilizado"
+	case VerifyEmailStatusExpiredToken:
+		return "Link de confirmação expirado, solicite um novo"
+	case VerifyEmailStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s VerifyEmailStatus) Int() int {
+	return int(s)
+}
+
+func (s ResendVerificationStatus) String() string {
+	switch s {
+	case ResendVerificationStatusSuccess:
+		return "E-mail de confirmação reenviado"
+	case ResendVerificationStatusAlreadyVerified:
+		return "E-mail já confirmado"
+	case ResendVerificationStatusTooSoon:
+		return "Aguarde alguns minutos antes de solicitar um novo e-mail"
+	case ResendVerificationStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s ResendVerificationStatus) Int() int {
+	return int(s)
+}
@@ -15,6 +15,12 @@ const (
 	LoginFailure
 	LoginInvalidCredentials
 	LoginUserNotFound
+	LoginTwoFactorRequired
+	LoginInvalidTwoFactorCode
+	LoginTwoFactorSetupRequired
+	LoginTooManyAttempts
+	LoginAccountLocked
+	LoginAccountDisabled
 )
 
 func LoginStatusCodeToString(code LoginStatusCode) string {
@@ -27,6 +33,18 @@ func LoginStatusCodeToString(code LoginStatusCode) string {
 		return "Credenciais inválidas"
 	case LoginUserNotFound:
 		return "Usuário não encontrado"
+	case LoginTwoFactorRequired:
+		return "Informe o código de autenticação em dois fatores"
+	case LoginInvalidTwoFactorCode:
+		return "Código de autenticação inválido"
+	case LoginTwoFactorSetupRequired:
+		return "Ative a autenticação em dois fatores para acessar a área administrativa"
+	case LoginTooManyAttempts:
+		return "Muitas tentativas de login. Aguarde alguns instantes e tente novamente"
+	case LoginAccountLocked:
+		return "Login bloqueado temporariamente por excesso de tentativas. Tente novamente mais tarde"
+	case LoginAccountDisabled:
+		return "Conta desativada. Entre em contato com o suporte"
 	default:
 		return "Erro desconhecido"
 	}
@@ -0,0 +1,51 @@
+package status_codes
+
+type ReadNotificationStatus int
+
+const (
+	ReadNotificationStatusSuccess ReadNotificationStatus = iota
+	ReadNotificationStatusNotFound
+	ReadNotificationStatusError
+)
+
+func (s ReadNotificationStatus) String() string {
+	switch s {
+	case ReadNotificationStatusSuccess:
+		return "Notificação marcada como lida"
+	case ReadNotificationStatusNotFound:
+		return "Notificação não encontrada"
+	case ReadNotificationStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s ReadNotificationStatus) Int() int {
+	return int(s)
+}
+
+type UpdateNotificationPreferencesStatus int
+
+const (
+	UpdateNotificationPreferencesStatusSuccess UpdateNotificationPreferencesStatus = iota
+	UpdateNotificationPreferencesStatusInvalidType
+	UpdateNotificationPreferencesStatusError
+)
+
+func (s UpdateNotificationPreferencesStatus) String() string {
+	switch s {
+	case UpdateNotificationPreferencesStatusSuccess:
+		return "Preferências de notificação atualizadas com sucesso!"
+	case UpdateNotificationPreferencesStatusInvalidType:
+		return "Tipo de notificação inválido"
+	case UpdateNotificationPreferencesStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s UpdateNotificationPreferencesStatus) Int() int {
+	return int(s)
+}
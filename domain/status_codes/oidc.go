@@ -0,0 +1,50 @@
+package status_codes
+
+type OIDCLoginStatus int
+
+const (
+	OIDCLoginStatusSuccess OIDCLoginStatus = iota
+	OIDCLoginStatusUnknownProvider
+	OIDCLoginStatusProviderUnavailable
+	OIDCLoginStatusInvalidState
+	OIDCLoginStatusInvalidToken
+	OIDCLoginStatusEmailNotVerified
+	OIDCLoginStatusAccountDisabled
+	OIDCLoginStatusAccountNotVerified
+	OIDCLoginStatusTwoFactorRequired
+	OIDCLoginStatusAcceptanceRequired
+	OIDCLoginStatusError
+)
+
+func (s OIDCLoginStatus) String() string {
+	switch s {
+	case OIDCLoginStatusSuccess:
+		return "Sucesso"
+	case OIDCLoginStatusUnknownProvider:
+		return "Provedor de login não encontrado"
+	case OIDCLoginStatusProviderUnavailable:
+		return "Provedor de login indisponível. Tente novamente mais tarde"
+	case OIDCLoginStatusInvalidState:
+		return "Login expirado ou inválido. Tente novamente"
+	case OIDCLoginStatusInvalidToken:
+		return "Não foi possível confirmar o login com o provedor"
+	case OIDCLoginStatusEmailNotVerified:
+		return "O email da conta do provedor não foi verificado"
+	case OIDCLoginStatusAccountDisabled:
+		return "Conta desativada. Entre em contato com o suporte"
+	case OIDCLoginStatusAccountNotVerified:
+		return "Já existe uma conta com este email. Confirme o email dela antes de entrar com o provedor"
+	case OIDCLoginStatusTwoFactorRequired:
+		return "Esta conta usa autenticação em dois fatores. Entre com email e senha"
+	case OIDCLoginStatusAcceptanceRequired:
+		return "Login realizado. Aceite os termos de uso e a política de privacidade para continuar"
+	case OIDCLoginStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s OIDCLoginStatus) Int() int {
+	return int(s)
+}
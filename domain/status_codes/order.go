@@ -1 +1,38 @@
 package status_codes
+
+type ChangeOrderStatus int
+
+const (
+	ChangeOrderStatusSuccess ChangeOrderStatus = iota
+	ChangeOrderStatusNotFound
+	ChangeOrderStatusInvalidStatus
+	ChangeOrderStatusInvalidTransition
+	ChangeOrderStatusMissingTrackingCode
+	ChangeOrderStatusInvalidTrackingCode
+	ChangeOrderStatusError
+)
+
+func (s ChangeOrderStatus) String() string {
+	switch s {
+	case ChangeOrderStatusSuccess:
+		return "Status do pedido atualizado com sucesso!"
+	case ChangeOrderStatusNotFound:
+		return "Pedido não encontrado"
+	case ChangeOrderStatusInvalidStatus:
+		return "Status inválido"
+	case ChangeOrderStatusInvalidTransition:
+		return "O pedido não pode passar para este status"
+	case ChangeOrderStatusMissingTrackingCode:
+		return "Informe o código de rastreio do envio"
+	case ChangeOrderStatusInvalidTrackingCode:
+		return "Código de rastreio inválido"
+	case ChangeOrderStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s ChangeOrderStatus) Int() int {
+	return int(s)
+}
@@ -0,0 +1,62 @@
+package status_codes
+
+type ForgotPasswordStatus int
+type ResetPasswordStatus int
+
+const (
+	ForgotPasswordStatusSuccess ForgotPasswordStatus = iota
+	ForgotPasswordStatusInvalidEmail
+	ForgotPasswordStatusError
+)
+
+const (
+	ResetPasswordStatusSuccess ResetPasswordStatus = iota
+	ResetPasswordStatusInvalidToken
+	ResetPasswordStatusExpiredToken
+	ResetPasswordStatusIncomplete
+	ResetPasswordStatusInvalidPassword
+	ResetPasswordStatusPasswordsDontMatch
+	ResetPasswordStatusError
+)
+
+func (s ForgotPasswordStatus) String() string {
+	switch s {
+	case ForgotPasswordStatusSuccess:
+		return "Se o e-mail estiver cadastrado, você receberá um link para redefinir sua senha"
+	case ForgotPasswordStatusInvalidEmail:
+		return "Email inválido"
+	case ForgotPasswordStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s ForgotPasswordStatus) Int() int {
+	return int(s)
+}
+
+func (s ResetPasswordStatus) String() string {
+	switch s {
+	case ResetPasswordStatusSuccess:
+		return "Senha redefinida com sucesso!"
+	case ResetPasswordStatusInvalidToken:
+		return "Link de redefinição inválido ou já utilizado"
+	case ResetPasswordStatusExpiredToken:
+		return "Link de redefinição expirado"
+	case ResetPasswordStatusIncomplete:
+		return "Preencha todos os campos"
+	case ResetPasswordStatusInvalidPassword:
+		return "Senha inválida"
+	case ResetPasswordStatusPasswordsDontMatch:
+		return "As senhas não coincidem"
+	case ResetPasswordStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s ResetPasswordStatus) Int() int {
+	return int(s)
+}
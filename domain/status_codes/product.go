@@ -92,3 +92,62 @@ func (s UpdateProductStatus) String() string {
 func (s UpdateProductStatus) Int() int {
 	return int(s)
 }
+
+type SchedulePriceStatus int
+
+const (
+	SchedulePriceStatusSuccess SchedulePriceStatus = iota
+	SchedulePriceStatusProductNotFound
+	SchedulePriceStatusInvalidPrice
+	SchedulePriceStatusInvalidPeriod
+	SchedulePriceStatusError
+)
+
+func (s SchedulePriceStatus) String() string {
+	switch s {
+	case SchedulePriceStatusSuccess:
+		return "Preço agendado com sucesso"
+	case SchedulePriceStatusProductNotFound:
+		return "Produto não encontrado"
+	case SchedulePriceStatusInvalidPrice:
+		return "Preço inválido"
+	case SchedulePriceStatusInvalidPeriod:
+		return "Período inválido"
+	case SchedulePriceStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s SchedulePriceStatus) Int() int {
+	return int(s)
+}
+
+type CancelPriceStatus int
+
+const (
+	CancelPriceStatusSuccess CancelPriceStatus = iota
+	CancelPriceStatusNotFound
+	CancelPriceStatusAlreadyApplied
+	CancelPriceStatusError
+)
+
+func (s CancelPriceStatus) String() string {
+	switch s {
+	case CancelPriceStatusSuccess:
+		return "Agendamento cancelado com sucesso"
+	case CancelPriceStatusNotFound:
+		return "Agendamento não encontrado"
+	case CancelPriceStatusAlreadyApplied:
+		return "O preço já foi aplicado"
+	case CancelPriceStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s CancelPriceStatus) Int() int {
+	return int(s)
+}
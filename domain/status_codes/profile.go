@@ -0,0 +1,65 @@
+package status_codes
+
+type UpdateProfileStatus int
+type UpdateAvatarStatus int
+
+const (
+	UpdateProfileStatusSuccess UpdateProfileStatus = iota
+	UpdateProfileStatusInvalidName
+	UpdateProfileStatusInvalidCPF
+	UpdateProfileStatusCPFAlreadyExists
+	UpdateProfileStatusInvalidBirthDate
+	UpdateProfileStatusAgeVerified
+	UpdateProfileStatusInvalidLocale
+	UpdateProfileStatusError
+)
+
+const (
+	UpdateAvatarStatusSuccess UpdateAvatarStatus = iota
+	UpdateAvatarStatusInvalidImage
+	UpdateAvatarStatusError
+)
+
+func (s UpdateProfileStatus) String() string {
+	switch s {
+	case UpdateProfileStatusSuccess:
+		return "Perfil atualizado com sucesso!"
+	case UpdateProfileStatusInvalidName:
+		return "Nome inválido"
+	case UpdateProfileStatusInvalidCPF:
+		return "CPF inválido"
+	case UpdateProfileStatusCPFAlreadyExists:
+		return "CPF já cadastrado em outra conta"
+	case UpdateProfileStatusInvalidBirthDate:
+		return "Data de nascimento inválida"
+	case UpdateProfileStatusAgeVerified:
+		return "O CPF e a data de nascimento já foram confirmados e não podem ser alterados"
+	case UpdateProfileStatusInvalidLocale:
+		return "Idioma inválido"
+	case UpdateProfileStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s UpdateProfileStatus) Int() int {
+	return int(s)
+}
+
+func (s UpdateAvatarStatus) String() string {
+	switch s {
+	case UpdateAvatarStatusSuccess:
+		return "Foto de perfil atualizada com sucesso!"
+	case UpdateAvatarStatusInvalidImage:
+		return "A foto de perfil deve ser uma imagem JPEG ou PNG de até 2 MB"
+	case UpdateAvatarStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s UpdateAvatarStatus) Int() int {
+	return int(s)
+}
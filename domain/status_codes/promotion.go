@@ -0,0 +1,69 @@
+package status_codes
+
+type AddPromotionStatus int
+
+const (
+	AddPromotionStatusSuccess AddPromotionStatus = iota
+	AddPromotionStatusInvalidName
+	AddPromotionStatusInvalidType
+	AddPromotionStatusInvalidScope
+	AddPromotionStatusInvalidQuantities
+	AddPromotionStatusInvalidTiers
+	AddPromotionStatusInvalidKit
+	AddPromotionStatusInvalidPeriod
+	AddPromotionStatusError
+)
+
+func (s AddPromotionStatus) String() string {
+	switch s {
+	case AddPromotionStatusSuccess:
+		return "Promoção criada com sucesso"
+	case AddPromotionStatusInvalidName:
+		return "Nome inválido"
+	case AddPromotionStatusInvalidType:
+		return "Tipo de promoção inválido"
+	case AddPromotionStatusInvalidScope:
+		return "Informe uma categoria ou produtos para a promoção"
+	case AddPromotionStatusInvalidQuantities:
+		return "Quantidades inválidas"
+	case AddPromotionStatusInvalidTiers:
+		return "Faixas de desconto inválidas"
+	case AddPromotionStatusInvalidKit:
+		return "Kit inválido"
+	case AddPromotionStatusInvalidPeriod:
+		return "Período de validade inválido"
+	case AddPromotionStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s AddPromotionStatus) Int() int {
+	return int(s)
+}
+
+type DeletePromotionStatus int
+
+const (
+	DeletePromotionStatusSuccess DeletePromotionStatus = iota
+	DeletePromotionStatusNotFound
+	DeletePromotionStatusError
+)
+
+func (s DeletePromotionStatus) String() string {
+	switch s {
+	case DeletePromotionStatusSuccess:
+		return "Promoção desativada com sucesso"
+	case DeletePromotionStatusNotFound:
+		return "Promoção não encontrada"
+	case DeletePromotionStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s DeletePromotionStatus) Int() int {
+	return int(s)
+}
@@ -18,6 +18,8 @@ const (
 	RegisterInvalidName
 	RegisterInvalidPassword
 	RegisterInvalidCredentials
+	RegisterTermsNotAccepted
+	RegisterInvalidLocale
 )
 
 func RegisterStatusCodeToString(code RegisterStatusCode) string {
@@ -36,6 +38,10 @@ func RegisterStatusCodeToString(code RegisterStatusCode) string {
 		return "Senha inválida"
 	case RegisterInvalidCredentials:
 		return "Credenciais inválidas"
+	case RegisterTermsNotAccepted:
+		return "É necessário aceitar a versão atual dos termos de uso e da política de privacidade"
+	case RegisterInvalidLocale:
+		return "Idioma inválido"
 	default:
 		return "UNKNOWN"
 	}
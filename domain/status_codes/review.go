@@ -0,0 +1,122 @@
+package status_codes
+
+type AddReviewStatus int
+
+const (
+	AddReviewStatusSuccess AddReviewStatus = iota
+	AddReviewStatusProductNotFound
+	AddReviewStatusNotPurchased
+	AddReviewStatusAlreadyReviewed
+	AddReviewStatusInvalidRating
+	AddReviewStatusInvalidComment
+	AddReviewStatusError
+)
+
+func (s AddReviewStatus) String() string {
+	switch s {
+	case AddReviewStatusSuccess:
+		return "Avaliação enviada para moderação"
+	case AddReviewStatusProductNotFound:
+		return "Produto não encontrado"
+	case AddReviewStatusNotPurchased:
+		return "Apenas clientes que compraram o produto podem avaliá-lo"
+	case AddReviewStatusAlreadyReviewed:
+		return "Você já avaliou esse produto"
+	case AddReviewStatusInvalidRating:
+		return "A nota deve ser entre 1 e 5"
+	case AddReviewStatusInvalidComment:
+		return "Comentário inválido"
+	case AddReviewStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s AddReviewStatus) Int() int {
+	return int(s)
+}
+
+type UpdateReviewStatus int
+
+const (
+	UpdateReviewStatusSuccess UpdateReviewStatus = iota
+	UpdateReviewStatusNotFound
+	UpdateReviewStatusInvalidRating
+	UpdateReviewStatusInvalidComment
+	UpdateReviewStatusError
+)
+
+func (s UpdateReviewStatus) String() string {
+	switch s {
+	case UpdateReviewStatusSuccess:
+		return "Avaliação atualizada e enviada para moderação"
+	case UpdateReviewStatusNotFound:
+		return "Avaliação não encontrada"
+	case UpdateReviewStatusInvalidRating:
+		return "A nota deve ser entre 1 e 5"
+	case UpdateReviewStatusInvalidComment:
+		return "Comentário inválido"
+	case UpdateReviewStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s UpdateReviewStatus) Int() int {
+	return int(s)
+}
+
+type DeleteReviewStatus int
+
+const (
+	DeleteReviewStatusSuccess DeleteReviewStatus = iota
+	DeleteReviewStatusNotFound
+	DeleteReviewStatusError
+)
+
+func (s DeleteReviewStatus) String() string {
+	switch s {
+	case DeleteReviewStatusSuccess:
+		return "Avaliação removida com sucesso"
+	case DeleteReviewStatusNotFound:
+		return "Avaliação não encontrada"
+	case DeleteReviewStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s DeleteReviewStatus) Int() int {
+	return int(s)
+}
+
+type ModerateReviewStatus int
+
+const (
+	ModerateReviewStatusSuccess ModerateReviewStatus = iota
+	ModerateReviewStatusNotFound
+	ModerateReviewStatusInvalidStatus
+	ModerateReviewStatusError
+)
+
+func (s ModerateReviewStatus) String() string {
+	switch s {
+	case ModerateReviewStatusSuccess:
+		return "Avaliação moderada com sucesso"
+	case ModerateReviewStatusNotFound:
+		return "Avaliação não encontrada"
+	case ModerateReviewStatusInvalidStatus:
+		return "Status inválido"
+	case ModerateReviewStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s ModerateReviewStatus) Int() int {
+	return int(s)
+}
@@ -0,0 +1,32 @@
+package status_codes
+
+type AssignRoleStatus int
+
+const (
+	AssignRoleStatusSuccess AssignRoleStatus = iota
+	AssignRoleStatusInvalidRole
+	AssignRoleStatusUserNotFound
+	AssignRoleStatusLastSuperAdmin
+	AssignRoleStatusError
+)
+
+func (s AssignRoleStatus) String() string {
+	switch s {
+	case AssignRoleStatusSuccess:
+		return "Cargo atribuído com sucesso!"
+	case AssignRoleStatusInvalidRole:
+		return "Cargo inválido"
+	case AssignRoleStatusUserNotFound:
+		return "Usuário não encontrado"
+	case AssignRoleStatusLastSuperAdmin:
+		return "Não é possível remover o último super administrador"
+	case AssignRoleStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s AssignRoleStatus) Int() int {
+	return int(s)
+}
@@ -0,0 +1,105 @@
+package status_codes
+
+type RefreshTokenStatus int
+type LogoutStatus int
+type RevokeTokensStatus int
+
+const (
+	RefreshTokenStatusSuccess RefreshTokenStatus = iota
+	RefreshTokenStatusInvalidToken
+	RefreshTokenStatusExpiredToken
+	RefreshTokenStatusReusedToken
+	RefreshTokenStatusError
+)
+
+const (
+	LogoutStatusSuccess LogoutStatus = iota
+	LogoutStatusInvalidToken
+	LogoutStatusError
+)
+
+const (
+	RevokeTokensStatusSuccess RevokeTokensStatus = iota
+	RevokeTokensStatusUserNotFound
+	RevokeTokensStatusError
+)
+
+func (s RefreshTokenStatus) String() string {
+	switch s {
+	case RefreshTokenStatusSuccess:
+		return "Sucesso"
+	case RefreshTokenStatusInvalidToken:
+		return "Token de atualização inválido"
+	case RefreshTokenStatusExpiredToken:
+		return "Token de atualização expirado"
+	case RefreshTokenStatusReusedToken:
+		return "Token de atualização já utilizado, faça login novamente"
+	case RefreshTokenStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s RefreshTokenStatus) Int() int {
+	return int(s)
+}
+
+func (s LogoutStatus) String() string {
+	switch s {
+	case LogoutStatusSuccess:
+		return "Sessão encerrada com sucesso"
+	case LogoutStatusInvalidToken:
+		return "Token inválido ou expirado"
+	case LogoutStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s LogoutStatus) Int() int {
+	return int(s)
+}
+
+func (s RevokeTokensStatus) String() string {
+	switch s {
+	case RevokeTokensStatusSuccess:
+		return "Sessões do usuário encerradas com sucesso"
+	case RevokeTokensStatusUserNotFound:
+		return "Usuário não encontrado"
+	case RevokeTokensStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s RevokeTokensStatus) Int() int {
+	return int(s)
+}
+
+type RevokeSessionStatus int
+
+const (
+	RevokeSessionStatusSuccess RevokeSessionStatus = iota
+	RevokeSessionStatusNotFound
+	RevokeSessionStatusError
+)
+
+func (s RevokeSessionStatus) String() string {
+	switch s {
+	case RevokeSessionStatusSuccess:
+		return "Sessão encerrada com sucesso"
+	case RevokeSessionStatusNotFound:
+		return "Sessão não encontrada"
+	case RevokeSessionStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s RevokeSessionStatus) Int() int {
+	return int(s)
+}
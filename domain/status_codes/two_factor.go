@@ -0,0 +1,116 @@
+package status_codes
+
+type SetupTwoFactorStatus int
+type EnableTwoFactorStatus int
+type DisableTwoFactorStatus int
+type RegenerateRecoveryCodesStatus int
+
+const (
+	SetupTwoFactorStatusSuccess SetupTwoFactorStatus = iota
+	SetupTwoFactorStatusAlreadyEnabled
+	SetupTwoFactorStatusError
+)
+
+const (
+	EnableTwoFactorStatusSuccess EnableTwoFactorStatus = iota
+	EnableTwoFactorStatusNotStarted
+	EnableTwoFactorStatusAlreadyEnabled
+	EnableTwoFactorStatusInvalidCode
+	EnableTwoFactorStatusError
+)
+
+const (
+	DisableTwoFactorStatusSuccess DisableTwoFactorStatus = iota
+	DisableTwoFactorStatusNotEnabled
+	DisableTwoFactorStatusInvalidPassword
+	DisableTwoFactorStatusInvalidCode
+	DisableTwoFactorStatusRequired
+	DisableTwoFactorStatusError
+)
+
+const (
+	RegenerateRecoveryCodesStatusSuccess RegenerateRecoveryCodesStatus = iota
+	RegenerateRecoveryCodesStatusNotEnabled
+	RegenerateRecoveryCodesStatusInvalidCode
+	RegenerateRecoveryCodesStatusError
+)
+
+func (s SetupTwoFactorStatus) String() string {
+	switch s {
+	case SetupTwoFactorStatusSuccess:
+		return "Escaneie o QR code com seu aplicativo autenticador e confirme com o código gerado"
+	case SetupTwoFactorStatusAlreadyEnabled:
+		return "A autenticação em dois fatores já está ativa"
+	case SetupTwoFactorStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s SetupTwoFactorStatus) Int() int {
+	return int(s)
+}
+
+func (s EnableTwoFactorStatus) String() string {
+	switch s {
+	case EnableTwoFactorStatusSuccess:
+		return "Autenticação em dois fatores ativada! Guarde seus códigos de recuperação e faça login novamente"
+	case EnableTwoFactorStatusNotStarted:
+		return "Inicie a configuração da autenticação em dois fatores"
+	case EnableTwoFactorStatusAlreadyEnabled:
+		return "A autenticação em dois fatores já está ativa"
+	case EnableTwoFactorStatusInvalidCode:
+		return "Código de autenticação inválido"
+	case EnableTwoFactorStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s EnableTwoFactorStatus) Int() int {
+	return int(s)
+}
+
+func (s DisableTwoFactorStatus) String() string {
+	switch s {
+	case DisableTwoFactorStatusSuccess:
+		return "Autenticação em dois fatores desativada"
+	case DisableTwoFactorStatusNotEnabled:
+		return "A autenticação em dois fatores não está ativa"
+	case DisableTwoFactorStatusInvalidPassword:
+		return "Senha inválida"
+	case DisableTwoFactorStatusInvalidCode:
+		return "Código de autenticação inválido"
+	case DisableTwoFactorStatusRequired:
+		return "A autenticação em dois fatores é obrigatória para administradores"
+	case DisableTwoFactorStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s DisableTwoFactorStatus) Int() int {
+	return int(s)
+}
+
+func (s RegenerateRecoveryCodesStatus) String() string {
+	switch s {
+	case RegenerateRecoveryCodesStatusSuccess:
+		return "Novos códigos de recuperação gerados. Os anteriores não são mais válidos"
+	case RegenerateRecoveryCodesStatusNotEnabled:
+		return "A autenticação em dois fatores não está ativa"
+	case RegenerateRecoveryCodesStatusInvalidCode:
+		return "Código de autenticação inválido"
+	case RegenerateRecoveryCodesStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s RegenerateRecoveryCodesStatus) Int() int {
+	return int(s)
+}
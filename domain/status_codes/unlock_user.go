@@ -0,0 +1,26 @@
+package status_codes
+
+type UnlockUserStatus int
+
+const (
+	UnlockUserStatusSuccess UnlockUserStatus = iota
+	UnlockUserStatusUserNotFound
+	UnlockUserStatusError
+)
+
+func (s UnlockUserStatus) String() string {
+	switch s {
+	case UnlockUserStatusSuccess:
+		return "Login do usuário desbloqueado"
+	case UnlockUserStatusUserNotFound:
+		return "Usuário não encontrado"
+	case UnlockUserStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s UnlockUserStatus) Int() int {
+	return int(s)
+}
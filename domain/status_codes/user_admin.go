@@ -0,0 +1,119 @@
+package status_codes
+
+type DisableUserStatus int
+type EnableUserStatus int
+type DeleteUserStatus int
+type DeleteAccountStatus int
+
+const (
+	DisableUserStatusSuccess DisableUserStatus = iota
+	DisableUserStatusNotFound
+	DisableUserStatusAlreadyDisabled
+	DisableUserStatusSelf
+	DisableUserStatusForbidden
+	DisableUserStatusError
+)
+
+const (
+	EnableUserStatusSuccess EnableUserStatus = iota
+	EnableUserStatusNotFound
+	EnableUserStatusNotDisabled
+	EnableUserStatusForbidden
+	EnableUserStatusError
+)
+
+const (
+	DeleteUserStatusSuccess DeleteUserStatus = iota
+	DeleteUserStatusNotFound
+	DeleteUserStatusSelf
+	DeleteUserStatusError
+)
+
+const (
+	DeleteAccountStatusSuccess DeleteAccountStatus = iota
+	DeleteAccountStatusInvalidPassword
+	DeleteAccountStatusLastSuperAdmin
+	DeleteAccountStatusError
+)
+
+func (s DisableUserStatus) String() string {
+	switch s {
+	case DisableUserStatusSuccess:
+		return "Conta desativada com sucesso!"
+	case DisableUserStatusNotFound:
+		return "Usuário não encontrado"
+	case DisableUserStatusAlreadyDisabled:
+		return "A conta já está desativada"
+	case DisableUserStatusSelf:
+		return "Você não pode desativar a sua própria conta"
+	case DisableUserStatusForbidden:
+		return "Você não pode desativar a conta de um usuário com mais permissões"
+	case DisableUserStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s DisableUserStatus) Int() int {
+	return int(s)
+}
+
+func (s EnableUserStatus) String() string {
+	switch s {
+	case EnableUserStatusSuccess:
+		return "Conta reativada com sucesso!"
+	case EnableUserStatusNotFound:
+		return "Usuário não encontrado"
+	case EnableUserStatusNotDisabled:
+		return "A conta não está desativada"
+	case EnableUserStatusForbidden:
+		return "Você não pode reativar a conta de um usuário com mais permissões"
+	case EnableUserStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s EnableUserStatus) Int() int {
+	return int(s)
+}
+
+func (s DeleteUserStatus) String() string {
+	switch s {
+	case DeleteUserStatusSuccess:
+		return "Usuário excluído com sucesso!"
+	case DeleteUserStatusNotFound:
+		return "Usuário não encontrado"
+	case DeleteUserStatusSelf:
+		return "Você não pode excluir a sua própria conta por aqui"
+	case DeleteUserStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s DeleteUserStatus) Int() int {
+	return int(s)
+}
+
+func (s DeleteAccountStatus) String() string {
+	switch s {
+	case DeleteAccountStatusSuccess:
+		return "Conta excluída com sucesso!"
+	case DeleteAccountStatusInvalidPassword:
+		return "Senha inválida"
+	case DeleteAccountStatusLastSuperAdmin:
+		return "Não é possível excluir a conta do último super administrador"
+	case DeleteAccountStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s DeleteAccountStatus) Int() int {
+	return int(s)
+}
@@ -0,0 +1,83 @@
+package status_codes
+
+type AddWishlistItemStatus int
+type RemoveWishlistItemStatus int
+type MoveWishlistItemStatus int
+
+const (
+	AddWishlistItemStatusSuccess AddWishlistItemStatus = iota
+	AddWishlistItemStatusInvalidProduct
+	AddWishlistItemStatusError
+)
+
+const (
+	RemoveWishlistItemStatusSuccess RemoveWishlistItemStatus = iota
+	RemoveWishlistItemStatusNotFound
+	RemoveWishlistItemStatusError
+)
+
+const (
+	MoveWishlistItemStatusSuccess MoveWishlistItemStatus = iota
+	MoveWishlistItemStatusNotFound
+	MoveWishlistItemStatusInvalidQuantity
+	MoveWishlistItemStatusInvalidProduct
+	MoveWishlistItemStatusInvalidUser
+	MoveWishlistItemStatusError
+)
+
+func (s AddWishlistItemStatus) String() string {
+	switch s {
+	case AddWishlistItemStatusSuccess:
+		return "Produto adicionado à lista de desejos"
+	case AddWishlistItemStatusInvalidProduct:
+		return "Produto inválido"
+	case AddWishlistItemStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s AddWishlistItemStatus) Int() int {
+	return int(s)
+}
+
+func (s RemoveWishlistItemStatus) String() string {
+	switch s {
+	case RemoveWishlistItemStatusSuccess:
+		return "Produto removido da lista de desejos"
+	case RemoveWishlistItemStatusNotFound:
+		return "Produto não está na lista de desejos"
+	case RemoveWishlistItemStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s RemoveWishlistItemStatus) Int() int {
+	return int(s)
+}
+
+func (s MoveWishlistItemStatus) String() string {
+	switch s {
+	case MoveWishlistItemStatusSuccess:
+		return "Produto movido para o carrinho"
+	case MoveWishlistItemStatusNotFound:
+		return "Produto não está na lista de desejos"
+	case MoveWishlistItemStatusInvalidQuantity:
+		return "Quantidade inválida"
+	case MoveWishlistItemStatusInvalidProduct:
+		return "Produto inválido"
+	case MoveWishlistItemStatusInvalidUser:
+		return "Usuário inválido"
+	case MoveWishlistItemStatusError:
+		return "Erro interno no servidor"
+	default:
+		return "Erro desconhecido"
+	}
+}
+
+func (s MoveWishlistItemStatus) Int() int {
+	return int(s)
+}
@@ -0,0 +1,125 @@
+package usecases
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/status_codes"
+	repositories "cachacariaapi/infrastructure/datastore"
+	"cachacariaapi/infrastructure/util"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	maxAPIKeysPerUser = 10
+	maxAPIKeyNameLen  = 100
+)
+
+type APIKeyUseCases struct {
+	repository  repositories.APIKeyRepository
+	authManager util.AuthManager
+}
+
+func NewAPIKeyUseCases(repository repositories.APIKeyRepository, authManager util.AuthManager) APIKeyUseCases {
+	return APIKeyUseCases{
+		repository:  repository,
+		authManager: authManager,
+	}
+}
+
+// CreateAPIKey creates a key for the user, with a subset of the permissions of the user's role.
+// When two-factor authentication is mandatory for staff, the user must be logged in with it, so
+// the key does not open the back office to an account without it.
+func (u *APIKeyUseCases) CreateAPIKey(
+	ctx context.Context,
+	user *util.UserContext,
+	request entities.CreateAPIKeyRequest,
+) (*entities.CreatedAPIKey, status_codes.CreateAPIKeyStatus, error) {
+	request.Name = util.TrimSpace(request.Name)
+	if request.Name == "" || len(request.Name) > maxAPIKeyNameLen {
+		return nil, status_codes.CreateAPIKeyStatusInvalidName, nil
+	}
+
+	if !validAPIKeyScopes(request.Scopes, user.Role) {
+		return nil, status_codes.CreateAPIKeyStatusInvalidScopes, nil
+	}
+
+	now := time.Now()
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(now) {
+		return nil, status_codes.CreateAPIKeyStatusInvalidExpiration, nil
+	}
+
+	if u.authManager.RequireAdminTwoFactor() && !user.TwoFactor {
+		return nil, status_codes.CreateAPIKeyStatusTwoFactorRequired, nil
+	}
+
+	count, err := u.repository.CountActiveAPIKeys(ctx, int64(user.UserID), now)
+	if err != nil {
+		return nil, status_codes.CreateAPIKeyStatusError, errors.Join(fmt.Errorf("failed to count api keys"), err)
+	}
+
+	if count >= maxAPIKeysPerUser {
+		return nil, status_codes.CreateAPIKeyStatusTooManyKeys, nil
+	}
+
+	key, prefix, hash, err := u.authManager.CreateAPIKey()
+	if err != nil {
+		return nil, status_codes.CreateAPIKeyStatusError, errors.Join(fmt.Errorf("failed to generate api key"), err)
+	}
+
+	apiKey := &entities.APIKey{
+		UserID:    int64(user.UserID),
+		Name:      request.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    request.Scopes,
+		ExpiresAt: request.ExpiresAt,
+		CreatedAt: now,
+	}
+
+	apiKey.ID, err = u.repository.AddAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, status_codes.CreateAPIKeyStatusError, errors.Join(fmt.Errorf("failed to add api key"), err)
+	}
+
+	return &entities.CreatedAPIKey{Key: key, APIKey: apiKey}, status_codes.CreateAPIKeyStatusSuccess, nil
+}
+
+func (u *APIKeyUseCases) GetAPIKeys(ctx context.Context, userID int64) ([]entities.APIKey, error) {
+	keys, err := u.repository.GetAPIKeysByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get api keys"), err)
+	}
+
+	return keys, nil
+}
+
+func (u *APIKeyUseCases) RevokeAPIKey(ctx context.Context, userID, keyID int64) (status_codes.RevokeAPIKeyStatus, error) {
+	revoked, err := u.repository.RevokeAPIKey(ctx, keyID, userID)
+	if err != nil {
+		return status_codes.RevokeAPIKeyStatusError, errors.Join(fmt.Errorf("failed to revoke api key"), err)
+	}
+
+	if !revoked {
+		return status_codes.RevokeAPIKeyStatusNotFound, nil
+	}
+
+	return status_codes.RevokeAPIKeyStatusSuccess, nil
+}
+
+// validAPIKeyScopes tells whether the scopes are distinct permissions granted by the role
+func validAPIKeyScopes(scopes []entities.Permission, role entities.Role) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+
+	for i, scope := range scopes {
+		if !role.HasPermission(scope) || slices.Contains(scopes[:i], scope) {
+			return false
+		}
+	}
+
+	return true
+}
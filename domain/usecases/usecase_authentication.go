@@ -11,129 +11,344 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	// verificationResendCooldown is the minimum interval between two verification emails
+	verificationResendCooldown = time.Minute
+	recoveryCodeCount          = 10
+)
+
+// Login throttling. After freeLoginAttempts failures of an email within loginFailureWindow,
+// each attempt must wait twice as long as the previous one, up to maxLoginDelay. Reaching
+// maxLoginFailures locks the email for accountLockoutDuration, and every further failure
+// locks it again. An IP address with maxIPLoginFailures failures is blocked the same way.
+const (
+	loginFailureWindow     = time.Hour
+	freeLoginAttempts      = 3
+	loginBaseDelay         = time.Second
+	maxLoginDelay          = 30 * time.Second
+	maxLoginFailures       = 10
+	maxIPLoginFailures     = 100
+	accountLockoutDuration = 15 * time.Minute
+)
+
 type AuthUseCases struct {
-	repository     repositories.AuthRepository
-	userRepository repositories.UserRepository
-	authManager    util.AuthManager
-	emailConfig    util2.EmailConfig
+	repository             repositories.AuthRepository
+	userRepository         repositories.UserRepository
+	tokenRepository        repositories.TokenRepository
+	loginAttemptRepository repositories.LoginAttemptRepository
+	consentUseCases        ConsentUseCases
+	authManager            util.AuthManager
+	templates              *util2.EmailTemplates
+	baseURL                string
 }
 
-func NewAuthUseCases(repository repositories.AuthRepository, userRepository repositories.UserRepository, authManager util.AuthManager, emailConfig util2.EmailConfig) AuthUseCases {
+func NewAuthUseCases(
+	repository repositories.AuthRepository,
+	userRepository repositories.UserRepository,
+	tokenRepository repositories.TokenRepository,
+	loginAttemptRepository repositories.LoginAttemptRepository,
+	consentUseCases ConsentUseCases,
+	authManager util.AuthManager,
+	templates *util2.EmailTemplates,
+	baseURL string,
+) AuthUseCases {
 	return AuthUseCases{
-		repository:     repository,
-		userRepository: userRepository,
-		authManager:    authManager,
-		emailConfig:    emailConfig,
+		repository:             repository,
+		userRepository:         userRepository,
+		tokenRepository:        tokenRepository,
+		loginAttemptRepository: loginAttemptRepository,
+		consentUseCases:        consentUseCases,
+		authManager:            authManager,
+		templates:              templates,
+		baseURL:                baseURL,
 	}
 }
 
+// AttemptLogin checks the credentials and starts a session. Unknown emails and wrong passwords
+// get the same response, and failed attempts are throttled per email and per IP address.
 func (a AuthUseCases) AttemptLogin(
 	ctx context.Context,
 	credentials entities.UserCredentials,
-) (string, status_codes.LoginStatusCode, error) {
+	client entities.SessionClient,
+) (*entities.AuthTokens, status_codes.LoginStatusCode, error) {
+	ipAddress := client.IPAddress
+	credentials.Email = util.TrimSpace(credentials.Email)
+	email := strings.ToLower(credentials.Email)
+	now := time.Now()
+
+	emailFailures, err := a.loginAttemptRepository.GetEmailFailures(ctx, email, now.Add(-loginFailureWindow))
+	if err != nil {
+		return nil, status_codes.LoginFailure, errors.Join(fmt.Errorf("failed to get email login failures"), err)
+	}
+
+	ipFailures, err := a.loginAttemptRepository.GetIPFailures(ctx, ipAddress, now.Add(-loginFailureWindow))
+	if err != nil {
+		return nil, status_codes.LoginFailure, errors.Join(fmt.Errorf("failed to get ip login failures"), err)
+	}
+
+	if status, throttled := loginThrottle(emailFailures, ipFailures, now); throttled {
+		return nil, status, nil
+	}
+
 	user, err := a.repository.GetUserByEmail(ctx, credentials.Email)
 	if err != nil {
-		return "", status_codes.LoginFailure, errors.Join(fmt.Errorf("failed to get user by email"), err)
+		return nil, status_codes.LoginFailure, errors.Join(fmt.Errorf("failed to get user by email"), err)
 	}
 
 	if user == nil {
-		return "", status_codes.LoginUserNotFound, nil
+		a.authManager.SimulatePasswordCheck(credentials.Password)
+		return a.loginFailed(ctx, nil, email, ipAddress, emailFailures, status_codes.LoginInvalidCredentials)
 	}
 
 	if !a.authManager.CheckPasswordHash(credentials.Password, user.Password) {
-		return "", status_codes.LoginInvalidCredentials, nil
+		return a.loginFailed(ctx, user, email, ipAddress, emailFailures, status_codes.LoginInvalidCredentials)
+	}
+
+	if user.IsDisabled() {
+		return nil, status_codes.LoginAccountDisabled, nil
+	}
+
+	twoFactor, err := a.repository.GetTwoFactor(ctx, int64(user.ID))
+	if err != nil {
+		return nil, status_codes.LoginFailure, errors.Join(fmt.Errorf("failed to get two factor"), err)
+	}
+
+	status := status_codes.LoginSuccess
+	if twoFactor != nil && twoFactor.IsEnabled() {
+		if util.TrimSpace(credentials.OTP) == "" {
+			return nil, status_codes.LoginTwoFactorRequired, nil
+		}
+
+		ok, err := a.checkTwoFactorCode(ctx, twoFactor, credentials.OTP)
+		if err != nil {
+			return nil, status_codes.LoginFailure, err
+		}
+
+		if !ok {
+			return a.loginFailed(ctx, user, email, ipAddress, emailFailures, status_codes.LoginInvalidTwoFactorCode)
+		}
+	} else if user.Role.IsStaff() && a.authManager.RequireAdminTwoFactor() {
+		// Staff can log in to enroll, but the back office routes stay closed until then
+		status = status_codes.LoginTwoFactorSetupRequired
+	}
+
+	if err = a.loginAttemptRepository.ClearEmailFailures(ctx, email); err != nil {
+		return nil, status_codes.LoginFailure, errors.Join(fmt.Errorf("failed to clear login failures"), err)
 	}
 
-	token, err := a.authManager.CreateToken(credentials.Email, user.ID, user.IsAdm)
+	tokens, err := a.StartSession(ctx, user, client)
 	if err != nil {
-		return "", status_codes.LoginFailure, errors.Join(fmt.Errorf("failed to generate auth token"), err)
+		return nil, status_codes.LoginFailure, err
+	}
+
+	return tokens, status, nil
+}
+
+// UnlockUser clears the failed logins of the user, lifting a lockout before it expires
+func (a AuthUseCases) UnlockUser(ctx context.Context, userID int64) (status_codes.UnlockUserStatus, error) {
+	user, err := a.repository.GetUserByID(ctx, userID)
+	if err != nil {
+		return status_codes.UnlockUserStatusError, errors.Join(fmt.Errorf("failed to get user"), err)
+	}
+
+	if user == nil {
+		return status_codes.UnlockUserStatusUserNotFound, nil
+	}
+
+	if err = a.loginAttemptRepository.ClearEmailFailures(ctx, strings.ToLower(user.Email)); err != nil {
+		return status_codes.UnlockUserStatusError, errors.Join(fmt.Errorf("failed to clear login failures"), err)
+	}
+
+	return status_codes.UnlockUserStatusSuccess, nil
+}
+
+// DeleteExpiredLoginFailures purges the failed logins that no longer count towards throttling
+func (a AuthUseCases) DeleteExpiredLoginFailures(ctx context.Context) error {
+	if err := a.loginAttemptRepository.DeleteLoginFailuresBefore(ctx, time.Now().Add(-loginFailureWindow)); err != nil {
+		return errors.Join(fmt.Errorf("failed to delete expired login failures"), err)
+	}
+
+	return nil
+}
+
+// loginThrottle tells whether a login attempt must be refused before checking the credentials
+func loginThrottle(emailFailures, ipFailures *entities.LoginFailures, now time.Time) (status_codes.LoginStatusCode, bool) {
+	if ipFailures.Count >= maxIPLoginFailures && now.Sub(*ipFailures.LastAt) < accountLockoutDuration {
+		return status_codes.LoginTooManyAttempts, true
+	}
+
+	if emailFailures.Count < freeLoginAttempts {
+		return status_codes.LoginSuccess, false
+	}
+
+	elapsed := now.Sub(*emailFailures.LastAt)
+	if emailFailures.Count >= maxLoginFailures && elapsed < accountLockoutDuration {
+		return status_codes.LoginAccountLocked, true
+	}
+
+	delay := min(loginBaseDelay<<(emailFailures.Count-freeLoginAttempts), maxLoginDelay)
+	if elapsed < delay {
+		return status_codes.LoginTooManyAttempts, true
 	}
 
-	return token, status_codes.LoginSuccess, nil
+	return status_codes.LoginSuccess, false
+}
+
+// loginFailed records the failed attempt, warning the user by email when it locks the account
+func (a AuthUseCases) loginFailed(
+	ctx context.Context,
+	user *entities.User,
+	email, ipAddress string,
+	failures *entities.LoginFailures,
+	status status_codes.LoginStatusCode,
+) (*entities.AuthTokens, status_codes.LoginStatusCode, error) {
+	var lockNotification *entities.Email
+	if user != nil && failures.Count+1 == maxLoginFailures {
+		lockNotification = notification(a.templates.NewAccountLockedEmail(*user, ipAddress, accountLockoutDuration))
+	}
+
+	if err := a.loginAttemptRepository.AddLoginFailure(ctx, email, ipAddress, lockNotification); err != nil {
+		return nil, status_codes.LoginFailure, errors.Join(fmt.Errorf("failed to add login failure"), err)
+	}
+
+	if failures.Count+1 < maxLoginFailures {
+		return nil, status, nil
+	}
+
+	slog.WarnContext(ctx, "login locked after too many failures", "email", email, "ip", ipAddress)
+	return nil, status_codes.LoginAccountLocked, nil
 }
 
 func (a AuthUseCases) RegisterUser(
 	ctx context.Context,
 	credentials entities.UserCredentials,
-) (string, status_codes.RegisterStatusCode, error) {
+	client entities.SessionClient,
+) (*entities.AuthTokens, status_codes.RegisterStatusCode, error) {
 	credentials.Email = util.TrimSpace(credentials.Email)
 	credentials.Password = util.TrimSpace(credentials.Password)
+	credentials.Name = util.TrimSpace(credentials.Name)
+	if credentials.Locale == "" {
+		credentials.Locale = entities.DefaultLocale
+	}
 
 	user, err := a.repository.GetUserByEmail(ctx, credentials.Email)
 	if err != nil {
-		return "", status_codes.RegisterFailure, errors.Join(fmt.Errorf("failed to check user"), err)
+		return nil, status_codes.RegisterFailure, errors.Join(fmt.Errorf("failed to check user"), err)
 	}
 
 	if user != nil {
-		return "", status_codes.RegisterUserAlreadyExist, nil
+		return nil, status_codes.RegisterUserAlreadyExist, nil
 	}
 
 	user, err = a.repository.GetUserByPhone(ctx, credentials.Phone)
 	if err != nil {
-		return "", status_codes.RegisterFailure, errors.Join(fmt.Errorf("failed to check user"), err)
+		return nil, status_codes.RegisterFailure, errors.Join(fmt.Errorf("failed to check user"), err)
 	}
 
 	if user != nil {
-		return "", status_codes.RegisterUserAlreadyExist, nil
+		return nil, status_codes.RegisterUserAlreadyExist, nil
 	}
 
 	if !rules.IsValidEmail(credentials.Email) {
-		return "", status_codes.RegisterInvalidEmail, nil
+		return nil, status_codes.RegisterInvalidEmail, nil
+	}
+
+	if !rules.IsValidName(credentials.Name) {
+		return nil, status_codes.RegisterInvalidName, nil
 	}
 
 	if !rules.IsValidPassword(credentials.Password) {
-		return "", status_codes.RegisterInvalidPassword, nil
+		return nil, status_codes.RegisterInvalidPassword, nil
+	}
+
+	if !a.consentUseCases.acceptsCurrentDocuments(credentials.TermsVersion, credentials.PrivacyPolicyVersion) {
+		return nil, status_codes.RegisterTermsNotAccepted, nil
+	}
+
+	if !entities.IsValidLocale(credentials.Locale) {
+		return nil, status_codes.RegisterInvalidLocale, nil
 	}
 
 	credentials.Password, err = a.authManager.HashPassword(credentials.Password)
 	if err != nil {
-		return "", status_codes.RegisterFailure, errors.Join(fmt.Errorf("failed to hash password"), err)
+		return nil, status_codes.RegisterFailure, errors.Join(fmt.Errorf("failed to hash password"), err)
 	}
 
 	userUUID, err := uuid.NewRandom()
 	if err != nil {
-		return "", status_codes.RegisterFailure, errors.Join(fmt.Errorf("failed to generate uuid"), err)
+		return nil, status_codes.RegisterFailure, errors.Join(fmt.Errorf("failed to generate uuid"), err)
 	}
 
 	user = &entities.User{
 		UUID:     userUUID,
 		Email:    credentials.Email,
 		Password: credentials.Password,
+		Name:     credentials.Name,
 		Phone:    credentials.Phone,
-		IsAdm:    credentials.IsAdm,
+		Role:     entities.RoleCustomer,
+		Locale:   credentials.Locale,
 	}
 
-	id, err := a.repository.AddUser(ctx, user)
+	id, err := a.repository.AddUser(ctx, user, a.consentUseCases.registrationConsents(credentials, client))
 	if err != nil {
-		return "", status_codes.RegisterFailure, errors.Join(fmt.Errorf("failed to add user"), err)
+		return nil, status_codes.RegisterFailure, errors.Join(fmt.Errorf("failed to add user"), err)
 	}
 
 	user.ID = int(id)
 
-	token, err := a.authManager.CreateToken(credentials.Email, user.ID, user.IsAdm)
+	tokens, err := a.StartSession(ctx, user, client)
 	if err != nil {
-		return "", status_codes.RegisterFailure, errors.Join(fmt.Errorf("failed to generate auth token"), err)
+		return nil, status_codes.RegisterFailure, err
 	}
 
-	err = util2.SendAccountCreatedEmail(a.emailConfig, []string{user.Email}, *user)
-	if err != nil {
-		slog.ErrorContext(ctx, "failed to send account created email", "cause", err)
+	if err = a.sendVerificationEmail(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "failed to send email verification", "cause", err)
 	}
 
-	return token, status_codes.RegisterSuccess, nil
+	return tokens, status_codes.RegisterSuccess, nil
+}
+
+// PublicUser returns the user as shown by the API, with the URL of their avatar
+func (a AuthUseCases) PublicUser(user entities.User) entities.PublicUser {
+	return user.Public(util2.GetAvatarURL(user.Avatar, a.baseURL))
+}
+
+// LegalVersions returns the versions of the terms and privacy policy accepted on registration
+func (a AuthUseCases) LegalVersions() entities.LegalVersions {
+	return a.consentUseCases.LegalVersions()
 }
 
-func (a AuthUseCases) GetUserByAuthToken(token string) (*entities.User, error) {
+func (a AuthUseCases) GetUserByAuthToken(ctx context.Context, token string) (*entities.User, error) {
 	payload, err := a.authManager.VerifyToken(token)
 	if err != nil {
 		return nil, errors.Join(fmt.Errorf("failed to verify token"), err)
 	}
 
-	return a.userRepository.FindById(int64(payload.UserID))
+	revoked, err := a.authManager.IsTokenRevoked(ctx, payload)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to check token revocation"), err)
+	}
+
+	if revoked {
+		return nil, entities.ErrInvalidToken
+	}
+
+	user, err := a.userRepository.FindById(int64(payload.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil || user.IsDisabled() {
+		return nil, nil
+	}
+
+	return user, nil
 }
 
 func (a AuthUseCases) ChangePassword(ctx context.Context, request entities.ChangePasswordRequest) (status_codes.ChangePasswordStatus, error) {
@@ -177,15 +392,671 @@ func (a AuthUseCases) ChangePassword(ctx context.Context, request entities.Chang
 		return status_codes.ChangePasswordError, errors.Join(fmt.Errorf("failed to hash password"), err)
 	}
 
-	err = a.repository.UpdateUserPassword(ctx, int64(user.ID), hashedNewPassword)
+	changedNotification := notification(a.templates.NewPasswordChangedEmail(*user))
+	err = a.repository.UpdateUserPassword(ctx, int64(user.ID), hashedNewPassword, changedNotification)
 	if err != nil {
 		return status_codes.ChangePasswordError, errors.Join(fmt.Errorf("failed to update password"), err)
 	}
 
-	err = util2.SendPasswordChangedEmail(a.emailConfig, []string{user.Email}, *user)
+	return status_codes.ChangePasswordSuccess, nil
+}
+
+// ForgotPassword emails a single-use password reset link to the user. It reports success even
+// if no account uses the email, so the endpoint cannot be used to find registered emails.
+func (a AuthUseCases) ForgotPassword(ctx context.Context, request entities.ForgotPasswordRequest) (status_codes.ForgotPasswordStatus, error) {
+	request.Email = util.TrimSpace(request.Email)
+	if !rules.IsValidEmail(request.Email) {
+		return status_codes.ForgotPasswordStatusInvalidEmail, nil
+	}
+
+	user, err := a.repository.GetUserByEmail(ctx, request.Email)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to send password changed email", "cause", err)
+		return status_codes.ForgotPasswordStatusError, errors.Join(fmt.Errorf("failed to get user by email"), err)
 	}
 
-	return status_codes.ChangePasswordSuccess, nil
+	if user == nil {
+		return status_codes.ForgotPasswordStatusSuccess, nil
+	}
+
+	token, hash, err := a.authManager.CreateOpaqueToken()
+	if err != nil {
+		return status_codes.ForgotPasswordStatusError, errors.Join(fmt.Errorf("failed to generate reset token"), err)
+	}
+
+	reset := &entities.PasswordReset{
+		UserID:    int64(user.ID),
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(util.ResetTokenDuration),
+	}
+
+	link := a.baseURL + "/reset-password?token=" + token
+	email, err := a.templates.NewPasswordResetEmail(*user, link, util.ResetTokenDuration)
+	if err != nil {
+		return status_codes.ForgotPasswordStatusError, errors.Join(fmt.Errorf("failed to render password reset email"), err)
+	}
+
+	if _, err = a.repository.AddPasswordReset(ctx, reset, email); err != nil {
+		return status_codes.ForgotPasswordStatusError, errors.Join(fmt.Errorf("failed to add password reset"), err)
+	}
+
+	return status_codes.ForgotPasswordStatusSuccess, nil
+}
+
+// ResetPassword sets a new password using a reset token. The token can only be used once, and
+// every session of the user is revoked, since whoever had the old password may be logged in.
+func (a AuthUseCases) ResetPassword(ctx context.Context, request entities.ResetPasswordRequest) (status_codes.ResetPasswordStatus, error) {
+	request.Token = util.TrimSpace(request.Token)
+	request.NewPassword = util.TrimSpace(request.NewPassword)
+	request.NewPasswordConfirmation = util.TrimSpace(request.NewPasswordConfirmation)
+
+	if request.Token == "" || request.NewPassword == "" || request.NewPasswordConfirmation == "" {
+		return status_codes.ResetPasswordStatusIncomplete, nil
+	}
+
+	reset, err := a.repository.GetPasswordResetByHash(ctx, a.authManager.HashOpaqueToken(request.Token))
+	if err != nil {
+		return status_codes.ResetPasswordStatusError, errors.Join(fmt.Errorf("failed to get password reset"), err)
+	}
+
+	if reset == nil || reset.UsedAt != nil {
+		return status_codes.ResetPasswordStatusInvalidToken, nil
+	}
+
+	if !time.Now().Before(reset.ExpiresAt) {
+		return status_codes.ResetPasswordStatusExpiredToken, nil
+	}
+
+	if !rules.IsValidPassword(request.NewPassword) {
+		return status_codes.ResetPasswordStatusInvalidPassword, nil
+	}
+
+	if request.NewPassword != request.NewPasswordConfirmation {
+		return status_codes.ResetPasswordStatusPasswordsDontMatch, nil
+	}
+
+	user, err := a.repository.GetUserByID(ctx, reset.UserID)
+	if err != nil {
+		return status_codes.ResetPasswordStatusError, errors.Join(fmt.Errorf("failed to get user"), err)
+	}
+
+	if user == nil {
+		return status_codes.ResetPasswordStatusInvalidToken, nil
+	}
+
+	hashedPassword, err := a.authManager.HashPassword(request.NewPassword)
+	if err != nil {
+		return status_codes.ResetPasswordStatusError, errors.Join(fmt.Errorf("failed to hash password"), err)
+	}
+
+	changedNotification := notification(a.templates.NewPasswordChangedEmail(*user))
+	ok, err := a.repository.ResetPassword(ctx, reset, hashedPassword, changedNotification)
+	if err != nil {
+		return status_codes.ResetPasswordStatusError, errors.Join(fmt.Errorf("failed to reset password"), err)
+	}
+
+	if !ok {
+		return status_codes.ResetPasswordStatusInvalidToken, nil
+	}
+
+	if err = a.tokenRepository.RevokeUserTokens(ctx, reset.UserID); err != nil {
+		return status_codes.ResetPasswordStatusError, errors.Join(fmt.Errorf("failed to revoke user tokens"), err)
+	}
+
+	return status_codes.ResetPasswordStatusSuccess, nil
+}
+
+// VerifyEmail confirms the user's email with the token sent by email and welcomes the user
+func (a AuthUseCases) VerifyEmail(ctx context.Context, token string) (status_codes.VerifyEmailStatus, error) {
+	token = util.TrimSpace(token)
+	if token == "" {
+		return status_codes.VerifyEmailStatusInvalidToken, nil
+	}
+
+	verification, err := a.repository.GetEmailVerificationByHash(ctx, a.authManager.HashOpaqueToken(token))
+	if err != nil {
+		return status_codes.VerifyEmailStatusError, errors.Join(fmt.Errorf("failed to get email verification"), err)
+	}
+
+	if verification == nil || verification.UsedAt != nil {
+		return status_codes.VerifyEmailStatusInvalidToken, nil
+	}
+
+	if !time.Now().Before(verification.ExpiresAt) {
+		return status_codes.VerifyEmailStatusExpiredToken, nil
+	}
+
+	user, err := a.repository.GetUserByID(ctx, verification.UserID)
+	if err != nil {
+		return status_codes.VerifyEmailStatusError, errors.Join(fmt.Errorf("failed to get user"), err)
+	}
+
+	var welcome *entities.Email
+	if user != nil {
+		welcome = notification(a.templates.NewAccountCreatedEmail(*user))
+	}
+
+	ok, err := a.repository.VerifyEmail(ctx, verification, welcome)
+	if err != nil {
+		return status_codes.VerifyEmailStatusError, errors.Join(fmt.Errorf("failed to verify email"), err)
+	}
+
+	if !ok {
+		return status_codes.VerifyEmailStatusInvalidToken, nil
+	}
+
+	return status_codes.VerifyEmailStatusSuccess, nil
+}
+
+// ResendVerification sends a new verification email to an unverified user
+func (a AuthUseCases) ResendVerification(ctx context.Context, user *entities.User) (status_codes.ResendVerificationStatus, error) {
+	if user.IsVerified() {
+		return status_codes.ResendVerificationStatusAlreadyVerified, nil
+	}
+
+	latest, err := a.repository.GetLatestEmailVerification(ctx, int64(user.ID))
+	if err != nil {
+		return status_codes.ResendVerificationStatusError, errors.Join(fmt.Errorf("failed to get latest email verification"), err)
+	}
+
+	if latest != nil && time.Since(latest.CreatedAt) < verificationResendCooldown {
+		return status_codes.ResendVerificationStatusTooSoon, nil
+	}
+
+	if err = a.sendVerificationEmail(ctx, user); err != nil {
+		return status_codes.ResendVerificationStatusError, err
+	}
+
+	return status_codes.ResendVerificationStatusSuccess, nil
+}
+
+// sendVerificationEmail creates a verification token and queues the email with its link
+func (a AuthUseCases) sendVerificationEmail(ctx context.Context, user *entities.User) error {
+	verification, email, err := a.newEmailVerification(user)
+	if err != nil {
+		return err
+	}
+
+	if _, err = a.repository.AddEmailVerification(ctx, verification, *email); err != nil {
+		return errors.Join(fmt.Errorf("failed to add email verification"), err)
+	}
+
+	return nil
+}
+
+// newEmailVerification creates a verification token for the user's email and renders the
+// email with its link, for the caller to store them together
+func (a AuthUseCases) newEmailVerification(user *entities.User) (*entities.EmailVerification, *entities.Email, error) {
+	token, hash, err := a.authManager.CreateOpaqueToken()
+	if err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("failed to generate verification token"), err)
+	}
+
+	verification := &entities.EmailVerification{
+		UserID:    int64(user.ID),
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(util.VerifyTokenDuration),
+	}
+
+	link := a.baseURL + "/api/auth/verify-email?token=" + token
+	email, err := a.templates.NewEmailVerificationEmail(*user, link, util.VerifyTokenDuration)
+	if err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("failed to render email verification"), err)
+	}
+
+	return verification, &email, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new pair of tokens. The refresh token is
+// single use: presenting an already rotated token revokes its whole family, since either the
+// client or an attacker holds a stolen copy.
+func (a AuthUseCases) RefreshTokens(
+	ctx context.Context,
+	refreshToken string,
+	client entities.SessionClient,
+) (*entities.AuthTokens, status_codes.RefreshTokenStatus, error) {
+	if refreshToken == "" {
+		return nil, status_codes.RefreshTokenStatusInvalidToken, nil
+	}
+
+	current, err := a.tokenRepository.GetRefreshTokenByHash(ctx, a.authManager.HashOpaqueToken(refreshToken))
+	if err != nil {
+		return nil, status_codes.RefreshTokenStatusError, errors.Join(fmt.Errorf("failed to get refresh token"), err)
+	}
+
+	if current == nil {
+		return nil, status_codes.RefreshTokenStatusInvalidToken, nil
+	}
+
+	if current.RevokedAt != nil {
+		return a.revokeReusedFamily(ctx, current)
+	}
+
+	if !time.Now().Before(current.ExpiresAt) {
+		return nil, status_codes.RefreshTokenStatusExpiredToken, nil
+	}
+
+	user, err := a.repository.GetUserByID(ctx, current.UserID)
+	if err != nil {
+		return nil, status_codes.RefreshTokenStatusError, errors.Join(fmt.Errorf("failed to get user"), err)
+	}
+
+	if user == nil || user.IsDisabled() {
+		return nil, status_codes.RefreshTokenStatusInvalidToken, nil
+	}
+
+	tokens, next, err := a.issueTokens(ctx, user, current.FamilyID)
+	if err != nil {
+		return nil, status_codes.RefreshTokenStatusError, err
+	}
+
+	err = a.tokenRepository.RotateRefreshToken(ctx, current, next)
+	if errors.Is(err, entities.ErrRefreshTokenReused) {
+		return a.revokeReusedFamily(ctx, current)
+	}
+
+	if err != nil {
+		return nil, status_codes.RefreshTokenStatusError, errors.Join(fmt.Errorf("failed to rotate refresh token"), err)
+	}
+
+	if err = a.tokenRepository.TouchSession(ctx, current.FamilyID, client.IPAddress); err != nil {
+		return nil, status_codes.RefreshTokenStatusError, errors.Join(fmt.Errorf("failed to update session"), err)
+	}
+
+	return tokens, status_codes.RefreshTokenStatusSuccess, nil
+}
+
+// Logout ends the session of the given access token and the family of the given refresh token.
+// Either one is enough, so clients holding an expired access token can still log out.
+func (a AuthUseCases) Logout(ctx context.Context, accessToken, refreshToken string) (status_codes.LogoutStatus, error) {
+	revoked := false
+
+	if payload, err := a.authManager.VerifyToken(accessToken); err == nil {
+		if err = a.tokenRepository.RevokeAccessToken(ctx, payload.ID, payload.ExpiresAt); err != nil {
+			return status_codes.LogoutStatusError, errors.Join(fmt.Errorf("failed to revoke access token"), err)
+		}
+
+		if payload.SessionID != uuid.Nil {
+			if err = a.tokenRepository.RevokeTokenFamily(ctx, payload.SessionID); err != nil {
+				return status_codes.LogoutStatusError, errors.Join(fmt.Errorf("failed to revoke session"), err)
+			}
+		}
+		revoked = true
+	}
+
+	if refreshToken != "" {
+		token, err := a.tokenRepository.GetRefreshTokenByHash(ctx, a.authManager.HashOpaqueToken(refreshToken))
+		if err != nil {
+			return status_codes.LogoutStatusError, errors.Join(fmt.Errorf("failed to get refresh token"), err)
+		}
+
+		if token != nil {
+			if err = a.tokenRepository.RevokeTokenFamily(ctx, token.FamilyID); err != nil {
+				return status_codes.LogoutStatusError, errors.Join(fmt.Errorf("failed to revoke refresh tokens"), err)
+			}
+			revoked = true
+		}
+	}
+
+	if !revoked {
+		return status_codes.LogoutStatusInvalidToken, nil
+	}
+
+	return status_codes.LogoutStatusSuccess, nil
+}
+
+// RevokeUserTokens logs the user out of every device, revoking all of their refresh tokens
+// and the access tokens issued with them
+func (a AuthUseCases) RevokeUserTokens(ctx context.Context, userID int64) (status_codes.RevokeTokensStatus, error) {
+	user, err := a.repository.GetUserByID(ctx, userID)
+	if err != nil {
+		return status_codes.RevokeTokensStatusError, errors.Join(fmt.Errorf("failed to get user"), err)
+	}
+
+	if user == nil {
+		return status_codes.RevokeTokensStatusUserNotFound, nil
+	}
+
+	if err = a.tokenRepository.RevokeUserTokens(ctx, userID); err != nil {
+		return status_codes.RevokeTokensStatusError, errors.Join(fmt.Errorf("failed to revoke user tokens"), err)
+	}
+
+	return status_codes.RevokeTokensStatusSuccess, nil
+}
+
+// GetSessions returns the active sessions of the user, flagging the one making the request
+func (a AuthUseCases) GetSessions(ctx context.Context, userID int64, currentSessionID uuid.UUID) ([]entities.Session, error) {
+	sessions, err := a.tokenRepository.GetActiveSessions(ctx, userID, time.Now())
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get sessions"), err)
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession logs the device of one of the user's sessions out
+func (a AuthUseCases) RevokeSession(ctx context.Context, userID int64, sessionID uuid.UUID) (status_codes.RevokeSessionStatus, error) {
+	session, err := a.tokenRepository.GetSession(ctx, sessionID)
+	if err != nil {
+		return status_codes.RevokeSessionStatusError, errors.Join(fmt.Errorf("failed to get session"), err)
+	}
+
+	if session == nil || session.UserID != userID || session.RevokedAt != nil {
+		return status_codes.RevokeSessionStatusNotFound, nil
+	}
+
+	if err = a.tokenRepository.RevokeTokenFamily(ctx, sessionID); err != nil {
+		return status_codes.RevokeSessionStatusError, errors.Join(fmt.Errorf("failed to revoke session"), err)
+	}
+
+	return status_codes.RevokeSessionStatusSuccess, nil
+}
+
+// DeleteExpiredTokens purges the expired refresh tokens and revoked access tokens
+func (a AuthUseCases) DeleteExpiredTokens(ctx context.Context) error {
+	if err := a.tokenRepository.DeleteExpiredTokens(ctx, time.Now()); err != nil {
+		return errors.Join(fmt.Errorf("failed to delete expired tokens"), err)
+	}
+
+	return nil
+}
+
+// StartSession records a session for the client and issues the tokens of its refresh token
+// family
+func (a AuthUseCases) StartSession(
+	ctx context.Context,
+	user *entities.User,
+	client entities.SessionClient,
+) (*entities.AuthTokens, error) {
+	familyID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to generate token family"), err)
+	}
+
+	tokens, refreshToken, err := a.issueTokens(ctx, user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.tokenRepository.AddSession(ctx, &entities.Session{
+		ID:        familyID,
+		UserID:    int64(user.ID),
+		Device:    util.DeviceName(client.UserAgent),
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+	})
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to add session"), err)
+	}
+
+	if _, err = a.tokenRepository.AddRefreshToken(ctx, refreshToken); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to store refresh token"), err)
+	}
+
+	return tokens, nil
+}
+
+// issueTokens creates an access token and a refresh token in the given family. The access token
+// tells whether the user has two-factor authentication enabled.
+func (a AuthUseCases) issueTokens(
+	ctx context.Context,
+	user *entities.User,
+	familyID uuid.UUID,
+) (*entities.AuthTokens, *entities.RefreshToken, error) {
+	twoFactor, err := a.repository.GetTwoFactor(ctx, int64(user.ID))
+	if err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("failed to get two factor"), err)
+	}
+
+	accessToken, payload, err := a.authManager.CreateToken(familyID, user.Email, user.ID, user.Role, twoFactor != nil && twoFactor.IsEnabled())
+	if err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("failed to generate auth token"), err)
+	}
+
+	refreshToken, hash, err := a.authManager.CreateOpaqueToken()
+	if err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("failed to generate refresh token"), err)
+	}
+
+	tokens := &entities.AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    payload.ExpiresAt,
+	}
+
+	record := &entities.RefreshToken{
+		UserID:               int64(user.ID),
+		FamilyID:             familyID,
+		TokenHash:            hash,
+		AccessTokenID:        payload.ID,
+		AccessTokenExpiresAt: payload.ExpiresAt,
+		ExpiresAt:            time.Now().Add(util.RefreshTokenDuration),
+	}
+
+	return tokens, record, nil
+}
+
+func (a AuthUseCases) revokeReusedFamily(
+	ctx context.Context,
+	token *entities.RefreshToken,
+) (*entities.AuthTokens, status_codes.RefreshTokenStatus, error) {
+	slog.WarnContext(ctx, "refresh token reused, revoking its family", "user_id", token.UserID, "family_id", token.FamilyID)
+
+	if err := a.tokenRepository.RevokeTokenFamily(ctx, token.FamilyID); err != nil {
+		return nil, status_codes.RefreshTokenStatusError, errors.Join(fmt.Errorf("failed to revoke token family"), err)
+	}
+
+	return nil, status_codes.RefreshTokenStatusReusedToken, nil
+}
+
+// SetupTwoFactor starts the two-factor enrollment of the user, generating a new TOTP secret.
+// It is only enabled once the user confirms it with EnableTwoFactor.
+func (a AuthUseCases) SetupTwoFactor(ctx context.Context, user *entities.User) (*entities.TwoFactorSetup, status_codes.SetupTwoFactorStatus, error) {
+	twoFactor, err := a.repository.GetTwoFactor(ctx, int64(user.ID))
+	if err != nil {
+		return nil, status_codes.SetupTwoFactorStatusError, errors.Join(fmt.Errorf("failed to get two factor"), err)
+	}
+
+	if twoFactor != nil && twoFactor.IsEnabled() {
+		return nil, status_codes.SetupTwoFactorStatusAlreadyEnabled, nil
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		return nil, status_codes.SetupTwoFactorStatusError, err
+	}
+
+	if err = a.repository.SaveTwoFactorSecret(ctx, int64(user.ID), secret); err != nil {
+		return nil, status_codes.SetupTwoFactorStatusError, errors.Join(fmt.Errorf("failed to save two factor secret"), err)
+	}
+
+	uri := util.TOTPURI(user.Email, secret)
+	qrCode, err := util.TOTPQRCode(uri)
+	if err != nil {
+		return nil, status_codes.SetupTwoFactorStatusError, err
+	}
+
+	setup := &entities.TwoFactorSetup{
+		Secret: secret,
+		URI:    uri,
+		QRCode: qrCode,
+	}
+
+	return setup, status_codes.SetupTwoFactorStatusSuccess, nil
+}
+
+// EnableTwoFactor confirms the enrollment with a code of the authenticator, returning the
+// recovery codes. Every session of the user is revoked, so the user logs in again with 2FA.
+func (a AuthUseCases) EnableTwoFactor(
+	ctx context.Context,
+	user *entities.User,
+	request entities.TwoFactorCodeRequest,
+) (*entities.RecoveryCodes, status_codes.EnableTwoFactorStatus, error) {
+	twoFactor, err := a.repository.GetTwoFactor(ctx, int64(user.ID))
+	if err != nil {
+		return nil, status_codes.EnableTwoFactorStatusError, errors.Join(fmt.Errorf("failed to get two factor"), err)
+	}
+
+	if twoFactor == nil {
+		return nil, status_codes.EnableTwoFactorStatusNotStarted, nil
+	}
+
+	if twoFactor.IsEnabled() {
+		return nil, status_codes.EnableTwoFactorStatusAlreadyEnabled, nil
+	}
+
+	step, ok := util.ValidateTOTP(twoFactor.Secret, request.Code, time.Now())
+	if !ok {
+		return nil, status_codes.EnableTwoFactorStatusInvalidCode, nil
+	}
+
+	codes, hashes, err := a.generateRecoveryCodes()
+	if err != nil {
+		return nil, status_codes.EnableTwoFactorStatusError, err
+	}
+
+	enabled, err := a.repository.EnableTwoFactor(ctx, int64(user.ID), step, hashes)
+	if err != nil {
+		return nil, status_codes.EnableTwoFactorStatusError, errors.Join(fmt.Errorf("failed to enable two factor"), err)
+	}
+
+	if !enabled {
+		return nil, status_codes.EnableTwoFactorStatusAlreadyEnabled, nil
+	}
+
+	if err = a.tokenRepository.RevokeUserTokens(ctx, int64(user.ID)); err != nil {
+		return nil, status_codes.EnableTwoFactorStatusError, errors.Join(fmt.Errorf("failed to revoke user tokens"), err)
+	}
+
+	return &entities.RecoveryCodes{Codes: codes}, status_codes.EnableTwoFactorStatusSuccess, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off, asking for both the password and a code.
+// Staff cannot disable it while it is mandatory for them.
+func (a AuthUseCases) DisableTwoFactor(
+	ctx context.Context,
+	user *entities.User,
+	request entities.DisableTwoFactorRequest,
+) (status_codes.DisableTwoFactorStatus, error) {
+	if user.Role.IsStaff() && a.authManager.RequireAdminTwoFactor() {
+		return status_codes.DisableTwoFactorStatusRequired, nil
+	}
+
+	twoFactor, err := a.repository.GetTwoFactor(ctx, int64(user.ID))
+	if err != nil {
+		return status_codes.DisableTwoFactorStatusError, errors.Join(fmt.Errorf("failed to get two factor"), err)
+	}
+
+	if twoFactor == nil || !twoFactor.IsEnabled() {
+		return status_codes.DisableTwoFactorStatusNotEnabled, nil
+	}
+
+	if !a.authManager.CheckPasswordHash(request.Password, user.Password) {
+		return status_codes.DisableTwoFactorStatusInvalidPassword, nil
+	}
+
+	ok, err := a.checkTwoFactorCode(ctx, twoFactor, request.Code)
+	if err != nil {
+		return status_codes.DisableTwoFactorStatusError, err
+	}
+
+	if !ok {
+		return status_codes.DisableTwoFactorStatusInvalidCode, nil
+	}
+
+	if err = a.repository.DisableTwoFactor(ctx, int64(user.ID)); err != nil {
+		return status_codes.DisableTwoFactorStatusError, errors.Join(fmt.Errorf("failed to disable two factor"), err)
+	}
+
+	if err = a.tokenRepository.RevokeUserTokens(ctx, int64(user.ID)); err != nil {
+		return status_codes.DisableTwoFactorStatusError, errors.Join(fmt.Errorf("failed to revoke user tokens"), err)
+	}
+
+	return status_codes.DisableTwoFactorStatusSuccess, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, invalidating the previous ones
+func (a AuthUseCases) RegenerateRecoveryCodes(
+	ctx context.Context,
+	user *entities.User,
+	request entities.TwoFactorCodeRequest,
+) (*entities.RecoveryCodes, status_codes.RegenerateRecoveryCodesStatus, error) {
+	twoFactor, err := a.repository.GetTwoFactor(ctx, int64(user.ID))
+	if err != nil {
+		return nil, status_codes.RegenerateRecoveryCodesStatusError, errors.Join(fmt.Errorf("failed to get two factor"), err)
+	}
+
+	if twoFactor == nil || !twoFactor.IsEnabled() {
+		return nil, status_codes.RegenerateRecoveryCodesStatusNotEnabled, nil
+	}
+
+	ok, err := a.checkTwoFactorCode(ctx, twoFactor, request.Code)
+	if err != nil {
+		return nil, status_codes.RegenerateRecoveryCodesStatusError, err
+	}
+
+	if !ok {
+		return nil, status_codes.RegenerateRecoveryCodesStatusInvalidCode, nil
+	}
+
+	codes, hashes, err := a.generateRecoveryCodes()
+	if err != nil {
+		return nil, status_codes.RegenerateRecoveryCodesStatusError, err
+	}
+
+	if err = a.repository.ReplaceRecoveryCodes(ctx, int64(user.ID), hashes); err != nil {
+		return nil, status_codes.RegenerateRecoveryCodesStatusError, errors.Join(fmt.Errorf("failed to replace recovery codes"), err)
+	}
+
+	return &entities.RecoveryCodes{Codes: codes}, status_codes.RegenerateRecoveryCodesStatusSuccess, nil
+}
+
+// checkTwoFactorCode accepts either a TOTP code, which cannot be used twice, or an unused
+// recovery code, which is consumed
+func (a AuthUseCases) checkTwoFactorCode(ctx context.Context, twoFactor *entities.TwoFactor, code string) (bool, error) {
+	if step, ok := util.ValidateTOTP(twoFactor.Secret, code, time.Now()); ok {
+		used, err := a.repository.UseTwoFactorStep(ctx, twoFactor.UserID, step)
+		if err != nil {
+			return false, errors.Join(fmt.Errorf("failed to use two factor code"), err)
+		}
+
+		return used, nil
+	}
+
+	hash := a.authManager.HashOpaqueToken(util.NormalizeRecoveryCode(code))
+	used, err := a.repository.UseRecoveryCode(ctx, twoFactor.UserID, hash)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to use recovery code"), err)
+	}
+
+	return used, nil
+}
+
+// generateRecoveryCodes creates the recovery codes along with the hashes to be stored
+func (a AuthUseCases) generateRecoveryCodes() ([]string, []string, error) {
+	codes, err := util.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = a.authManager.HashOpaqueToken(code)
+	}
+
+	return codes, hashes, nil
+}
+
+// notification returns the rendered email, or nil if it failed to render, for the emails that
+// must not prevent the event they report
+func notification(email entities.Email, err error) *entities.Email {
+	if err != nil {
+		slog.Error("failed to render notification email", "cause", err)
+		return nil
+	}
+
+	return &email
 }
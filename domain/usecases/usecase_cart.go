@@ -2,20 +2,34 @@ package usecases
 
 import (
 	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/rules"
 	"cachacariaapi/domain/status_codes"
 	"cachacariaapi/domain/util"
 	repositories "cachacariaapi/infrastructure/datastore"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+// cartReminderBatchSize is the number of abandoned carts reminded on each run of the job
+const cartReminderBatchSize = 50
+
 type CartUseCases struct {
-	cartRepository    repositories.CartRepository
-	userRepository    repositories.UserRepository
-	productRepository repositories.ProductRepository
-	orderRepository   repositories.OrderRepository
-	baseURL           string
+	cartRepository      repositories.CartRepository
+	userRepository      repositories.UserRepository
+	productRepository   repositories.ProductRepository
+	orderRepository     repositories.OrderRepository
+	couponRepository    repositories.CouponRepository
+	promotionRepository repositories.PromotionRepository
+	orderUseCases       OrderUseCases
+	consentUseCases     ConsentUseCases
+	templates           *util.EmailTemplates
+	reminderPolicy      entities.CartReminderPolicy
+	baseURL             string
 }
 
 func NewCartUseCases(
@@ -23,14 +37,26 @@ func NewCartUseCases(
 	userRepository repositories.UserRepository,
 	productRepository repositories.ProductRepository,
 	orderRepository repositories.OrderRepository,
+	couponRepository repositories.CouponRepository,
+	promotionRepository repositories.PromotionRepository,
+	orderUseCases OrderUseCases,
+	consentUseCases ConsentUseCases,
+	templates *util.EmailTemplates,
+	reminderPolicy entities.CartReminderPolicy,
 	baseURL string,
 ) CartUseCases {
 	return CartUseCases{
-		cartRepository:    repo,
-		productRepository: productRepository,
-		userRepository:    userRepository,
-		orderRepository:   orderRepository,
-		baseURL:           baseURL,
+		cartRepository:      repo,
+		productRepository:   productRepository,
+		userRepository:      userRepository,
+		orderRepository:     orderRepository,
+		couponRepository:    couponRepository,
+		promotionRepository: promotionRepository,
+		orderUseCases:       orderUseCases,
+		consentUseCases:     consentUseCases,
+		templates:           templates,
+		reminderPolicy:      reminderPolicy,
+		baseURL:             baseURL,
 	}
 }
 
@@ -65,13 +91,15 @@ func (uc *CartUseCases) AddToCart(ctx context.Context, userID, productID int64,
 	return status_codes.AddProductItemStatusSuccess, nil
 }
 
-func (uc *CartUseCases) GetCartItems(ctx context.Context, userID int64) ([]*entities.CartItem, error) {
-	items, err := uc.cartRepository.GetCartItems(ctx, userID)
+// GetCartItems returns the user's cart priced with the discounts of the active promotions and
+// of the applied coupon, if any
+func (uc *CartUseCases) GetCartItems(ctx context.Context, userID int64) (*entities.Cart, error) {
+	cart, err := uc.priceCart(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, item := range items {
+	for _, item := range cart.Items {
 		if item.Product != nil && len(item.Product.Photos) > 0 {
 			photos := make([]string, len(item.Product.Photos))
 			for i, filename := range item.Product.Photos {
@@ -81,7 +109,135 @@ func (uc *CartUseCases) GetCartItems(ctx context.Context, userID int64) ([]*enti
 		}
 	}
 
-	return items, nil
+	return cart, nil
+}
+
+// loadCart loads the user's cart, priced with the active promotions
+func (uc *CartUseCases) loadCart(ctx context.Context, userID int64) (*entities.Cart, error) {
+	items, err := uc.cartRepository.GetCartItems(ctx, userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get cart items"), err)
+	}
+
+	cart := newCart(items)
+
+	promotions, err := uc.promotionRepository.GetActivePromotions(ctx, time.Now())
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get active promotions"), err)
+	}
+
+	applyPromotions(cart, promotions)
+	return cart, nil
+}
+
+// priceCart loads the user's cart and computes its subtotal, discounts and total
+func (uc *CartUseCases) priceCart(ctx context.Context, userID int64) (*entities.Cart, error) {
+	cart, err := uc.loadCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	coupon, err := uc.cartRepository.GetCartCoupon(ctx, userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get cart coupon"), err)
+	}
+
+	if coupon != nil {
+		status, err := uc.applyCouponToCart(ctx, userID, cart, coupon)
+		if err != nil {
+			return nil, err
+		}
+
+		if status != status_codes.ApplyCouponStatusSuccess {
+			cart.CouponMessage = status.String()
+		}
+	}
+
+	cart.Discount = roundPrice(cart.PromotionDiscount + cart.CouponDiscount)
+	cart.Total = roundPrice(cart.Subtotal - cart.Discount)
+	if cart.Total < 0 {
+		cart.Total = 0
+	}
+
+	return cart, nil
+}
+
+// newCart prices each cart item with the current product price and sums the cart subtotal
+func newCart(items []*entities.CartItem) *entities.Cart {
+	if items == nil {
+		items = []*entities.CartItem{}
+	}
+
+	cart := &entities.Cart{Items: items, Promotions: []entities.AppliedPromotion{}}
+	for _, item := range items {
+		if item.Product != nil {
+			item.UnitPrice = roundPrice(float64(item.Product.Price))
+		}
+
+		item.Subtotal = roundPrice(item.UnitPrice * float64(item.Quantity))
+		item.Total = item.Subtotal
+		cart.Subtotal += item.Subtotal
+	}
+
+	cart.Subtotal = roundPrice(cart.Subtotal)
+	return cart
+}
+
+// applyCouponToCart evaluates the coupon against the cart and, if applicable, fills the coupon discounts
+func (uc *CartUseCases) applyCouponToCart(
+	ctx context.Context,
+	userID int64,
+	cart *entities.Cart,
+	coupon *entities.Coupon,
+) (status_codes.ApplyCouponStatus, error) {
+	userUsages, err := uc.couponRepository.CountUserUsages(ctx, coupon.ID, userID)
+	if err != nil {
+		return status_codes.ApplyCouponStatusError, errors.Join(fmt.Errorf("failed to count coupon usages"), err)
+	}
+
+	subtotal := roundPrice(cart.Subtotal - cart.PromotionDiscount)
+	discount, freeShipping, status := evaluateCoupon(coupon, cart.Items, subtotal, userUsages, time.Now())
+
+	cart.Coupon = coupon
+	if status == status_codes.ApplyCouponStatusSuccess {
+		cart.CouponDiscount = discount
+		cart.FreeShipping = freeShipping
+	}
+
+	return status, nil
+}
+
+// ApplyCoupon validates the coupon with the given code against the user's cart and attaches it to the cart
+func (uc *CartUseCases) ApplyCoupon(ctx context.Context, userID int64, code string) (status_codes.ApplyCouponStatus, error) {
+	coupon, err := uc.couponRepository.GetCouponByCode(ctx, normalizeCouponCode(code))
+	if err != nil {
+		return status_codes.ApplyCouponStatusError, errors.Join(fmt.Errorf("failed to get coupon"), err)
+	}
+
+	if coupon == nil || !coupon.Active {
+		return status_codes.ApplyCouponStatusNotFound, nil
+	}
+
+	cart, err := uc.loadCart(ctx, userID)
+	if err != nil {
+		return status_codes.ApplyCouponStatusError, err
+	}
+
+	status, err := uc.applyCouponToCart(ctx, userID, cart, coupon)
+	if err != nil || status != status_codes.ApplyCouponStatusSuccess {
+		return status, err
+	}
+
+	if err = uc.cartRepository.SetCartCoupon(ctx, userID, coupon.ID); err != nil {
+		return status_codes.ApplyCouponStatusError, errors.Join(fmt.Errorf("failed to set cart coupon"), err)
+	}
+
+	return status_codes.ApplyCouponStatusSuccess, nil
+}
+
+// RemoveCoupon detaches the applied coupon from the user's cart
+func (uc *CartUseCases) RemoveCoupon(ctx context.Context, userID int64) error {
+	return uc.cartRepository.RemoveCartCoupon(ctx, userID)
 }
 
 func (uc *CartUseCases) UpdateCartItem(ctx context.Context, userID, productID int64, quantity int) error {
@@ -96,17 +252,46 @@ func (uc *CartUseCases) ClearCart(ctx context.Context, userID int64) error {
 	return uc.cartRepository.ClearCart(ctx, userID)
 }
 
-func (uc *CartUseCases) BuyItems(ctx context.Context, userID int64) (status_codes.BuyProductsStatus, error) {
-	items, err := uc.cartRepository.GetCartItems(ctx, userID)
+// BuyItems places an order with the items in the cart, shipped to the address in the request,
+// and queues its confirmation email
+func (uc *CartUseCases) BuyItems(ctx context.Context, userID int64, request entities.CheckoutRequest) (status_codes.BuyProductsStatus, error) {
+	user, err := uc.userRepository.FindById(userID)
+	if err != nil {
+		return status_codes.BuyProductsStatusError, errors.Join(fmt.Errorf("failed to get user"), err)
+	}
+
+	if user == nil || !user.IsVerified() {
+		return status_codes.BuyProductsStatusUnverifiedUser, nil
+	}
+
+	if !hasVerifiedAge(user, time.Now()) {
+		return status_codes.BuyProductsStatusAgeNotVerified, nil
+	}
+
+	consents, err := uc.consentUseCases.GetPreferences(ctx, userID)
 	if err != nil {
 		return status_codes.BuyProductsStatusError, err
 	}
 
-	if len(items) == 0 {
+	if consents.RequiresAcceptance {
+		return status_codes.BuyProductsStatusTermsNotAccepted, nil
+	}
+
+	address, ok := normalizeShippingAddress(request.ShippingAddress)
+	if !ok {
+		return status_codes.BuyProductsStatusInvalidAddress, nil
+	}
+
+	cart, err := uc.priceCart(ctx, userID)
+	if err != nil {
+		return status_codes.BuyProductsStatusError, err
+	}
+
+	if len(cart.Items) == 0 {
 		return status_codes.BuyProductsStatusCartEmpty, nil
 	}
 
-	for _, item := range items {
+	for _, item := range cart.Items {
 		product, err := uc.productRepository.GetProduct(item.ProductID)
 		if err != nil {
 			return status_codes.BuyProductsStatusError, err
@@ -121,11 +306,34 @@ func (uc *CartUseCases) BuyItems(ctx context.Context, userID int64) (status_code
 		}
 	}
 
-	err = uc.cartRepository.ClearCart(ctx, userID)
+	if cart.Coupon != nil && cart.CouponMessage != "" {
+		return status_codes.BuyProductsStatusCouponUnavailable, nil
+	}
+
+	var placed *entities.Notification
+	orderID, err := uc.cartRepository.Checkout(ctx, userID, cart, address, func(orderID int64) *entities.Email {
+		var confirmation *entities.Email
+		placed, confirmation = uc.orderUseCases.placedOrderNotification(ctx, orderID, userID, cart, address)
+		return confirmation
+	})
 	if err != nil {
+		if errors.Is(err, entities.ErrCouponUnavailable) {
+			return status_codes.BuyProductsStatusCouponUnavailable, nil
+		}
+
+		if errors.Is(err, entities.ErrInsufficientStock) {
+			return status_codes.BuyProductsStatusOutOfStock, nil
+		}
+
 		return status_codes.BuyProductsStatusError, err
 	}
 
+	if placed != nil {
+		if err = uc.orderUseCases.notificationUseCases.NotifyOrderStatus(ctx, *placed); err != nil {
+			slog.ErrorContext(ctx, "failed to add order notification", "order_id", orderID, "cause", err)
+		}
+	}
+
 	return status_codes.BuyProductsStatusSuccess, nil
 }
 
@@ -144,13 +352,36 @@ func (uc *CartUseCases) GetOrders(ctx context.Context, userID int64) ([]entities
 		return nil, errors.Join(fmt.Errorf("failed to fetch orders"), err)
 	}
 
+	if err = fillOrderProducts(uc.productRepository, orders, uc.baseURL); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// GetAllOrders returns the orders of every user, for the back office
+func (uc *CartUseCases) GetAllOrders(ctx context.Context) ([]entities.Order, error) {
+	orders, err := uc.orderRepository.GetAllOrders(ctx)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to fetch orders"), err)
+	}
+
+	if err = fillOrderProducts(uc.productRepository, orders, uc.baseURL); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// fillOrderProducts loads the product of each order item, with its photo URLs
+func fillOrderProducts(productRepository repositories.ProductRepository, orders []entities.Order, baseURL string) error {
 	for i := range orders {
 		for j := range orders[i].Items {
 			item := &orders[i].Items[j]
 
-			product, err := uc.productRepository.GetProduct(item.ProductID)
+			product, err := productRepository.GetProduct(item.ProductID)
 			if err != nil {
-				return nil, err
+				return err
 			}
 
 			item.Product = product
@@ -158,12 +389,114 @@ func (uc *CartUseCases) GetOrders(ctx context.Context, userID int64) ([]entities
 			if product != nil && len(product.Photos) > 0 {
 				photos := make([]string, len(product.Photos))
 				for k, filename := range product.Photos {
-					photos[k] = util.GetProductImageURL(filename, uc.baseURL)
+					photos[k] = util.GetProductImageURL(filename, baseURL)
 				}
 				item.Product.Photos = photos
 			}
 		}
 	}
 
-	return orders, nil
+	return nil
+}
+
+// SendCartReminders emails the users whose carts were left untouched for the policy's
+// AbandonedAfter, up to MaxReminders times per cart. Reminders are marketing emails, so users
+// without the opt-in are skipped, but their carts are counted as reminded all the same, so
+// that they are not picked again on every run. A cart that fails is skipped until the next run,
+// without holding back the others. A MaxReminders of zero disables the reminders.
+func (uc *CartUseCases) SendCartReminders(ctx context.Context) error {
+	if uc.reminderPolicy.MaxReminders == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	carts, err := uc.cartRepository.GetAbandonedCarts(ctx, now.Add(-uc.reminderPolicy.AbandonedAfter), uc.reminderPolicy.MaxReminders, cartReminderBatchSize)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to get abandoned carts"), err)
+	}
+
+	var errs []error
+	for _, abandoned := range carts {
+		email, err := uc.cartReminder(ctx, abandoned)
+		if err == nil {
+			err = uc.cartRepository.AddCartReminder(ctx, abandoned, now, email)
+		}
+
+		if err != nil {
+			errs = append(errs, errors.Join(fmt.Errorf("failed to remind cart of user %d", abandoned.UserID), err))
+			if err = uc.cartRepository.AddCartReminderFailure(ctx, abandoned, now); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if email != nil {
+			slog.InfoContext(ctx, "cart reminder queued", "user_id", abandoned.UserID, "reminder", abandoned.RemindersSent+1)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// cartReminder renders the reminder of the cart, or returns nil if the user cannot be emailed
+// or the cart is empty by now
+func (uc *CartUseCases) cartReminder(ctx context.Context, abandoned entities.AbandonedCart) (*entities.Email, error) {
+	user, err := uc.userRepository.FindById(abandoned.UserID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get user"), err)
+	}
+
+	if user == nil {
+		return nil, nil
+	}
+
+	cart, err := uc.GetCartItems(ctx, abandoned.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cart.Items) == 0 {
+		return nil, nil
+	}
+
+	email, err := uc.templates.NewCartReminderEmail(*user, *cart, uc.baseURL+"/cart")
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to render cart reminder"), err)
+	}
+
+	return uc.consentUseCases.MarketingEmail(ctx, *user, email.Subject, email.Body)
+}
+
+// hasVerifiedAge tells whether the user confirmed being of legal age to buy alcoholic beverages
+func hasVerifiedAge(user *entities.User, now time.Time) bool {
+	return user.AgeVerifiedAt != nil && user.BirthDate != nil && rules.IsAdult(*user.BirthDate, now)
+}
+
+// normalizeShippingAddress trims the address and formats its CEP and state, and tells whether
+// every required field is filled and valid
+func normalizeShippingAddress(address entities.ShippingAddress) (entities.ShippingAddress, bool) {
+	address.RecipientName = strings.TrimSpace(address.RecipientName)
+	address.ZipCode = rules.NormalizeZipCode(address.ZipCode)
+	address.Street = strings.TrimSpace(address.Street)
+	address.Number = strings.TrimSpace(address.Number)
+	address.Complement = strings.TrimSpace(address.Complement)
+	address.District = strings.TrimSpace(address.District)
+	address.City = strings.TrimSpace(address.City)
+	address.State = strings.ToUpper(strings.TrimSpace(address.State))
+
+	if !rules.IsValidName(address.RecipientName) || !rules.IsValidZipCode(address.ZipCode) || !rules.IsValidState(address.State) {
+		return address, false
+	}
+
+	for _, field := range []string{address.Street, address.Number, address.District, address.City} {
+		if field == "" || utf8.RuneCountInString(field) > rules.AddressFieldMaxLen {
+			return address, false
+		}
+	}
+
+	if utf8.RuneCountInString(address.Complement) > rules.AddressFieldMaxLen || utf8.RuneCountInString(address.Number) > rules.AddressNumberMaxLen {
+		return address, false
+	}
+
+	return address, true
 }
@@ -0,0 +1,202 @@
+package usecases
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/status_codes"
+	repositories "cachacariaapi/infrastructure/datastore"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ConsentUseCases manages the user's acceptance of the terms and privacy policy and the
+// marketing opt-ins. Every marketing message must go through it, so that users who did not
+// opt in are never contacted.
+type ConsentUseCases struct {
+	repository       repositories.ConsentRepository
+	outboxRepository repositories.EmailOutboxRepository
+	versions         entities.LegalVersions
+}
+
+func NewConsentUseCases(
+	repository repositories.ConsentRepository,
+	outboxRepository repositories.EmailOutboxRepository,
+	versions entities.LegalVersions,
+) ConsentUseCases {
+	return ConsentUseCases{
+		repository:       repository,
+		outboxRepository: outboxRepository,
+		versions:         versions,
+	}
+}
+
+// LegalVersions returns the versions of the documents users must accept
+func (u *ConsentUseCases) LegalVersions() entities.LegalVersions {
+	return u.versions
+}
+
+func (u *ConsentUseCases) GetPreferences(ctx context.Context, userID int64) (*entities.ConsentPreferences, error) {
+	consents, err := u.repository.GetCurrentConsents(ctx, userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get consents"), err)
+	}
+
+	preferences := &entities.ConsentPreferences{Current: u.versions}
+	for _, consent := range consents {
+		updatedAt := consent.CreatedAt
+
+		switch consent.Type {
+		case entities.ConsentTerms:
+			if consent.Granted {
+				preferences.TermsVersion = consent.Version
+				preferences.TermsAcceptedAt = &updatedAt
+			}
+		case entities.ConsentPrivacyPolicy:
+			if consent.Granted {
+				preferences.PrivacyPolicyVersion = consent.Version
+				preferences.PrivacyPolicyAcceptedAt = &updatedAt
+			}
+		case entities.ConsentMarketingEmail:
+			preferences.MarketingEmail = consent.Granted
+			preferences.MarketingEmailUpdatedAt = &updatedAt
+		case entities.ConsentMarketingSMS:
+			preferences.MarketingSMS = consent.Granted
+			preferences.MarketingSMSUpdatedAt = &updatedAt
+		}
+	}
+
+	preferences.RequiresAcceptance = !u.acceptsCurrentDocuments(preferences.TermsVersion, preferences.PrivacyPolicyVersion)
+	return preferences, nil
+}
+
+// GetHistory returns every consent given or withdrawn by the user, most recent first
+func (u *ConsentUseCases) GetHistory(ctx context.Context, userID int64) ([]entities.Consent, error) {
+	consents, err := u.repository.GetConsentHistory(ctx, userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get consent history"), err)
+	}
+
+	return consents, nil
+}
+
+// UpdatePreferences records the consents in the request that differ from the ones in force
+func (u *ConsentUseCases) UpdatePreferences(
+	ctx context.Context,
+	userID int64,
+	request entities.UpdateConsentRequest,
+	client entities.SessionClient,
+) (status_codes.UpdateConsentStatus, error) {
+	if request.TermsVersion == "" && request.PrivacyPolicyVersion == "" &&
+		request.MarketingEmail == nil && request.MarketingSMS == nil {
+		return status_codes.UpdateConsentStatusNothingToUpdate, nil
+	}
+
+	if request.TermsVersion != "" && request.TermsVersion != u.versions.TermsVersion {
+		return status_codes.UpdateConsentStatusOutdatedTerms, nil
+	}
+
+	if request.PrivacyPolicyVersion != "" && request.PrivacyPolicyVersion != u.versions.PrivacyPolicyVersion {
+		return status_codes.UpdateConsentStatusOutdatedPrivacyPolicy, nil
+	}
+
+	current, err := u.GetPreferences(ctx, userID)
+	if err != nil {
+		return status_codes.UpdateConsentStatusError, err
+	}
+
+	consents := make([]entities.Consent, 0)
+	if request.TermsVersion != "" && request.TermsVersion != current.TermsVersion {
+		consents = append(consents, newConsent(entities.ConsentTerms, request.TermsVersion, true, client))
+	}
+
+	if request.PrivacyPolicyVersion != "" && request.PrivacyPolicyVersion != current.PrivacyPolicyVersion {
+		consents = append(consents, newConsent(entities.ConsentPrivacyPolicy, request.PrivacyPolicyVersion, true, client))
+	}
+
+	if request.MarketingEmail != nil && (*request.MarketingEmail != current.MarketingEmail || current.MarketingEmailUpdatedAt == nil) {
+		consents = append(consents, newConsent(entities.ConsentMarketingEmail, "", *request.MarketingEmail, client))
+	}
+
+	if request.MarketingSMS != nil && (*request.MarketingSMS != current.MarketingSMS || current.MarketingSMSUpdatedAt == nil) {
+		consents = append(consents, newConsent(entities.ConsentMarketingSMS, "", *request.MarketingSMS, client))
+	}
+
+	if err = u.repository.AddConsents(ctx, userID, consents); err != nil {
+		return status_codes.UpdateConsentStatusError, errors.Join(fmt.Errorf("failed to add consents"), err)
+	}
+
+	return status_codes.UpdateConsentStatusSuccess, nil
+}
+
+// AllowsMarketing tells whether the user opted in to marketing through the channel, which is
+// either entities.ConsentMarketingEmail or entities.ConsentMarketingSMS
+func (u *ConsentUseCases) AllowsMarketing(ctx context.Context, userID int64, channel entities.ConsentType) (bool, error) {
+	granted, err := u.repository.HasConsent(ctx, userID, channel)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check marketing consent"), err)
+	}
+
+	return granted, nil
+}
+
+// SendMarketingEmail queues the email only if the user opted in to marketing emails, and tells
+// whether it was queued
+func (u *ConsentUseCases) SendMarketingEmail(ctx context.Context, user entities.User, subject, body string) (bool, error) {
+	email, err := u.MarketingEmail(ctx, user, subject, body)
+	if err != nil || email == nil {
+		return false, err
+	}
+
+	if err = u.outboxRepository.AddEmail(ctx, *email); err != nil {
+		return false, errors.Join(fmt.Errorf("failed to queue marketing email"), err)
+	}
+
+	return true, nil
+}
+
+// MarketingEmail returns the email addressed to the user, or nil if the user did not opt in to
+// marketing emails, for callers that queue it along with other changes
+func (u *ConsentUseCases) MarketingEmail(ctx context.Context, user entities.User, subject, body string) (*entities.Email, error) {
+	if user.IsDisabled() {
+		return nil, nil
+	}
+
+	allowed, err := u.AllowsMarketing(ctx, int64(user.ID), entities.ConsentMarketingEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	if !allowed {
+		slog.DebugContext(ctx, "marketing email skipped without opt-in", "user_id", user.ID)
+		return nil, nil
+	}
+
+	return &entities.Email{To: user.Email, Subject: subject, Body: body}, nil
+}
+
+// acceptsCurrentDocuments tells whether the versions are the current terms and privacy policy
+func (u *ConsentUseCases) acceptsCurrentDocuments(termsVersion, privacyPolicyVersion string) bool {
+	return termsVersion == u.versions.TermsVersion && privacyPolicyVersion == u.versions.PrivacyPolicyVersion
+}
+
+// registrationConsents records the documents accepted at registration and the marketing
+// channels the user opted in to, or out of
+func (u *ConsentUseCases) registrationConsents(credentials entities.UserCredentials, client entities.SessionClient) []entities.Consent {
+	return []entities.Consent{
+		newConsent(entities.ConsentTerms, credentials.TermsVersion, true, client),
+		newConsent(entities.ConsentPrivacyPolicy, credentials.PrivacyPolicyVersion, true, client),
+		newConsent(entities.ConsentMarketingEmail, "", credentials.MarketingEmail, client),
+		newConsent(entities.ConsentMarketingSMS, "", credentials.MarketingSMS, client),
+	}
+}
+
+func newConsent(consentType entities.ConsentType, version string, granted bool, client entities.SessionClient) entities.Consent {
+	return entities.Consent{
+		Type:      consentType,
+		Version:   version,
+		Granted:   granted,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+	}
+}
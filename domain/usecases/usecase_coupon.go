@@ -0,0 +1,185 @@
+package usecases
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/status_codes"
+	repositories "cachacariaapi/infrastructure/datastore"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const maxCouponCodeLength = 50
+
+type CouponUseCases struct {
+	couponRepository repositories.CouponRepository
+}
+
+func NewCouponUseCases(couponRepository repositories.CouponRepository) CouponUseCases {
+	return CouponUseCases{couponRepository: couponRepository}
+}
+
+func (u *CouponUseCases) AddCoupon(ctx context.Context, req entities.AddCouponRequest) (status_codes.AddCouponStatus, error) {
+	code := normalizeCouponCode(req.Code)
+	if code == "" || len(code) > maxCouponCodeLength || strings.ContainsAny(code, " \t\n") {
+		return status_codes.AddCouponStatusInvalidCode, nil
+	}
+
+	if !req.Type.IsValid() {
+		return status_codes.AddCouponStatusInvalidType, nil
+	}
+
+	switch req.Type {
+	case entities.CouponTypePercentage:
+		if req.Value <= 0 || req.Value > 100 {
+			return status_codes.AddCouponStatusInvalidValue, nil
+		}
+	case entities.CouponTypeFixedAmount:
+		if req.Value <= 0 {
+			return status_codes.AddCouponStatusInvalidValue, nil
+		}
+	}
+
+	if req.MinOrderValue < 0 {
+		return status_codes.AddCouponStatusInvalidValue, nil
+	}
+
+	if req.StartsAt.IsZero() {
+		req.StartsAt = time.Now()
+	}
+
+	if req.ExpiresAt.IsZero() || !req.ExpiresAt.After(req.StartsAt) {
+		return status_codes.AddCouponStatusInvalidPeriod, nil
+	}
+
+	if req.MaxUses < 0 || req.MaxUsesPerUser < 0 {
+		return status_codes.AddCouponStatusInvalidLimits, nil
+	}
+
+	existing, err := u.couponRepository.GetCouponByCode(ctx, code)
+	if err != nil {
+		return status_codes.AddCouponStatusError, errors.Join(fmt.Errorf("failed to check coupon code"), err)
+	}
+
+	if existing != nil {
+		return status_codes.AddCouponStatusCodeAlreadyExists, nil
+	}
+
+	coupon := &entities.Coupon{
+		Code:           code,
+		Type:           req.Type,
+		Value:          req.Value,
+		MinOrderValue:  req.MinOrderValue,
+		StartsAt:       req.StartsAt,
+		ExpiresAt:      req.ExpiresAt,
+		MaxUses:        req.MaxUses,
+		MaxUsesPerUser: req.MaxUsesPerUser,
+		ProductIDs:     req.ProductIDs,
+		CategoryIDs:    req.CategoryIDs,
+	}
+
+	if _, err = u.couponRepository.AddCoupon(ctx, coupon); err != nil {
+		return status_codes.AddCouponStatusError, errors.Join(fmt.Errorf("failed to add coupon"), err)
+	}
+
+	return status_codes.AddCouponStatusSuccess, nil
+}
+
+func (u *CouponUseCases) GetCoupons(ctx context.Context) ([]entities.Coupon, error) {
+	coupons, err := u.couponRepository.GetCoupons(ctx)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get coupons"), err)
+	}
+
+	return coupons, nil
+}
+
+func (u *CouponUseCases) DisableCoupon(ctx context.Context, id int64) (status_codes.DeleteCouponStatus, error) {
+	coupon, err := u.couponRepository.GetCouponByID(ctx, id)
+	if err != nil {
+		return status_codes.DeleteCouponStatusError, errors.Join(fmt.Errorf("failed to get coupon"), err)
+	}
+
+	if coupon == nil {
+		return status_codes.DeleteCouponStatusNotFound, nil
+	}
+
+	if err = u.couponRepository.DisableCoupon(ctx, id); err != nil {
+		return status_codes.DeleteCouponStatusError, errors.Join(fmt.Errorf("failed to disable coupon"), err)
+	}
+
+	return status_codes.DeleteCouponStatusSuccess, nil
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// evaluateCoupon checks whether the coupon can be applied to the given cart items and
+// returns the discount it grants and whether it grants free shipping. The coupon discounts
+// the line totals, after the automatic promotions.
+func evaluateCoupon(
+	coupon *entities.Coupon,
+	items []*entities.CartItem,
+	subtotal float64,
+	userUsages int,
+	now time.Time,
+) (float64, bool, status_codes.ApplyCouponStatus) {
+	if len(items) == 0 {
+		return 0, false, status_codes.ApplyCouponStatusCartEmpty
+	}
+
+	if !coupon.Active {
+		return 0, false, status_codes.ApplyCouponStatusNotFound
+	}
+
+	if now.Before(coupon.StartsAt) {
+		return 0, false, status_codes.ApplyCouponStatusNotStarted
+	}
+
+	if !now.Before(coupon.ExpiresAt) {
+		return 0, false, status_codes.ApplyCouponStatusExpired
+	}
+
+	if coupon.MaxUses > 0 && coupon.UsedCount >= coupon.MaxUses {
+		return 0, false, status_codes.ApplyCouponStatusUsageLimitReached
+	}
+
+	if coupon.MaxUsesPerUser > 0 && userUsages >= coupon.MaxUsesPerUser {
+		return 0, false, status_codes.ApplyCouponStatusUserLimitReached
+	}
+
+	var eligible float64
+	for _, item := range items {
+		if item.Product != nil && coupon.AppliesTo(item.Product) {
+			eligible += item.Total
+		}
+	}
+
+	if eligible == 0 {
+		return 0, false, status_codes.ApplyCouponStatusNotApplicable
+	}
+
+	if subtotal < coupon.MinOrderValue {
+		return 0, false, status_codes.ApplyCouponStatusMinOrderValue
+	}
+
+	switch coupon.Type {
+	case entities.CouponTypePercentage:
+		return roundPrice(eligible * coupon.Value / 100), false, status_codes.ApplyCouponStatusSuccess
+	case entities.CouponTypeFixedAmount:
+		return roundPrice(math.Min(coupon.Value, eligible)), false, status_codes.ApplyCouponStatusSuccess
+	case entities.CouponTypeFreeShipping:
+		return 0, true, status_codes.ApplyCouponStatusSuccess
+	default:
+		return 0, false, status_codes.ApplyCouponStatusNotApplicable
+	}
+}
+
+// roundPrice rounds a monetary value to cents
+func roundPrice(value float64) float64 {
+	return math.Round(value*100) / 100
+}
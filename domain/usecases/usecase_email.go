@@ -0,0 +1,187 @@
+package usecases
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/status_codes"
+	util2 "cachacariaapi/domain/util"
+	repositories "cachacariaapi/infrastructure/datastore"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+const (
+	emailBatchSize        = 20
+	emailLease            = 5 * time.Minute
+	emailRetryBaseDelay   = time.Minute
+	emailRetryMaxDelay    = 6 * time.Hour
+	maxEmailAttempts      = 8
+	sentEmailRetention    = 7 * 24 * time.Hour
+	defaultEmailsPageSize = 20
+	maxEmailsPageSize     = 100
+)
+
+// EmailUseCases delivers the emails queued in the outbox. Emails are queued in the same
+// transaction as the change they report, so none is lost when the SMTP server is down: the
+// delivery job retries them with an exponential backoff.
+type EmailUseCases struct {
+	outboxRepository repositories.EmailOutboxRepository
+	mailer           util2.Mailer
+	templates        *util2.EmailTemplates
+}
+
+func NewEmailUseCases(
+	outboxRepository repositories.EmailOutboxRepository,
+	mailer util2.Mailer,
+	templates *util2.EmailTemplates,
+) EmailUseCases {
+	return EmailUseCases{
+		outboxRepository: outboxRepository,
+		mailer:           mailer,
+		templates:        templates,
+	}
+}
+
+// DeliverPendingEmails sends a batch of the emails that are due. The claimed emails are leased,
+// so that other instances of the job skip them while they are being sent.
+func (u *EmailUseCases) DeliverPendingEmails(ctx context.Context) error {
+	emails, err := u.outboxRepository.ClaimDueEmails(ctx, time.Now(), emailBatchSize, emailLease)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to claim due emails"), err)
+	}
+
+	for _, email := range emails {
+		if err = u.deliver(ctx, email); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetEmails returns a page of the queued emails, of every status unless one is given
+func (u *EmailUseCases) GetEmails(ctx context.Context, status entities.OutboxStatus, page, limit int) (*entities.PaginatedOutboxEmails, error) {
+	if !status.IsValid() {
+		status = ""
+	}
+
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 {
+		limit = defaultEmailsPageSize
+	}
+
+	limit = min(limit, maxEmailsPageSize)
+
+	emails, total, err := u.outboxRepository.GetEmails(ctx, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get emails"), err)
+	}
+
+	return &entities.PaginatedOutboxEmails{
+		Page:   page,
+		Limit:  limit,
+		Total:  total,
+		Emails: emails,
+	}, nil
+}
+
+// RetryEmail queues again an email that failed too many times to be sent
+func (u *EmailUseCases) RetryEmail(ctx context.Context, id int64) (status_codes.RetryEmailStatus, error) {
+	ok, err := u.outboxRepository.RetryEmail(ctx, id, time.Now())
+	if err != nil {
+		return status_codes.RetryEmailStatusError, errors.Join(fmt.Errorf("failed to retry email"), err)
+	}
+
+	if !ok {
+		return status_codes.RetryEmailStatusNotFound, nil
+	}
+
+	return status_codes.RetryEmailStatusSuccess, nil
+}
+
+// DeleteSentEmails purges the emails sent longer ago than the retention
+func (u *EmailUseCases) DeleteSentEmails(ctx context.Context) error {
+	if err := u.outboxRepository.DeleteSentEmails(ctx, time.Now().Add(-sentEmailRetention)); err != nil {
+		return errors.Join(fmt.Errorf("failed to delete sent emails"), err)
+	}
+
+	return nil
+}
+
+// GetTemplates returns the names of the email templates and the locales they are available in
+func (u *EmailUseCases) GetTemplates() entities.EmailTemplates {
+	return entities.EmailTemplates{
+		Templates: util2.EmailTemplateNames,
+		Locales:   entities.Locales,
+	}
+}
+
+// PreviewTemplate renders the template in the locale with sample data, defaulting to
+// entities.DefaultLocale
+func (u *EmailUseCases) PreviewTemplate(name, locale string) (*entities.Email, status_codes.PreviewEmailStatus, error) {
+	if locale == "" {
+		locale = entities.DefaultLocale
+	}
+
+	if !entities.IsValidLocale(locale) {
+		return nil, status_codes.PreviewEmailStatusInvalidLocale, nil
+	}
+
+	if !slices.Contains(util2.EmailTemplateNames, name) {
+		return nil, status_codes.PreviewEmailStatusNotFound, nil
+	}
+
+	email, err := u.templates.Preview(name, locale)
+	if err != nil {
+		return nil, status_codes.PreviewEmailStatusError, errors.Join(fmt.Errorf("failed to render email template"), err)
+	}
+
+	return &email, status_codes.PreviewEmailStatusSuccess, nil
+}
+
+// deliver sends the email and records the outcome. A failed email is retried later, unless it
+// reached maxEmailAttempts, in which case it is set aside for an admin to retry.
+func (u *EmailUseCases) deliver(ctx context.Context, email entities.OutboxEmail) error {
+	err := u.mailer.Send(ctx, entities.Email{To: email.Recipient, Subject: email.Subject, Body: email.Body})
+	if err == nil {
+		if err = u.outboxRepository.MarkSent(ctx, email.ID, time.Now()); err != nil {
+			return errors.Join(fmt.Errorf("failed to mark email as sent"), err)
+		}
+
+		return nil
+	}
+
+	var nextAttemptAt *time.Time
+	if email.Attempts+1 < maxEmailAttempts {
+		next := time.Now().Add(emailRetryDelay(email.Attempts))
+		nextAttemptAt = &next
+		slog.WarnContext(ctx, "failed to send email, will retry", "id", email.ID, "next_attempt_at", next, "cause", err)
+	} else {
+		slog.ErrorContext(ctx, "failed to send email, giving up", "id", email.ID, "attempts", email.Attempts+1, "cause", err)
+	}
+
+	if err = u.outboxRepository.MarkFailed(ctx, email.ID, err.Error(), nextAttemptAt); err != nil {
+		return errors.Join(fmt.Errorf("failed to mark email as failed"), err)
+	}
+
+	return nil
+}
+
+// emailRetryDelay doubles the delay after each failed attempt, up to emailRetryMaxDelay
+func emailRetryDelay(attempts int) time.Duration {
+	delay := emailRetryBaseDelay
+	for range attempts {
+		delay *= 2
+		if delay >= emailRetryMaxDelay {
+			return emailRetryMaxDelay
+		}
+	}
+
+	return delay
+}
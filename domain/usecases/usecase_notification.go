@@ -0,0 +1,277 @@
+package usecases
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/status_codes"
+	util2 "cachacariaapi/domain/util"
+	repositories "cachacariaapi/infrastructure/datastore"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+const (
+	defaultNotificationsPageSize = 20
+	maxNotificationsPageSize     = 100
+	readNotificationRetention    = 90 * 24 * time.Hour
+	backInStockTimeout           = 5 * time.Minute
+)
+
+// NotificationUseCases keeps the users' notification center. The other use cases report the
+// events that concern a user through it, and each notification is also emailed when the
+// user's preferences allow it.
+type NotificationUseCases struct {
+	notificationRepository repositories.NotificationRepository
+	userRepository         repositories.UserRepository
+	productRepository      repositories.ProductRepository
+	wishlistRepository     repositories.WishlistRepository
+	templates              *util2.EmailTemplates
+	baseURL                string
+}
+
+func NewNotificationUseCases(
+	notificationRepository repositories.NotificationRepository,
+	userRepository repositories.UserRepository,
+	productRepository repositories.ProductRepository,
+	wishlistRepository repositories.WishlistRepository,
+	templates *util2.EmailTemplates,
+	baseURL string,
+) NotificationUseCases {
+	return NotificationUseCases{
+		notificationRepository: notificationRepository,
+		userRepository:         userRepository,
+		productRepository:      productRepository,
+		wishlistRepository:     wishlistRepository,
+		templates:              templates,
+		baseURL:                baseURL,
+	}
+}
+
+// GetNotifications returns a page of the user's notifications, or of the unread ones, along
+// with the number of unread notifications
+func (u *NotificationUseCases) GetNotifications(ctx context.Context, userID int64, unreadOnly bool, page, limit int) (*entities.PaginatedNotifications, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 {
+		limit = defaultNotificationsPageSize
+	}
+
+	limit = min(limit, maxNotificationsPageSize)
+
+	notifications, total, err := u.notificationRepository.GetNotifications(ctx, userID, unreadOnly, limit, (page-1)*limit)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get notifications"), err)
+	}
+
+	unread, err := u.notificationRepository.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.PaginatedNotifications{
+		Page:          page,
+		Limit:         limit,
+		Total:         total,
+		Unread:        unread,
+		Notifications: notifications,
+	}, nil
+}
+
+func (u *NotificationUseCases) GetUnreadCount(ctx context.Context, userID int64) (*entities.UnreadNotifications, error) {
+	unread, err := u.notificationRepository.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.UnreadNotifications{Unread: unread}, nil
+}
+
+func (u *NotificationUseCases) MarkRead(ctx context.Context, userID, id int64) (status_codes.ReadNotificationStatus, error) {
+	ok, err := u.notificationRepository.MarkRead(ctx, userID, id, time.Now())
+	if err != nil {
+		return status_codes.ReadNotificationStatusError, err
+	}
+
+	if !ok {
+		return status_codes.ReadNotificationStatusNotFound, nil
+	}
+
+	return status_codes.ReadNotificationStatusSuccess, nil
+}
+
+func (u *NotificationUseCases) MarkAllRead(ctx context.Context, userID int64) error {
+	return u.notificationRepository.MarkAllRead(ctx, userID, time.Now())
+}
+
+// GetPreferences returns whether each type of notification is emailed to the user, with the
+// default of the types the user did not choose
+func (u *NotificationUseCases) GetPreferences(ctx context.Context, userID int64) ([]entities.NotificationPreference, error) {
+	chosen, err := u.notificationRepository.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make([]entities.NotificationPreference, len(entities.NotificationTypes))
+	for i, notificationType := range entities.NotificationTypes {
+		preferences[i] = entities.NotificationPreference{Type: notificationType, Email: notificationType.EmailByDefault()}
+		for _, preference := range chosen {
+			if preference.Type == notificationType {
+				preferences[i].Email = preference.Email
+			}
+		}
+	}
+
+	return preferences, nil
+}
+
+func (u *NotificationUseCases) UpdatePreferences(
+	ctx context.Context,
+	userID int64,
+	req entities.UpdateNotificationPreferencesRequest,
+) (status_codes.UpdateNotificationPreferencesStatus, error) {
+	for _, preference := range req.Preferences {
+		if !slices.Contains(entities.NotificationTypes, preference.Type) {
+			return status_codes.UpdateNotificationPreferencesStatusInvalidType, nil
+		}
+	}
+
+	if err := u.notificationRepository.SetPreferences(ctx, userID, req.Preferences); err != nil {
+		return status_codes.UpdateNotificationPreferencesStatusError, err
+	}
+
+	return status_codes.UpdateNotificationPreferencesStatusSuccess, nil
+}
+
+// NotifyOrderStatus adds the notification of an order status change. Its email is the order
+// email, which OrderUseCases queues along with the change.
+func (u *NotificationUseCases) NotifyOrderStatus(ctx context.Context, notification entities.Notification) error {
+	if _, err := u.notificationRepository.AddNotification(ctx, &notification, nil); err != nil {
+		return errors.Join(fmt.Errorf("failed to add order notification"), err)
+	}
+
+	return nil
+}
+
+// NotifyBackInStock notifies the users who have the restocked product in their wishlist that it
+// is available again. They are notified in the background, with a context of their own, so that
+// the request that restocked the product neither waits for them nor cancels them.
+func (u *NotificationUseCases) NotifyBackInStock(ctx context.Context, productID int64) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), backInStockTimeout)
+
+	go func() {
+		defer cancel()
+
+		if err := u.notifyBackInStock(ctx, productID); err != nil {
+			slog.ErrorContext(ctx, "failed to notify back in stock", "product_id", productID, "cause", err)
+		}
+	}()
+}
+
+func (u *NotificationUseCases) notifyBackInStock(ctx context.Context, productID int64) error {
+	product, err := u.productRepository.GetProduct(productID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to get product"), err)
+	}
+
+	// The product may have sold out again or been deleted in the meantime
+	if product == nil || product.Stock == 0 {
+		return nil
+	}
+
+	for i, filename := range product.Photos {
+		product.Photos[i] = util2.GetProductImageURL(filename, u.baseURL)
+	}
+
+	userIDs, err := u.wishlistRepository.GetWishlistUserIDs(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/products/%d", u.baseURL, product.ID)
+
+	var errs []error
+	for _, userID := range userIDs {
+		user, err := u.userRepository.FindById(userID)
+		if err != nil {
+			errs = append(errs, errors.Join(fmt.Errorf("failed to get user"), err))
+			continue
+		}
+
+		if user == nil || user.IsDisabled() {
+			continue
+		}
+
+		notification, email, err := u.templates.NewBackInStockNotification(*user, *product, link)
+		if err != nil {
+			errs = append(errs, errors.Join(fmt.Errorf("failed to render back in stock notification"), err))
+			continue
+		}
+
+		if err = u.notify(ctx, notification, email); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// NotifyReviewApproved notifies the author that the review was published
+func (u *NotificationUseCases) NotifyReviewApproved(ctx context.Context, review entities.Review) error {
+	user, err := u.userRepository.FindById(review.UserID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to get user"), err)
+	}
+
+	if user == nil || user.IsDisabled() {
+		return nil
+	}
+
+	product, err := u.productRepository.GetProduct(review.ProductID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to get product"), err)
+	}
+
+	if product == nil {
+		return nil
+	}
+
+	link := fmt.Sprintf("%s/products/%d", u.baseURL, product.ID)
+	notification, email, err := u.templates.NewReviewApprovedNotification(*user, review, *product, link)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to render review approved notification"), err)
+	}
+
+	return u.notify(ctx, notification, email)
+}
+
+// DeleteReadNotifications purges the notifications read longer ago than the retention
+func (u *NotificationUseCases) DeleteReadNotifications(ctx context.Context) error {
+	return u.notificationRepository.DeleteReadNotifications(ctx, time.Now().Add(-readNotificationRetention))
+}
+
+// notify adds the notification and queues its email, if the user wants the notifications of
+// its type by email
+func (u *NotificationUseCases) notify(ctx context.Context, notification entities.Notification, email entities.Email) error {
+	preferences, err := u.GetPreferences(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	var queued *entities.Email
+	for _, preference := range preferences {
+		if preference.Type == notification.Type && preference.Email {
+			queued = &email
+		}
+	}
+
+	if _, err = u.notificationRepository.AddNotification(ctx, &notification, queued); err != nil {
+		return errors.Join(fmt.Errorf("failed to add notification"), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package usecases
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/rules"
+	"cachacariaapi/domain/status_codes"
+	repositories "cachacariaapi/infrastructure/datastore"
+	"cachacariaapi/infrastructure/util"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OIDCUseCases struct {
+	providers       map[string]*util.OIDCProvider
+	repository      repositories.OIDCRepository
+	authRepository  repositories.AuthRepository
+	authUseCases    AuthUseCases
+	consentUseCases ConsentUseCases
+	authManager     util.AuthManager
+}
+
+func NewOIDCUseCases(
+	providers map[string]*util.OIDCProvider,
+	repository repositories.OIDCRepository,
+	authRepository repositories.AuthRepository,
+	authUseCases AuthUseCases,
+	consentUseCases ConsentUseCases,
+	authManager util.AuthManager,
+) OIDCUseCases {
+	return OIDCUseCases{
+		providers:       providers,
+		repository:      repository,
+		authRepository:  authRepository,
+		authUseCases:    authUseCases,
+		consentUseCases: consentUseCases,
+		authManager:     authManager,
+	}
+}
+
+// StartLogin returns the URL of the provider's login page. The state, nonce and PKCE code
+// verifier of the login are kept until the provider redirects the user back to the callback.
+func (u *OIDCUseCases) StartLogin(ctx context.Context, providerName string) (string, status_codes.OIDCLoginStatus, error) {
+	provider, ok := u.providers[providerName]
+	if !ok {
+		return "", status_codes.OIDCLoginStatusUnknownProvider, nil
+	}
+
+	state, stateHash, err := u.authManager.CreateOpaqueToken()
+	if err != nil {
+		return "", status_codes.OIDCLoginStatusError, errors.Join(fmt.Errorf("failed to generate oidc state"), err)
+	}
+
+	nonce, _, err := u.authManager.CreateOpaqueToken()
+	if err != nil {
+		return "", status_codes.OIDCLoginStatusError, errors.Join(fmt.Errorf("failed to generate oidc nonce"), err)
+	}
+
+	codeVerifier := util.NewPKCEVerifier()
+
+	url, err := provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to reach oidc provider", "provider", providerName, "cause", err)
+		return "", status_codes.OIDCLoginStatusProviderUnavailable, nil
+	}
+
+	err = u.repository.AddState(ctx, &entities.OIDCState{
+		StateHash:    stateHash,
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(util.OIDCStateDuration),
+	})
+	if err != nil {
+		return "", status_codes.OIDCLoginStatusError, errors.Join(fmt.Errorf("failed to add oidc state"), err)
+	}
+
+	return url, status_codes.OIDCLoginStatusSuccess, nil
+}
+
+// CompleteLogin exchanges the authorization code sent to the callback and starts a session
+// for the user of the ID token. Unknown identities are linked to the user with the same email,
+// as long as the provider verified it, or to a new verified customer. Users who have not
+// accepted the current terms and privacy policy, such as new ones, get their tokens with
+// OIDCLoginStatusAcceptanceRequired, so the client sends them to accept the documents.
+func (u *OIDCUseCases) CompleteLogin(
+	ctx context.Context,
+	providerName, code, state string,
+	client entities.SessionClient,
+) (*entities.AuthTokens, status_codes.OIDCLoginStatus, error) {
+	provider, ok := u.providers[providerName]
+	if !ok {
+		return nil, status_codes.OIDCLoginStatusUnknownProvider, nil
+	}
+
+	if code == "" || state == "" {
+		return nil, status_codes.OIDCLoginStatusInvalidState, nil
+	}
+
+	storedState, err := u.repository.ConsumeState(ctx, u.authManager.HashOpaqueToken(state))
+	if err != nil {
+		return nil, status_codes.OIDCLoginStatusError, errors.Join(fmt.Errorf("failed to consume oidc state"), err)
+	}
+
+	if storedState == nil || storedState.Provider != providerName || time.Now().After(storedState.ExpiresAt) {
+		return nil, status_codes.OIDCLoginStatusInvalidState, nil
+	}
+
+	claims, err := provider.Exchange(ctx, code, storedState.CodeVerifier)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to complete oidc login", "provider", providerName, "cause", err)
+		return nil, status_codes.OIDCLoginStatusInvalidToken, nil
+	}
+
+	if claims.Subject == "" || claims.Nonce != storedState.Nonce {
+		slog.WarnContext(ctx, "oidc id token with invalid nonce", "provider", providerName)
+		return nil, status_codes.OIDCLoginStatusInvalidToken, nil
+	}
+
+	user, status, err := u.findOrCreateUser(ctx, providerName, claims)
+	if err != nil || status != status_codes.OIDCLoginStatusSuccess {
+		return nil, status, err
+	}
+
+	if user.IsDisabled() {
+		return nil, status_codes.OIDCLoginStatusAccountDisabled, nil
+	}
+
+	// The provider cannot check our second factor, so these users keep logging in with a password
+	twoFactor, err := u.authRepository.GetTwoFactor(ctx, int64(user.ID))
+	if err != nil {
+		return nil, status_codes.OIDCLoginStatusError, errors.Join(fmt.Errorf("failed to get two factor"), err)
+	}
+
+	if twoFactor != nil && twoFactor.IsEnabled() {
+		return nil, status_codes.OIDCLoginStatusTwoFactorRequired, nil
+	}
+
+	tokens, err := u.authUseCases.StartSession(ctx, user, client)
+	if err != nil {
+		return nil, status_codes.OIDCLoginStatusError, err
+	}
+
+	consents, err := u.consentUseCases.GetPreferences(ctx, int64(user.ID))
+	if err != nil {
+		return nil, status_codes.OIDCLoginStatusError, err
+	}
+
+	if consents.RequiresAcceptance {
+		return tokens, status_codes.OIDCLoginStatusAcceptanceRequired, nil
+	}
+
+	return tokens, status_codes.OIDCLoginStatusSuccess, nil
+}
+
+// DeleteExpiredStates removes the logins abandoned at the provider's login page
+func (u *OIDCUseCases) DeleteExpiredStates(ctx context.Context) error {
+	if err := u.repository.DeleteExpiredStates(ctx, time.Now()); err != nil {
+		return errors.Join(fmt.Errorf("failed to delete expired oidc states"), err)
+	}
+
+	return nil
+}
+
+func (u *OIDCUseCases) findOrCreateUser(
+	ctx context.Context,
+	providerName string,
+	claims *entities.OIDCClaims,
+) (*entities.User, status_codes.OIDCLoginStatus, error) {
+	identity, err := u.repository.GetIdentity(ctx, providerName, claims.Subject)
+	if err != nil {
+		return nil, status_codes.OIDCLoginStatusError, errors.Join(fmt.Errorf("failed to get user identity"), err)
+	}
+
+	if identity != nil {
+		user, err := u.authRepository.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, status_codes.OIDCLoginStatusError, errors.Join(fmt.Errorf("failed to get user by id"), err)
+		}
+
+		if user != nil {
+			return user, status_codes.OIDCLoginStatusSuccess, nil
+		}
+	}
+
+	// Linking by an unverified email would hand the account to whoever typed it at the provider
+	email := util.TrimSpace(claims.Email)
+	if email == "" || !claims.EmailVerified {
+		return nil, status_codes.OIDCLoginStatusEmailNotVerified, nil
+	}
+
+	user, err := u.authRepository.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, status_codes.OIDCLoginStatusError, errors.Join(fmt.Errorf("failed to get user by email"), err)
+	}
+
+	if user == nil {
+		user, err = u.createUser(ctx, email, util.TrimSpace(claims.Name))
+		if err != nil {
+			return nil, status_codes.OIDCLoginStatusError, err
+		}
+	} else if user.Status == entities.UserStatusUnverified {
+		// Whoever registered the address first may not own it, and linking would let them keep
+		// using the account with the password they chose
+		return nil, status_codes.OIDCLoginStatusAccountNotVerified, nil
+	}
+
+	_, err = u.repository.AddIdentity(ctx, &entities.UserIdentity{
+		UserID:   int64(user.ID),
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    email,
+	})
+	if err != nil {
+		return nil, status_codes.OIDCLoginStatusError, errors.Join(fmt.Errorf("failed to add user identity"), err)
+	}
+
+	return user, status_codes.OIDCLoginStatusSuccess, nil
+}
+
+// createUser adds a customer whose email was verified by the provider. Its password is random,
+// so the user can only log in with the provider until they reset it. No consent is recorded,
+// since the user accepted nothing yet: the login answers OIDCLoginStatusAcceptanceRequired and
+// checkout is refused until the documents are accepted through the preferences. The name given
+// by the provider is kept if valid.
+func (u *OIDCUseCases) createUser(ctx context.Context, email, name string) (*entities.User, error) {
+	password, _, err := u.authManager.CreateOpaqueToken()
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to generate password"), err)
+	}
+
+	hash, err := u.authManager.HashPassword(password)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to hash password"), err)
+	}
+
+	if !rules.IsValidName(name) {
+		name = ""
+	}
+
+	userUUID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to generate uuid"), err)
+	}
+
+	user := &entities.User{
+		UUID:     userUUID,
+		Email:    email,
+		Password: hash,
+		Name:     name,
+		Role:     entities.RoleCustomer,
+		Status:   entities.UserStatusVerified,
+	}
+
+	id, err := u.authRepository.AddUser(ctx, user, nil)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to add user"), err)
+	}
+
+	user.ID = int(id)
+	return user, nil
+}
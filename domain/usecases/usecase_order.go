@@ -1 +1,166 @@
 package usecases
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/rules"
+	"cachacariaapi/domain/status_codes"
+	"cachacariaapi/domain/util"
+	repositories "cachacariaapi/infrastructure/datastore"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// OrderUseCases moves orders through their statuses and emails the customer at each step
+type OrderUseCases struct {
+	orderRepository      repositories.OrderRepository
+	userRepository       repositories.UserRepository
+	productRepository    repositories.ProductRepository
+	notificationUseCases NotificationUseCases
+	templates            *util.EmailTemplates
+	baseURL              string
+}
+
+func NewOrderUseCases(
+	orderRepository repositories.OrderRepository,
+	userRepository repositories.UserRepository,
+	productRepository repositories.ProductRepository,
+	notificationUseCases NotificationUseCases,
+	templates *util.EmailTemplates,
+	baseURL string,
+) OrderUseCases {
+	return OrderUseCases{
+		orderRepository:      orderRepository,
+		userRepository:       userRepository,
+		productRepository:    productRepository,
+		notificationUseCases: notificationUseCases,
+		templates:            templates,
+		baseURL:              baseURL,
+	}
+}
+
+// UpdateStatus moves the order to the requested status, following entities.OrderStatus.CanBecome
+func (u *OrderUseCases) UpdateStatus(
+	ctx context.Context,
+	orderID int64,
+	request entities.UpdateOrderStatusRequest,
+) (status_codes.ChangeOrderStatus, error) {
+	if !request.Status.IsValid() {
+		return status_codes.ChangeOrderStatusInvalidStatus, nil
+	}
+
+	order, err := u.orderRepository.GetOrder(ctx, orderID)
+	if err != nil {
+		return status_codes.ChangeOrderStatusError, errors.Join(fmt.Errorf("failed to get order"), err)
+	}
+
+	if order == nil {
+		return status_codes.ChangeOrderStatusNotFound, nil
+	}
+
+	if !order.Status.CanBecome(request.Status) {
+		return status_codes.ChangeOrderStatusInvalidTransition, nil
+	}
+
+	trackingCode := strings.ToUpper(strings.TrimSpace(request.TrackingCode))
+	if request.Status == entities.OrderStatusShipped && trackingCode == "" {
+		return status_codes.ChangeOrderStatusMissingTrackingCode, nil
+	}
+
+	if trackingCode != "" && !rules.IsValidTrackingCode(trackingCode) {
+		return status_codes.ChangeOrderStatusInvalidTrackingCode, nil
+	}
+
+	updated := *order
+	updated.Status = request.Status
+	if trackingCode != "" {
+		updated.TrackingCode = trackingCode
+	}
+
+	inApp, email := u.orderNotification(ctx, updated)
+	ok, restocked, err := u.orderRepository.UpdateOrderStatus(ctx, order, request.Status, trackingCode, email)
+	if err != nil {
+		return status_codes.ChangeOrderStatusError, errors.Join(fmt.Errorf("failed to update order status"), err)
+	}
+
+	if !ok {
+		return status_codes.ChangeOrderStatusInvalidTransition, nil
+	}
+
+	if inApp != nil {
+		if err = u.notificationUseCases.NotifyOrderStatus(ctx, *inApp); err != nil {
+			slog.ErrorContext(ctx, "failed to add order notification", "order_id", order.ID, "cause", err)
+		}
+	}
+
+	for _, productID := range restocked {
+		u.notificationUseCases.NotifyBackInStock(ctx, productID)
+	}
+
+	return status_codes.ChangeOrderStatusSuccess, nil
+}
+
+// placedOrderNotification renders the notification and the confirmation email of the order
+// the cart is turning into, for Checkout to queue the email along with the order
+func (u *OrderUseCases) placedOrderNotification(
+	ctx context.Context,
+	orderID, userID int64,
+	cart *entities.Cart,
+	address entities.ShippingAddress,
+) (*entities.Notification, *entities.Email) {
+	order := entities.Order{
+		ID:              orderID,
+		UserID:          userID,
+		Subtotal:        cart.Subtotal,
+		Discount:        cart.Discount,
+		TotalAmount:     cart.Total,
+		Status:          entities.OrderStatusPending,
+		ShippingAddress: &address,
+		CreatedAt:       time.Now(),
+		Items:           make([]entities.OrderItem, len(cart.Items)),
+	}
+
+	for i, item := range cart.Items {
+		order.Items[i] = entities.OrderItem{
+			OrderID:   orderID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.UnitPrice,
+		}
+	}
+
+	return u.orderNotification(ctx, order)
+}
+
+// orderNotification renders the notification and the email for the current status of the
+// order, or returns nil if they cannot be rendered or the customer erased their account, so
+// that the order is not held back
+func (u *OrderUseCases) orderNotification(ctx context.Context, order entities.Order) (*entities.Notification, *entities.Email) {
+	user, err := u.userRepository.FindById(order.UserID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get order user", "order_id", order.ID, "cause", err)
+		return nil, nil
+	}
+
+	if user == nil || user.IsErased() {
+		return nil, nil
+	}
+
+	orders := []entities.Order{order}
+	if err = fillOrderProducts(u.productRepository, orders, u.baseURL); err != nil {
+		slog.ErrorContext(ctx, "failed to get order products", "order_id", order.ID, "cause", err)
+		return nil, nil
+	}
+
+	link := fmt.Sprintf("%s/orders/%d", u.baseURL, order.ID)
+	inApp, email, err := u.templates.NewOrderNotification(*user, orders[0], link)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to render order notification", "order_id", order.ID, "cause", err)
+		return nil, nil
+	}
+
+	return &inApp, &email
+}
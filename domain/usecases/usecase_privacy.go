@@ -0,0 +1,116 @@
+package usecases
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/util"
+	repositories "cachacariaapi/infrastructure/datastore"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PrivacyUseCases answers the data subject requests of the LGPD. Erasure is handled by
+// UserUseCases.DeleteAccount.
+type PrivacyUseCases struct {
+	userRepository         repositories.UserRepository
+	tokenRepository        repositories.TokenRepository
+	oidcRepository         repositories.OIDCRepository
+	consentRepository      repositories.ConsentRepository
+	apiKeyRepository       repositories.APIKeyRepository
+	cartRepository         repositories.CartRepository
+	wishlistRepository     repositories.WishlistRepository
+	reviewRepository       repositories.ReviewRepository
+	notificationRepository repositories.NotificationRepository
+	cartUseCases           CartUseCases
+	baseURL                string
+}
+
+func NewPrivacyUseCases(
+	userRepository repositories.UserRepository,
+	tokenRepository repositories.TokenRepository,
+	oidcRepository repositories.OIDCRepository,
+	consentRepository repositories.ConsentRepository,
+	apiKeyRepository repositories.APIKeyRepository,
+	cartRepository repositories.CartRepository,
+	wishlistRepository repositories.WishlistRepository,
+	reviewRepository repositories.ReviewRepository,
+	notificationRepository repositories.NotificationRepository,
+	cartUseCases CartUseCases,
+	baseURL string,
+) PrivacyUseCases {
+	return PrivacyUseCases{
+		userRepository:         userRepository,
+		tokenRepository:        tokenRepository,
+		oidcRepository:         oidcRepository,
+		consentRepository:      consentRepository,
+		apiKeyRepository:       apiKeyRepository,
+		cartRepository:         cartRepository,
+		wishlistRepository:     wishlistRepository,
+		reviewRepository:       reviewRepository,
+		notificationRepository: notificationRepository,
+		cartUseCases:           cartUseCases,
+		baseURL:                baseURL,
+	}
+}
+
+// ExportUserData gathers the personal data kept about the user. It returns nil if the user
+// does not exist.
+func (u *PrivacyUseCases) ExportUserData(ctx context.Context, userID int64) (*entities.UserDataExport, error) {
+	user, err := u.userRepository.FindById(userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to find user by id"), err)
+	}
+
+	if user == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	export := &entities.UserDataExport{
+		GeneratedAt: now,
+		Profile:     user.Public(util.GetAvatarURL(user.Avatar, u.baseURL)),
+	}
+
+	if export.AgeConfirmations, err = u.userRepository.GetAgeConfirmations(ctx, userID); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get age confirmations"), err)
+	}
+
+	if export.Consents, err = u.consentRepository.GetConsentHistory(ctx, userID); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get consents"), err)
+	}
+
+	if export.LinkedAccounts, err = u.oidcRepository.GetIdentitiesByUser(ctx, userID); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get linked accounts"), err)
+	}
+
+	if export.Sessions, err = u.tokenRepository.GetActiveSessions(ctx, userID, now); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get sessions"), err)
+	}
+
+	if export.APIKeys, err = u.apiKeyRepository.GetAPIKeysByUser(ctx, userID); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get api keys"), err)
+	}
+
+	if export.Cart, err = u.cartRepository.GetCartItems(ctx, userID); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get cart items"), err)
+	}
+
+	if export.Wishlist, err = u.wishlistRepository.GetWishlistItems(ctx, userID); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get wishlist items"), err)
+	}
+
+	if export.Orders, err = u.cartUseCases.GetOrders(ctx, userID); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get orders"), err)
+	}
+
+	if export.Reviews, err = u.reviewRepository.GetReviewsByUser(ctx, userID); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get reviews"), err)
+	}
+
+	if export.Notifications, err = u.notificationRepository.GetNotificationsByUser(ctx, userID); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get notifications"), err)
+	}
+
+	return export, nil
+}
@@ -5,6 +5,7 @@ import (
 	"cachacariaapi/domain/status_codes"
 	"cachacariaapi/domain/util"
 	repositories "cachacariaapi/infrastructure/datastore"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -17,12 +18,17 @@ import (
 )
 
 type ProductUseCases struct {
-	productRepository repositories.ProductRepository
-	baseURL           string
+	productRepository    repositories.ProductRepository
+	notificationUseCases NotificationUseCases
+	baseURL              string
 }
 
-func NewProductUseCases(productRepository repositories.ProductRepository, baseURL string) ProductUseCases {
-	return ProductUseCases{productRepository, baseURL}
+func NewProductUseCases(
+	productRepository repositories.ProductRepository,
+	notificationUseCases NotificationUseCases,
+	baseURL string,
+) ProductUseCases {
+	return ProductUseCases{productRepository, notificationUseCases, baseURL}
 }
 
 func (u *ProductUseCases) AddProduct(
@@ -70,7 +76,7 @@ func (u *ProductUseCases) AddProduct(
 			time.Now().UnixNano(),
 			filepath.Ext(fileHeader.Filename),
 		)
-		filePath := filepath.Join("C:/Users/Gsdagustavo/Documents/imagesCacahcaria", filename)
+		filePath := filepath.Join(util.ImagesDir, filename)
 
 		dst, err := os.Create(filePath)
 		if err != nil {
@@ -151,7 +157,10 @@ func (u *ProductUseCases) DeleteProduct(id int64) (status_codes.DeleteProductSta
 	return status_codes.DeleteProductStatusSuccess, nil
 }
 
+// UpdateProduct updates the product and, when it is restocked, notifies the users who have it in
+// their wishlist
 func (u *ProductUseCases) UpdateProduct(
+	ctx context.Context,
 	id int64,
 	product entities.UpdateProductRequest,
 ) (status_codes.UpdateProductStatus, error) {
@@ -188,26 +197,155 @@ func (u *ProductUseCases) UpdateProduct(
 		return status_codes.UpdateProductStatusError, errors.Join(fmt.Errorf("failed to update product"), err)
 	}
 
+	if prod.Stock == 0 && product.Stock > 0 {
+		u.notificationUseCases.NotifyBackInStock(ctx, id)
+	}
+
 	return status_codes.UpdateProductStatusSuccess, nil
 }
 
 func validateImageType(header *multipart.FileHeader) error {
+	_, err := imageExtension(header)
+	return err
+}
+
+// imageExtension returns the file extension matching the content of the image, which must be
+// a JPEG or PNG
+func imageExtension(header *multipart.FileHeader) (string, error) {
 	src, err := header.Open()
 	if err != nil {
-		return errors.Join(fmt.Errorf("failed to open image file"), err)
+		return "", errors.Join(fmt.Errorf("failed to open image file"), err)
 	}
 
 	defer src.Close()
 
 	buf := make([]byte, 512)
 	if _, err := src.Read(buf); err != nil {
-		return errors.Join(fmt.Errorf("failed to read image bytes"), err)
+		return "", errors.Join(fmt.Errorf("failed to read image bytes"), err)
+	}
+
+	switch http.DetectContentType(buf) {
+	case "image/jpeg":
+		return ".jpg", nil
+	case "image/png":
+		return ".png", nil
+	default:
+		return "", entities.ErrBadRequest
+	}
+}
+
+// SchedulePrice schedules a new regular price for the product or, when an end is given, a
+// temporary sale price. Prices starting now are applied immediately.
+func (u *ProductUseCases) SchedulePrice(
+	ctx context.Context,
+	productID int64,
+	req entities.SchedulePriceRequest,
+) (status_codes.SchedulePriceStatus, error) {
+	product, err := u.productRepository.GetProduct(productID)
+	if err != nil {
+		return status_codes.SchedulePriceStatusError, errors.Join(fmt.Errorf("failed to get product"), err)
+	}
+
+	if product == nil {
+		return status_codes.SchedulePriceStatusProductNotFound, nil
+	}
+
+	if req.Price <= 0 {
+		return status_codes.SchedulePriceStatusInvalidPrice, nil
 	}
 
-	contentType := http.DetectContentType(buf)
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil && req.StartsAt.After(now) {
+		startsAt = *req.StartsAt
+	}
+
+	price := &entities.ProductPrice{
+		ProductID: productID,
+		Type:      entities.ProductPriceTypeRegular,
+		Price:     req.Price,
+		StartsAt:  startsAt,
+	}
+
+	if req.EndsAt != nil {
+		if !req.EndsAt.After(startsAt) {
+			return status_codes.SchedulePriceStatusInvalidPeriod, nil
+		}
+
+		price.Type = entities.ProductPriceTypeSale
+		price.EndsAt = req.EndsAt
+	}
+
+	if _, err = u.productRepository.AddProductPrice(ctx, price); err != nil {
+		return status_codes.SchedulePriceStatusError, errors.Join(fmt.Errorf("failed to schedule product price"), err)
+	}
+
+	if !startsAt.After(now) {
+		if err = u.ApplyScheduledPrices(ctx); err != nil {
+			return status_codes.SchedulePriceStatusError, err
+		}
+	}
+
+	return status_codes.SchedulePriceStatusSuccess, nil
+}
 
-	if contentType != "image/jpeg" && contentType != "image/png" {
-		return entities.ErrBadRequest
+// GetPriceHistory returns every applied, scheduled and cancelled price of the product, newest first
+func (u *ProductUseCases) GetPriceHistory(ctx context.Context, productID int64) ([]entities.ProductPrice, error) {
+	prices, err := u.productRepository.GetProductPrices(ctx, productID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get product prices"), err)
+	}
+
+	return prices, nil
+}
+
+// CancelScheduledPrice cancels a price of the product that has not been applied yet
+func (u *ProductUseCases) CancelScheduledPrice(ctx context.Context, productID, priceID int64) (status_codes.CancelPriceStatus, error) {
+	price, err := u.productRepository.GetProductPrice(ctx, priceID)
+	if err != nil {
+		return status_codes.CancelPriceStatusError, errors.Join(fmt.Errorf("failed to get product price"), err)
+	}
+
+	if price == nil || price.ProductID != productID || price.CancelledAt != nil {
+		return status_codes.CancelPriceStatusNotFound, nil
+	}
+
+	if price.AppliedAt != nil || price.EndedAt != nil {
+		return status_codes.CancelPriceStatusAlreadyApplied, nil
+	}
+
+	if err = u.productRepository.CancelProductPrice(ctx, priceID); err != nil {
+		return status_codes.CancelPriceStatusError, errors.Join(fmt.Errorf("failed to cancel product price"), err)
+	}
+
+	return status_codes.CancelPriceStatusSuccess, nil
+}
+
+// ApplyScheduledPrices ends the sales that are over and applies the prices that are due.
+// It is run periodically by the price scheduler job.
+func (u *ProductUseCases) ApplyScheduledPrices(ctx context.Context) error {
+	now := time.Now()
+
+	sales, err := u.productRepository.GetSalesToEnd(ctx, now)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to get sales to end"), err)
+	}
+
+	for _, sale := range sales {
+		if err = u.productRepository.EndProductSale(ctx, sale); err != nil {
+			return errors.Join(fmt.Errorf("failed to end sale %d", sale.ID), err)
+		}
+	}
+
+	prices, err := u.productRepository.GetPricesToStart(ctx, now)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to get prices to start"), err)
+	}
+
+	for _, price := range prices {
+		if err = u.productRepository.StartProductPrice(ctx, price); err != nil {
+			return errors.Join(fmt.Errorf("failed to start price %d", price.ID), err)
+		}
 	}
 
 	return nil
@@ -0,0 +1,323 @@
+package usecases
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/status_codes"
+	repositories "cachacariaapi/infrastructure/datastore"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+type PromotionUseCases struct {
+	promotionRepository repositories.PromotionRepository
+	productRepository   repositories.ProductRepository
+}
+
+func NewPromotionUseCases(
+	promotionRepository repositories.PromotionRepository,
+	productRepository repositories.ProductRepository,
+) PromotionUseCases {
+	return PromotionUseCases{
+		promotionRepository: promotionRepository,
+		productRepository:   productRepository,
+	}
+}
+
+func (u *PromotionUseCases) AddPromotion(ctx context.Context, req entities.AddPromotionRequest) (status_codes.AddPromotionStatus, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return status_codes.AddPromotionStatusInvalidName, nil
+	}
+
+	if !req.Type.IsValid() {
+		return status_codes.AddPromotionStatusInvalidType, nil
+	}
+
+	for i := range req.Products {
+		if req.Products[i].Quantity <= 0 {
+			req.Products[i].Quantity = 1
+		}
+	}
+
+	switch req.Type {
+	case entities.PromotionTypeBuyXGetY:
+		if req.CategoryID == nil && len(req.Products) == 0 {
+			return status_codes.AddPromotionStatusInvalidScope, nil
+		}
+
+		if req.BuyQuantity < 2 || req.PayQuantity < 1 || req.PayQuantity >= req.BuyQuantity {
+			return status_codes.AddPromotionStatusInvalidQuantities, nil
+		}
+	case entities.PromotionTypeQuantityTier:
+		if req.CategoryID == nil && len(req.Products) == 0 {
+			return status_codes.AddPromotionStatusInvalidScope, nil
+		}
+
+		if len(req.Tiers) == 0 {
+			return status_codes.AddPromotionStatusInvalidTiers, nil
+		}
+
+		minQuantities := make(map[int]bool, len(req.Tiers))
+		for _, tier := range req.Tiers {
+			if tier.MinQuantity < 2 || tier.DiscountPercent <= 0 || tier.DiscountPercent >= 100 || minQuantities[tier.MinQuantity] {
+				return status_codes.AddPromotionStatusInvalidTiers, nil
+			}
+
+			minQuantities[tier.MinQuantity] = true
+		}
+	case entities.PromotionTypeKit:
+		if len(req.Products) < 2 || req.KitPrice <= 0 {
+			return status_codes.AddPromotionStatusInvalidKit, nil
+		}
+	}
+
+	valid, err := u.validProducts(req.Products)
+	if err != nil {
+		return status_codes.AddPromotionStatusError, err
+	}
+
+	if !valid {
+		if req.Type == entities.PromotionTypeKit {
+			return status_codes.AddPromotionStatusInvalidKit, nil
+		}
+
+		return status_codes.AddPromotionStatusInvalidScope, nil
+	}
+
+	if req.StartsAt.IsZero() {
+		req.StartsAt = time.Now()
+	}
+
+	if req.EndsAt != nil && !req.EndsAt.After(req.StartsAt) {
+		return status_codes.AddPromotionStatusInvalidPeriod, nil
+	}
+
+	promotion := &entities.Promotion{
+		Name:        req.Name,
+		Type:        req.Type,
+		CategoryID:  req.CategoryID,
+		Products:    req.Products,
+		Tiers:       req.Tiers,
+		BuyQuantity: req.BuyQuantity,
+		PayQuantity: req.PayQuantity,
+		KitPrice:    req.KitPrice,
+		Priority:    req.Priority,
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+	}
+
+	if _, err := u.promotionRepository.AddPromotion(ctx, promotion); err != nil {
+		return status_codes.AddPromotionStatusError, errors.Join(fmt.Errorf("failed to add promotion"), err)
+	}
+
+	return status_codes.AddPromotionStatusSuccess, nil
+}
+
+// validProducts tells whether each product of the promotion exists and appears only once
+func (u *PromotionUseCases) validProducts(products []entities.PromotionProduct) (bool, error) {
+	seen := make(map[int64]bool, len(products))
+	for _, promotionProduct := range products {
+		if seen[promotionProduct.ProductID] {
+			return false, nil
+		}
+
+		seen[promotionProduct.ProductID] = true
+
+		product, err := u.productRepository.GetProduct(promotionProduct.ProductID)
+		if err != nil {
+			return false, errors.Join(fmt.Errorf("failed to get product"), err)
+		}
+
+		if product == nil {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (u *PromotionUseCases) GetPromotions(ctx context.Context) ([]entities.Promotion, error) {
+	promotions, err := u.promotionRepository.GetPromotions(ctx)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get promotions"), err)
+	}
+
+	return promotions, nil
+}
+
+func (u *PromotionUseCases) DisablePromotion(ctx context.Context, id int64) (status_codes.DeletePromotionStatus, error) {
+	promotion, err := u.promotionRepository.GetPromotionByID(ctx, id)
+	if err != nil {
+		return status_codes.DeletePromotionStatusError, errors.Join(fmt.Errorf("failed to get promotion"), err)
+	}
+
+	if promotion == nil {
+		return status_codes.DeletePromotionStatusNotFound, nil
+	}
+
+	if err = u.promotionRepository.DisablePromotion(ctx, id); err != nil {
+		return status_codes.DeletePromotionStatusError, errors.Join(fmt.Errorf("failed to disable promotion"), err)
+	}
+
+	return status_codes.DeletePromotionStatusSuccess, nil
+}
+
+// applyPromotions evaluates the promotions, in the given order, against the cart lines.
+//
+// Each cart line receives at most one promotion: once a promotion discounts a line, the line
+// is not considered by the following promotions.
+func applyPromotions(cart *entities.Cart, promotions []entities.Promotion) {
+	promoted := make(map[*entities.CartItem]bool)
+
+	for i := range promotions {
+		promotion := &promotions[i]
+
+		var candidates []*entities.CartItem
+		for _, item := range cart.Items {
+			if !promoted[item] && item.Product != nil && promotion.AppliesTo(item.Product) {
+				candidates = append(candidates, item)
+			}
+		}
+
+		if len(candidates) == 0 {
+			continue
+		}
+
+		var discounts map[*entities.CartItem]float64
+		switch promotion.Type {
+		case entities.PromotionTypeBuyXGetY:
+			discounts = buyXGetYDiscounts(promotion, candidates)
+		case entities.PromotionTypeQuantityTier:
+			discounts = quantityTierDiscounts(promotion, candidates)
+		case entities.PromotionTypeKit:
+			discounts = kitDiscounts(promotion, candidates)
+		}
+
+		var total float64
+		for _, item := range candidates {
+			discount := roundPrice(discounts[item])
+			if discount <= 0 {
+				continue
+			}
+
+			item.Discount = discount
+			item.Promotion = &entities.AppliedPromotion{
+				PromotionID: promotion.ID,
+				Name:        promotion.Name,
+				Type:        promotion.Type,
+				Description: promotion.Description(),
+				Discount:    discount,
+			}
+			promoted[item] = true
+			total += discount
+		}
+
+		if total > 0 {
+			cart.Promotions = append(cart.Promotions, entities.AppliedPromotion{
+				PromotionID: promotion.ID,
+				Name:        promotion.Name,
+				Type:        promotion.Type,
+				Description: promotion.Description(),
+				Discount:    roundPrice(total),
+			})
+		}
+	}
+
+	cart.PromotionDiscount = 0
+	for _, item := range cart.Items {
+		item.Total = roundPrice(item.Subtotal - item.Discount)
+		cart.PromotionDiscount += item.Discount
+	}
+
+	cart.PromotionDiscount = roundPrice(cart.PromotionDiscount)
+}
+
+// buyXGetYDiscounts pools every unit of the candidate lines, from the most to the least
+// expensive, and makes the cheapest units of each group of BuyQuantity units free.
+func buyXGetYDiscounts(promotion *entities.Promotion, items []*entities.CartItem) map[*entities.CartItem]float64 {
+	type unit struct {
+		item  *entities.CartItem
+		price float64
+	}
+
+	var units []unit
+	for _, item := range items {
+		for i := 0; i < item.Quantity; i++ {
+			units = append(units, unit{item: item, price: item.UnitPrice})
+		}
+	}
+
+	sort.SliceStable(units, func(i, j int) bool {
+		return units[i].price > units[j].price
+	})
+
+	discounts := make(map[*entities.CartItem]float64)
+	groups := len(units) / promotion.BuyQuantity
+	for g := 0; g < groups; g++ {
+		start := g * promotion.BuyQuantity
+		for i := start + promotion.PayQuantity; i < start+promotion.BuyQuantity; i++ {
+			discounts[units[i].item] += units[i].price
+		}
+	}
+
+	return discounts
+}
+
+// quantityTierDiscounts discounts each candidate line by the best tier reached by its quantity
+func quantityTierDiscounts(promotion *entities.Promotion, items []*entities.CartItem) map[*entities.CartItem]float64 {
+	discounts := make(map[*entities.CartItem]float64)
+	for _, item := range items {
+		var percent float64
+		for _, tier := range promotion.Tiers {
+			if item.Quantity >= tier.MinQuantity && tier.DiscountPercent > percent {
+				percent = tier.DiscountPercent
+			}
+		}
+
+		if percent > 0 {
+			discounts[item] = item.Subtotal * percent / 100
+		}
+	}
+
+	return discounts
+}
+
+// kitDiscounts computes how many complete kits the cart contains and spreads the savings
+// among the kit lines proportionally to their share of the kit regular price.
+func kitDiscounts(promotion *entities.Promotion, items []*entities.CartItem) map[*entities.CartItem]float64 {
+	byProduct := make(map[int64]*entities.CartItem)
+	for _, item := range items {
+		byProduct[item.ProductID] = item
+	}
+
+	kits := math.MaxInt
+	var regularPrice float64
+	for _, component := range promotion.Products {
+		item, ok := byProduct[component.ProductID]
+		if !ok {
+			return nil
+		}
+
+		kits = min(kits, item.Quantity/component.Quantity)
+		regularPrice += item.UnitPrice * float64(component.Quantity)
+	}
+
+	saving := regularPrice - promotion.KitPrice
+	if kits == 0 || saving <= 0 {
+		return nil
+	}
+
+	discounts := make(map[*entities.CartItem]float64)
+	for _, component := range promotion.Products {
+		item := byProduct[component.ProductID]
+		share := item.UnitPrice * float64(component.Quantity) / regularPrice
+		discounts[item] = float64(kits) * saving * share
+	}
+
+	return discounts
+}
@@ -0,0 +1,231 @@
+package usecases
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/status_codes"
+	repositories "cachacariaapi/infrastructure/datastore"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxReviewCommentLength = 2000
+	defaultReviewsPageSize = 10
+	maxReviewsPageSize     = 50
+)
+
+type ReviewUseCases struct {
+	reviewRepository     repositories.ReviewRepository
+	productRepository    repositories.ProductRepository
+	orderRepository      repositories.OrderRepository
+	notificationUseCases NotificationUseCases
+}
+
+func NewReviewUseCases(
+	reviewRepository repositories.ReviewRepository,
+	productRepository repositories.ProductRepository,
+	orderRepository repositories.OrderRepository,
+	notificationUseCases NotificationUseCases,
+) ReviewUseCases {
+	return ReviewUseCases{
+		reviewRepository:     reviewRepository,
+		productRepository:    productRepository,
+		orderRepository:      orderRepository,
+		notificationUseCases: notificationUseCases,
+	}
+}
+
+// AddReview adds a pending review of the product, as long as the user bought it and did not review it yet
+func (u *ReviewUseCases) AddReview(
+	ctx context.Context,
+	userID, productID int64,
+	req entities.ReviewRequest,
+) (status_codes.AddReviewStatus, error) {
+	req.Comment = strings.TrimSpace(req.Comment)
+
+	if req.Rating < 1 || req.Rating > 5 {
+		return status_codes.AddReviewStatusInvalidRating, nil
+	}
+
+	if utf8.RuneCountInString(req.Comment) > maxReviewCommentLength {
+		return status_codes.AddReviewStatusInvalidComment, nil
+	}
+
+	product, err := u.productRepository.GetProduct(productID)
+	if err != nil {
+		return status_codes.AddReviewStatusError, errors.Join(fmt.Errorf("failed to get product"), err)
+	}
+
+	if product == nil {
+		return status_codes.AddReviewStatusProductNotFound, nil
+	}
+
+	purchased, err := u.orderRepository.HasPurchasedProduct(ctx, userID, productID)
+	if err != nil {
+		return status_codes.AddReviewStatusError, errors.Join(fmt.Errorf("failed to check product purchase"), err)
+	}
+
+	if !purchased {
+		return status_codes.AddReviewStatusNotPurchased, nil
+	}
+
+	existing, err := u.reviewRepository.GetUserReview(ctx, userID, productID)
+	if err != nil {
+		return status_codes.AddReviewStatusError, errors.Join(fmt.Errorf("failed to check user review"), err)
+	}
+
+	if existing != nil {
+		return status_codes.AddReviewStatusAlreadyReviewed, nil
+	}
+
+	review := &entities.Review{
+		ProductID: productID,
+		UserID:    userID,
+		Rating:    req.Rating,
+		Comment:   req.Comment,
+		Status:    entities.ReviewStatusPending,
+	}
+
+	if _, err = u.reviewRepository.AddReview(ctx, review); err != nil {
+		return status_codes.AddReviewStatusError, errors.Join(fmt.Errorf("failed to add review"), err)
+	}
+
+	return status_codes.AddReviewStatusSuccess, nil
+}
+
+// UpdateReview edits the user's review of the product, sending it back to moderation
+func (u *ReviewUseCases) UpdateReview(
+	ctx context.Context,
+	userID, productID int64,
+	req entities.ReviewRequest,
+) (status_codes.UpdateReviewStatus, error) {
+	req.Comment = strings.TrimSpace(req.Comment)
+
+	if req.Rating < 1 || req.Rating > 5 {
+		return status_codes.UpdateReviewStatusInvalidRating, nil
+	}
+
+	if utf8.RuneCountInString(req.Comment) > maxReviewCommentLength {
+		return status_codes.UpdateReviewStatusInvalidComment, nil
+	}
+
+	review, err := u.reviewRepository.GetUserReview(ctx, userID, productID)
+	if err != nil {
+		return status_codes.UpdateReviewStatusError, errors.Join(fmt.Errorf("failed to get user review"), err)
+	}
+
+	if review == nil {
+		return status_codes.UpdateReviewStatusNotFound, nil
+	}
+
+	review.Rating = req.Rating
+	review.Comment = req.Comment
+	review.Status = entities.ReviewStatusPending
+
+	if err = u.reviewRepository.UpdateReview(ctx, review); err != nil {
+		return status_codes.UpdateReviewStatusError, errors.Join(fmt.Errorf("failed to update review"), err)
+	}
+
+	return status_codes.UpdateReviewStatusSuccess, nil
+}
+
+// DeleteReview deletes the user's review of the product
+func (u *ReviewUseCases) DeleteReview(ctx context.Context, userID, productID int64) (status_codes.DeleteReviewStatus, error) {
+	review, err := u.reviewRepository.GetUserReview(ctx, userID, productID)
+	if err != nil {
+		return status_codes.DeleteReviewStatusError, errors.Join(fmt.Errorf("failed to get user review"), err)
+	}
+
+	if review == nil {
+		return status_codes.DeleteReviewStatusNotFound, nil
+	}
+
+	if err = u.reviewRepository.DeleteReview(ctx, review.ID); err != nil {
+		return status_codes.DeleteReviewStatusError, errors.Join(fmt.Errorf("failed to delete review"), err)
+	}
+
+	return status_codes.DeleteReviewStatusSuccess, nil
+}
+
+// GetProductReviews returns a page of the approved reviews of the product, newest first
+func (u *ReviewUseCases) GetProductReviews(ctx context.Context, productID int64, page, limit int) (*entities.PaginatedReviews, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 {
+		limit = defaultReviewsPageSize
+	}
+
+	limit = min(limit, maxReviewsPageSize)
+
+	reviews, total, err := u.reviewRepository.GetProductReviews(
+		ctx,
+		productID,
+		entities.ReviewStatusApproved,
+		limit,
+		(page-1)*limit,
+	)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get product reviews"), err)
+	}
+
+	return &entities.PaginatedReviews{
+		Page:    page,
+		Limit:   limit,
+		Total:   total,
+		Reviews: reviews,
+	}, nil
+}
+
+// GetReviewsByStatus returns every review with the given status, defaulting to the moderation queue
+func (u *ReviewUseCases) GetReviewsByStatus(ctx context.Context, status entities.ReviewStatus) ([]entities.Review, error) {
+	if !status.IsValid() {
+		status = entities.ReviewStatusPending
+	}
+
+	reviews, err := u.reviewRepository.GetReviewsByStatus(ctx, status)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get reviews"), err)
+	}
+
+	return reviews, nil
+}
+
+// ModerateReview approves or hides a review. The author is notified the first time the review is
+// approved.
+func (u *ReviewUseCases) ModerateReview(
+	ctx context.Context,
+	reviewID int64,
+	status entities.ReviewStatus,
+) (status_codes.ModerateReviewStatus, error) {
+	if status != entities.ReviewStatusApproved && status != entities.ReviewStatusHidden {
+		return status_codes.ModerateReviewStatusInvalidStatus, nil
+	}
+
+	review, err := u.reviewRepository.GetReview(ctx, reviewID)
+	if err != nil {
+		return status_codes.ModerateReviewStatusError, errors.Join(fmt.Errorf("failed to get review"), err)
+	}
+
+	if review == nil {
+		return status_codes.ModerateReviewStatusNotFound, nil
+	}
+
+	if err = u.reviewRepository.UpdateReviewStatus(ctx, reviewID, status); err != nil {
+		return status_codes.ModerateReviewStatusError, errors.Join(fmt.Errorf("failed to update review status"), err)
+	}
+
+	if status == entities.ReviewStatusApproved && review.Status == entities.ReviewStatusPending {
+		review.Status = status
+		if err = u.notificationUseCases.NotifyReviewApproved(ctx, *review); err != nil {
+			slog.ErrorContext(ctx, "failed to notify review approval", "review_id", reviewID, "cause", err)
+		}
+	}
+
+	return status_codes.ModerateReviewStatusSuccess, nil
+}
@@ -0,0 +1,77 @@
+package usecases
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/status_codes"
+	repositories "cachacariaapi/infrastructure/datastore"
+	"context"
+	"errors"
+	"fmt"
+)
+
+type RoleUseCases struct {
+	userRepository  repositories.UserRepository
+	tokenRepository repositories.TokenRepository
+}
+
+func NewRoleUseCases(userRepository repositories.UserRepository, tokenRepository repositories.TokenRepository) RoleUseCases {
+	return RoleUseCases{
+		userRepository:  userRepository,
+		tokenRepository: tokenRepository,
+	}
+}
+
+// GetRoles returns every role along with the permissions it grants
+func (u *RoleUseCases) GetRoles() []entities.RoleInfo {
+	roles := make([]entities.RoleInfo, len(entities.Roles))
+	for i, role := range entities.Roles {
+		roles[i] = entities.RoleInfo{
+			Role:        role,
+			Permissions: role.Permissions(),
+		}
+	}
+
+	return roles
+}
+
+// AssignRole changes the role of the user. The user's sessions are revoked, so the new
+// permissions apply on the next login. The last super admin cannot be demoted.
+func (u *RoleUseCases) AssignRole(ctx context.Context, userID int64, role entities.Role) (status_codes.AssignRoleStatus, error) {
+	if !role.IsValid() {
+		return status_codes.AssignRoleStatusInvalidRole, nil
+	}
+
+	user, err := u.userRepository.FindById(userID)
+	if err != nil {
+		return status_codes.AssignRoleStatusError, errors.Join(fmt.Errorf("failed to find user by id"), err)
+	}
+
+	if user == nil {
+		return status_codes.AssignRoleStatusUserNotFound, nil
+	}
+
+	if user.Role == role {
+		return status_codes.AssignRoleStatusSuccess, nil
+	}
+
+	if user.Role == entities.RoleSuperAdmin {
+		count, err := u.userRepository.CountByRole(ctx, entities.RoleSuperAdmin)
+		if err != nil {
+			return status_codes.AssignRoleStatusError, errors.Join(fmt.Errorf("failed to count super admins"), err)
+		}
+
+		if count <= 1 {
+			return status_codes.AssignRoleStatusLastSuperAdmin, nil
+		}
+	}
+
+	if err = u.userRepository.UpdateRole(ctx, userID, role); err != nil {
+		return status_codes.AssignRoleStatusError, errors.Join(fmt.Errorf("failed to update user role"), err)
+	}
+
+	if err = u.tokenRepository.RevokeUserTokens(ctx, userID); err != nil {
+		return status_codes.AssignRoleStatusError, errors.Join(fmt.Errorf("failed to revoke user tokens"), err)
+	}
+
+	return status_codes.AssignRoleStatusSuccess, nil
+}
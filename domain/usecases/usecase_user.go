@@ -4,43 +4,251 @@ import (
 	"cachacariaapi/domain/entities"
 	"cachacariaapi/domain/rules"
 	"cachacariaapi/domain/status_codes"
+	util2 "cachacariaapi/domain/util"
 	repositories "cachacariaapi/infrastructure/datastore"
 	"cachacariaapi/infrastructure/util"
+	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultUsersPageSize = 20
+	maxUsersPageSize     = 100
+	// erasedEmailDomain replaces the email domain of erased users; .invalid never resolves
+	erasedEmailDomain = "apagado.invalid"
+	maxAvatarSize     = 2 << 20
 )
 
 type UserUseCases struct {
-	userRepository repositories.UserRepository
-	authRepository repositories.AuthRepository
-	authManager    util.AuthManager
+	userRepository  repositories.UserRepository
+	authRepository  repositories.AuthRepository
+	orderRepository repositories.OrderRepository
+	tokenRepository repositories.TokenRepository
+	authUseCases    AuthUseCases
+	authManager     util.AuthManager
+	baseURL         string
 }
 
-func NewUserUseCases(userRepository repositories.UserRepository, authRepository repositories.AuthRepository, authManager util.AuthManager) UserUseCases {
+func NewUserUseCases(
+	userRepository repositories.UserRepository,
+	authRepository repositories.AuthRepository,
+	orderRepository repositories.OrderRepository,
+	tokenRepository repositories.TokenRepository,
+	authUseCases AuthUseCases,
+	authManager util.AuthManager,
+	baseURL string,
+) UserUseCases {
 	return UserUseCases{
-		authRepository: authRepository,
-		userRepository: userRepository,
-		authManager:    authManager,
+		authRepository:  authRepository,
+		userRepository:  userRepository,
+		orderRepository: orderRepository,
+		tokenRepository: tokenRepository,
+		authUseCases:    authUseCases,
+		authManager:     authManager,
+		baseURL:         baseURL,
 	}
 }
 
-// GetAll users, or an error if any occurs
-func (u *UserUseCases) GetAll() ([]entities.User, error) {
-	return u.userRepository.GetAll()
+// PublicUser returns the user as shown by the API, with the URL of their avatar
+func (u *UserUseCases) PublicUser(user entities.User) entities.PublicUser {
+	return user.Public(util2.GetAvatarURL(user.Avatar, u.baseURL))
+}
+
+// SearchUsers returns a page of the users matching the filter
+func (u *UserUseCases) SearchUsers(ctx context.Context, filter entities.UserFilter, page int) (*entities.PaginatedUsers, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	if filter.Limit < 1 {
+		filter.Limit = defaultUsersPageSize
+	}
+
+	filter.Limit = min(filter.Limit, maxUsersPageSize)
+	filter.Offset = (page - 1) * filter.Limit
+	filter.Search = util.TrimSpace(filter.Search)
+
+	users, total, err := u.userRepository.Search(ctx, filter)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to search users"), err)
+	}
+
+	result := &entities.PaginatedUsers{
+		Page:  page,
+		Limit: filter.Limit,
+		Total: total,
+		Users: make([]entities.PublicUser, len(users)),
+	}
+
+	for i, user := range users {
+		result.Users[i] = u.PublicUser(user)
+	}
+
+	return result, nil
 }
 
-// Delete a user with the given userId. Return an error if any occurs
-func (u *UserUseCases) Delete(userId int64) error {
-	_, err := u.FindById(userId)
+// DisableUser disables the account, logging the user out of every device. Staff cannot disable
+// users whose role has permissions their own lacks.
+func (u *UserUseCases) DisableUser(
+	ctx context.Context,
+	actorID int64,
+	actorRole entities.Role,
+	userID int64,
+) (status_codes.DisableUserStatus, error) {
+	if actorID == userID {
+		return status_codes.DisableUserStatusSelf, nil
+	}
+
+	user, err := u.userRepository.FindById(userID)
 	if err != nil {
-		return errors.Join(fmt.Errorf("failed to get user by id"), err)
+		return status_codes.DisableUserStatusError, errors.Join(fmt.Errorf("failed to find user by id"), err)
+	}
+
+	if user == nil {
+		return status_codes.DisableUserStatusNotFound, nil
+	}
+
+	if !actorRole.Covers(user.Role) {
+		return status_codes.DisableUserStatusForbidden, nil
+	}
+
+	disabled, err := u.userRepository.Disable(ctx, userID)
+	if err != nil {
+		return status_codes.DisableUserStatusError, errors.Join(fmt.Errorf("failed to disable user"), err)
+	}
+
+	if !disabled {
+		return status_codes.DisableUserStatusAlreadyDisabled, nil
+	}
+
+	if err = u.tokenRepository.RevokeUserTokens(ctx, userID); err != nil {
+		return status_codes.DisableUserStatusError, errors.Join(fmt.Errorf("failed to revoke user tokens"), err)
+	}
+
+	return status_codes.DisableUserStatusSuccess, nil
+}
+
+// EnableUser reactivates a disabled account, unless its role has permissions the actor's lacks
+func (u *UserUseCases) EnableUser(ctx context.Context, actorRole entities.Role, userID int64) (status_codes.EnableUserStatus, error) {
+	user, err := u.userRepository.FindById(userID)
+	if err != nil {
+		return status_codes.EnableUserStatusError, errors.Join(fmt.Errorf("failed to find user by id"), err)
+	}
+
+	if user == nil {
+		return status_codes.EnableUserStatusNotFound, nil
+	}
+
+	if !actorRole.Covers(user.Role) {
+		return status_codes.EnableUserStatusForbidden, nil
+	}
+
+	enabled, err := u.userRepository.Enable(ctx, userID)
+	if err != nil {
+		return status_codes.EnableUserStatusError, errors.Join(fmt.Errorf("failed to enable user"), err)
+	}
+
+	if !enabled {
+		return status_codes.EnableUserStatusNotDisabled, nil
+	}
+
+	return status_codes.EnableUserStatusSuccess, nil
+}
+
+// DeleteUser erases another user's account, as described in erase
+func (u *UserUseCases) DeleteUser(ctx context.Context, actorID, userID int64) (status_codes.DeleteUserStatus, error) {
+	if actorID == userID {
+		return status_codes.DeleteUserStatusSelf, nil
 	}
 
-	err = u.userRepository.Delete(userId)
+	user, err := u.userRepository.FindById(userID)
 	if err != nil {
-		return errors.Join(fmt.Errorf("failed to delete user"), err)
+		return status_codes.DeleteUserStatusError, errors.Join(fmt.Errorf("failed to find user by id"), err)
 	}
 
+	if user == nil || user.IsErased() {
+		return status_codes.DeleteUserStatusNotFound, nil
+	}
+
+	if err = u.erase(ctx, user); err != nil {
+		return status_codes.DeleteUserStatusError, err
+	}
+
+	return status_codes.DeleteUserStatusSuccess, nil
+}
+
+// DeleteAccount erases the user's own account, after confirming their password, as required
+// by the LGPD. The last super admin cannot leave the store without one.
+func (u *UserUseCases) DeleteAccount(
+	ctx context.Context,
+	userID int64,
+	req entities.DeleteAccountRequest,
+) (status_codes.DeleteAccountStatus, error) {
+	user, err := u.userRepository.FindById(userID)
+	if err != nil {
+		return status_codes.DeleteAccountStatusError, errors.Join(fmt.Errorf("failed to find user by id"), err)
+	}
+
+	if user == nil || !u.authManager.CheckPasswordHash(req.Password, user.Password) {
+		return status_codes.DeleteAccountStatusInvalidPassword, nil
+	}
+
+	if user.Role == entities.RoleSuperAdmin {
+		count, err := u.userRepository.CountByRole(ctx, entities.RoleSuperAdmin)
+		if err != nil {
+			return status_codes.DeleteAccountStatusError, errors.Join(fmt.Errorf("failed to count super admins"), err)
+		}
+
+		if count <= 1 {
+			return status_codes.DeleteAccountStatusLastSuperAdmin, nil
+		}
+	}
+
+	if err = u.erase(ctx, user); err != nil {
+		return status_codes.DeleteAccountStatusError, err
+	}
+
+	return status_codes.DeleteAccountStatusSuccess, nil
+}
+
+// erase revokes the user's tokens and deletes the user. Orders must be kept for fiscal
+// retention, so users with orders are anonymized instead: their personal data is erased and
+// the account can never be used again.
+func (u *UserUseCases) erase(ctx context.Context, user *entities.User) error {
+	userID := int64(user.ID)
+
+	orders, err := u.orderRepository.GetOrders(ctx, userID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to get user orders"), err)
+	}
+
+	if err = u.tokenRepository.RevokeUserTokens(ctx, userID); err != nil {
+		return errors.Join(fmt.Errorf("failed to revoke user tokens"), err)
+	}
+
+	if len(orders) == 0 {
+		if err = u.userRepository.Delete(ctx, userID); err != nil {
+			return errors.Join(fmt.Errorf("failed to delete user"), err)
+		}
+
+		removeAvatar(ctx, user.Avatar)
+		return nil
+	}
+
+	email := fmt.Sprintf("%s@%s", user.UUID, erasedEmailDomain)
+	if err = u.userRepository.Anonymize(ctx, userID, email); err != nil {
+		return errors.Join(fmt.Errorf("failed to anonymize user"), err)
+	}
+
+	removeAvatar(ctx, user.Avatar)
 	return nil
 }
 
@@ -64,7 +272,164 @@ func (u *UserUseCases) FindById(userid int64) (*entities.User, error) {
 	return user, nil
 }
 
-func (u *UserUseCases) Update(user entities.User) (status_codes.UpdateUserStatus, error) {
+// UpdateProfile changes the user's name and, until the user's age is verified, CPF and birth
+// date. Omitted CPF and birth date are left untouched, and empty ones are removed.
+func (u *UserUseCases) UpdateProfile(
+	ctx context.Context,
+	userID int64,
+	req entities.UpdateProfileRequest,
+) (status_codes.UpdateProfileStatus, error) {
+	user, err := u.userRepository.FindById(userID)
+	if err != nil {
+		return status_codes.UpdateProfileStatusError, errors.Join(fmt.Errorf("failed to find user by id"), err)
+	}
+
+	if user == nil {
+		return status_codes.UpdateProfileStatusError, fmt.Errorf("user %d not found", userID)
+	}
+
+	user.Name = util.TrimSpace(req.Name)
+	if !rules.IsValidName(user.Name) {
+		return status_codes.UpdateProfileStatusInvalidName, nil
+	}
+
+	if (req.CPF != nil || req.BirthDate != nil) && user.AgeVerifiedAt != nil {
+		return status_codes.UpdateProfileStatusAgeVerified, nil
+	}
+
+	if req.CPF != nil {
+		user.CPF = nil
+
+		if value := util.TrimSpace(*req.CPF); value != "" {
+			if !rules.IsValidCPF(value) {
+				return status_codes.UpdateProfileStatusInvalidCPF, nil
+			}
+
+			cpf := rules.NormalizeCPF(value)
+			existing, err := u.userRepository.FindByCPF(ctx, cpf)
+			if err != nil {
+				return status_codes.UpdateProfileStatusError, errors.Join(fmt.Errorf("failed to check cpf"), err)
+			}
+
+			if existing != nil && existing.ID != user.ID {
+				return status_codes.UpdateProfileStatusCPFAlreadyExists, nil
+			}
+
+			user.CPF = &cpf
+		}
+	}
+
+	if req.BirthDate != nil {
+		user.BirthDate = nil
+
+		if value := util.TrimSpace(*req.BirthDate); value != "" {
+			birthDate, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+			if err != nil || birthDate.After(time.Now()) {
+				return status_codes.UpdateProfileStatusInvalidBirthDate, nil
+			}
+
+			user.BirthDate = &birthDate
+		}
+	}
+
+	if req.Locale != nil {
+		if !entities.IsValidLocale(*req.Locale) {
+			return status_codes.UpdateProfileStatusInvalidLocale, nil
+		}
+
+		user.Locale = *req.Locale
+	}
+
+	if err = u.userRepository.UpdateProfile(ctx, *user); err != nil {
+		return status_codes.UpdateProfileStatusError, errors.Join(fmt.Errorf("failed to update profile"), err)
+	}
+
+	return status_codes.UpdateProfileStatusSuccess, nil
+}
+
+// UpdateAvatar stores the image as the user's avatar, along with the product images, and
+// removes the previous one
+func (u *UserUseCases) UpdateAvatar(
+	ctx context.Context,
+	userID int64,
+	header *multipart.FileHeader,
+) (status_codes.UpdateAvatarStatus, error) {
+	user, err := u.userRepository.FindById(userID)
+	if err != nil {
+		return status_codes.UpdateAvatarStatusError, errors.Join(fmt.Errorf("failed to find user by id"), err)
+	}
+
+	if user == nil {
+		return status_codes.UpdateAvatarStatusError, fmt.Errorf("user %d not found", userID)
+	}
+
+	if header.Size > maxAvatarSize {
+		return status_codes.UpdateAvatarStatusInvalidImage, nil
+	}
+
+	ext, err := imageExtension(header)
+	if err != nil {
+		if errors.Is(err, entities.ErrBadRequest) {
+			return status_codes.UpdateAvatarStatusInvalidImage, nil
+		}
+
+		return status_codes.UpdateAvatarStatusError, err
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return status_codes.UpdateAvatarStatusError, errors.Join(fmt.Errorf("failed to open image file"), err)
+	}
+	defer src.Close()
+
+	// A random name keeps the avatars of other users from being guessed
+	filename := fmt.Sprintf("avatar_%s%s", rand.Text(), ext)
+
+	dst, err := os.Create(filepath.Join(util2.ImagesDir, filename))
+	if err != nil {
+		return status_codes.UpdateAvatarStatusError, errors.Join(fmt.Errorf("failed to create file"), err)
+	}
+
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		removeAvatar(ctx, filename)
+		return status_codes.UpdateAvatarStatusError, errors.Join(fmt.Errorf("failed to copy file"), err)
+	}
+
+	if err = u.userRepository.UpdateAvatar(ctx, userID, filename); err != nil {
+		removeAvatar(ctx, filename)
+		return status_codes.UpdateAvatarStatusError, errors.Join(fmt.Errorf("failed to update avatar"), err)
+	}
+
+	removeAvatar(ctx, user.Avatar)
+	return status_codes.UpdateAvatarStatusSuccess, nil
+}
+
+// DeleteAvatar removes the user's avatar
+func (u *UserUseCases) DeleteAvatar(ctx context.Context, userID int64) (status_codes.UpdateAvatarStatus, error) {
+	user, err := u.userRepository.FindById(userID)
+	if err != nil {
+		return status_codes.UpdateAvatarStatusError, errors.Join(fmt.Errorf("failed to find user by id"), err)
+	}
+
+	if user == nil {
+		return status_codes.UpdateAvatarStatusError, fmt.Errorf("user %d not found", userID)
+	}
+
+	if err = u.userRepository.UpdateAvatar(ctx, userID, ""); err != nil {
+		return status_codes.UpdateAvatarStatusError, errors.Join(fmt.Errorf("failed to remove avatar"), err)
+	}
+
+	removeAvatar(ctx, user.Avatar)
+	return status_codes.UpdateAvatarStatusSuccess, nil
+}
+
+// Update changes the user's email and phone. A new email must be verified again, so a
+// verification link is sent to it.
+func (u *UserUseCases) Update(ctx context.Context, user entities.User) (status_codes.UpdateUserStatus, error) {
 	user.Email = util.TrimSpace(user.Email)
 	user.Phone = util.TrimSpace(user.Phone)
 
@@ -108,10 +473,111 @@ func (u *UserUseCases) Update(user entities.User) (status_codes.UpdateUserStatus
 		}
 	}
 
-	err = u.userRepository.Update(user)
+	emailChanged := user.Email != currentUser.Email
+
+	var (
+		verification *entities.EmailVerification
+		notification *entities.Email
+	)
+	if emailChanged && !currentUser.IsDisabled() {
+		updatedUser := *currentUser
+		updatedUser.Email = user.Email
+		updatedUser.Phone = user.Phone
+		updatedUser.Status = entities.UserStatusUnverified
+
+		verification, notification, err = u.authUseCases.newEmailVerification(&updatedUser)
+		if err != nil {
+			return status_codes.UpdateUserFailure, err
+		}
+	}
+
+	err = u.userRepository.Update(ctx, user, emailChanged, verification, notification)
 	if err != nil {
 		return status_codes.UpdateUserFailure, errors.Join(fmt.Errorf("failed to update user"), err)
 	}
 
 	return status_codes.UpdateUserSuccess, nil
 }
+
+// VerifyAge records the user's CPF and birth date, along with the declaration of legal age, so
+// the user can buy alcoholic beverages. Once verified, the CPF and birth date cannot change.
+func (u *UserUseCases) VerifyAge(
+	ctx context.Context,
+	userID int64,
+	req entities.AgeVerificationRequest,
+	ipAddress, userAgent string,
+) (status_codes.VerifyAgeStatus, error) {
+	user, err := u.userRepository.FindById(userID)
+	if err != nil {
+		return status_codes.VerifyAgeStatusError, errors.Join(fmt.Errorf("failed to find user by id"), err)
+	}
+
+	if user == nil {
+		return status_codes.VerifyAgeStatusError, fmt.Errorf("user %d not found", userID)
+	}
+
+	if user.AgeVerifiedAt != nil {
+		return status_codes.VerifyAgeStatusAlreadyVerified, nil
+	}
+
+	if !rules.IsValidCPF(req.CPF) {
+		return status_codes.VerifyAgeStatusInvalidCPF, nil
+	}
+
+	cpf := rules.NormalizeCPF(req.CPF)
+
+	birthDate, err := time.ParseInLocation(time.DateOnly, util.TrimSpace(req.BirthDate), time.Local)
+	if err != nil {
+		return status_codes.VerifyAgeStatusInvalidBirthDate, nil
+	}
+
+	now := time.Now()
+	if birthDate.After(now) {
+		return status_codes.VerifyAgeStatusInvalidBirthDate, nil
+	}
+
+	if !rules.IsAdult(birthDate, now) {
+		return status_codes.VerifyAgeStatusUnderage, nil
+	}
+
+	if !req.AcceptTerms {
+		return status_codes.VerifyAgeStatusTermsNotAccepted, nil
+	}
+
+	existing, err := u.userRepository.FindByCPF(ctx, cpf)
+	if err != nil {
+		return status_codes.VerifyAgeStatusError, errors.Join(fmt.Errorf("failed to check cpf"), err)
+	}
+
+	if existing != nil && existing.ID != user.ID {
+		return status_codes.VerifyAgeStatusCPFAlreadyExists, nil
+	}
+
+	confirmation := &entities.AgeConfirmation{
+		UserID:     userID,
+		CPF:        cpf,
+		BirthDate:  birthDate,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		AcceptedAt: now,
+	}
+
+	if err = u.userRepository.ConfirmAge(ctx, confirmation); err != nil {
+		return status_codes.VerifyAgeStatusError, errors.Join(fmt.Errorf("failed to confirm age"), err)
+	}
+
+	return status_codes.VerifyAgeStatusSuccess, nil
+}
+
+// removeAvatar deletes the avatar file, if any. Failures only leave an orphan file behind, so
+// they are logged.
+func removeAvatar(ctx context.Context, filename string) {
+	if filename == "" {
+		return
+	}
+
+	err := os.Remove(filepath.Join(util2.ImagesDir, filename))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.ErrorContext(ctx, "failed to remove avatar", "filename", filename, "cause", err)
+	}
+}
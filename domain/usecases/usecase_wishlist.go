@@ -0,0 +1,122 @@
+package usecases
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/status_codes"
+	"cachacariaapi/domain/util"
+	repositories "cachacariaapi/infrastructure/datastore"
+	"context"
+	"errors"
+	"fmt"
+)
+
+type WishlistUseCases struct {
+	wishlistRepository repositories.WishlistRepository
+	productRepository  repositories.ProductRepository
+	cartUseCases       CartUseCases
+	baseURL            string
+}
+
+func NewWishlistUseCases(
+	wishlistRepository repositories.WishlistRepository,
+	productRepository repositories.ProductRepository,
+	cartUseCases CartUseCases,
+	baseURL string,
+) WishlistUseCases {
+	return WishlistUseCases{
+		wishlistRepository: wishlistRepository,
+		productRepository:  productRepository,
+		cartUseCases:       cartUseCases,
+		baseURL:            baseURL,
+	}
+}
+
+func (u *WishlistUseCases) AddToWishlist(ctx context.Context, userID, productID int64) (status_codes.AddWishlistItemStatus, error) {
+	product, err := u.productRepository.GetProduct(productID)
+	if err != nil {
+		return status_codes.AddWishlistItemStatusError, errors.Join(fmt.Errorf("failed to get product"), err)
+	}
+
+	if product == nil {
+		return status_codes.AddWishlistItemStatusInvalidProduct, nil
+	}
+
+	if err = u.wishlistRepository.AddToWishlist(ctx, userID, productID); err != nil {
+		return status_codes.AddWishlistItemStatusError, errors.Join(fmt.Errorf("failed to add product to wishlist"), err)
+	}
+
+	return status_codes.AddWishlistItemStatusSuccess, nil
+}
+
+// GetWishlist returns the user's favorite products, most recently added first
+func (u *WishlistUseCases) GetWishlist(ctx context.Context, userID int64) ([]entities.WishlistItem, error) {
+	items, err := u.wishlistRepository.GetWishlistItems(ctx, userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get wishlist items"), err)
+	}
+
+	for _, item := range items {
+		if item.Product != nil && len(item.Product.Photos) > 0 {
+			photos := make([]string, len(item.Product.Photos))
+			for i, filename := range item.Product.Photos {
+				photos[i] = util.GetProductImageURL(filename, u.baseURL)
+			}
+			item.Product.Photos = photos
+		}
+	}
+
+	return items, nil
+}
+
+func (u *WishlistUseCases) RemoveFromWishlist(ctx context.Context, userID, productID int64) (status_codes.RemoveWishlistItemStatus, error) {
+	exists, err := u.wishlistRepository.IsInWishlist(ctx, userID, productID)
+	if err != nil {
+		return status_codes.RemoveWishlistItemStatusError, errors.Join(fmt.Errorf("failed to check wishlist item"), err)
+	}
+
+	if !exists {
+		return status_codes.RemoveWishlistItemStatusNotFound, nil
+	}
+
+	if err = u.wishlistRepository.RemoveFromWishlist(ctx, userID, productID); err != nil {
+		return status_codes.RemoveWishlistItemStatusError, errors.Join(fmt.Errorf("failed to remove product from wishlist"), err)
+	}
+
+	return status_codes.RemoveWishlistItemStatusSuccess, nil
+}
+
+// MoveToCart adds a favorite product to the cart, with the same validation as adding it
+// directly, and removes it from the wishlist once it is in the cart
+func (u *WishlistUseCases) MoveToCart(ctx context.Context, userID, productID int64, quantity int) (status_codes.MoveWishlistItemStatus, error) {
+	exists, err := u.wishlistRepository.IsInWishlist(ctx, userID, productID)
+	if err != nil {
+		return status_codes.MoveWishlistItemStatusError, errors.Join(fmt.Errorf("failed to check wishlist item"), err)
+	}
+
+	if !exists {
+		return status_codes.MoveWishlistItemStatusNotFound, nil
+	}
+
+	status, err := u.cartUseCases.AddToCart(ctx, userID, productID, quantity)
+	if err != nil {
+		return status_codes.MoveWishlistItemStatusError, errors.Join(fmt.Errorf("failed to move product to cart"), err)
+	}
+
+	switch status {
+	case status_codes.AddProductItemStatusSuccess:
+	case status_codes.AddProductItemStatusInvalidQuantity:
+		return status_codes.MoveWishlistItemStatusInvalidQuantity, nil
+	case status_codes.AddProductItemStatusInvalidProduct:
+		return status_codes.MoveWishlistItemStatusInvalidProduct, nil
+	case status_codes.AddProductItemStatusInvalidUser:
+		return status_codes.MoveWishlistItemStatusInvalidUser, nil
+	default:
+		return status_codes.MoveWishlistItemStatusError, nil
+	}
+
+	if err = u.wishlistRepository.RemoveFromWishlist(ctx, userID, productID); err != nil {
+		return status_codes.MoveWishlistItemStatusError, errors.Join(fmt.Errorf("failed to remove product from wishlist"), err)
+	}
+
+	return status_codes.MoveWishlistItemStatusSuccess, nil
+}
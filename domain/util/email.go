@@ -3,19 +3,34 @@ package util
 import (
 	"bytes"
 	"cachacariaapi/domain/entities"
+	"embed"
+	"errors"
+	"fmt"
+	"html"
 	"html/template"
-	"net/smtp"
-	"path/filepath"
-	"runtime"
+	"io/fs"
+	"math"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// EmailConfig chooses the mailer and configures it. Security only applies to the SMTP mailer,
+// and Dir to the file mailer.
 type EmailConfig struct {
+	Mailer   string `toml:"mailer"`
 	SMTPHost string `toml:"host"`
 	SMTPPort string `toml:"port"`
+	Security string `toml:"security"`
 	Username string `toml:"username"`
 	Password string `toml:"password"`
 	From     string `toml:"from"`
+	FromName string `toml:"from_name"`
+	Dir      string `toml:"dir"`
+	// TemplatesDir holds templates, laid out as <locale>/<name>.gohtml, that replace the
+	// embedded ones
+	TemplatesDir string `toml:"templates_dir"`
 }
 
 func NewEmailConfig(
@@ -33,72 +48,450 @@ func NewEmailConfig(
 	}
 }
 
-// SendEmail sends a plain text email using SMTP
-func SendEmail(cfg EmailConfig, to []string, subject, body string) error {
-	msg := []byte(
-		"Subject: " + subject + "\n" +
-			"MIME-Version: 1.0;\n" +
-			"Content-Type: text/html; charset=\"UTF-8\";\n\n" +
-			body,
-	)
+//go:embed templates
+var embeddedTemplates embed.FS
 
-	auth := smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost)
+// Email templates, which exist in every locale in templates/<locale>/<name>.gohtml. Each one
+// defines the "subject" and the "content" of the layout, and may define its "title" and
+// "accent" color. The layout, in templates/layout.gohtml, is shared by every locale and takes
+// its texts from the defines of templates/<locale>/strings.gohtml.
+const (
+	TemplateAccountCreated    = "account_created"
+	TemplatePasswordChanged   = "password_changed"
+	TemplatePasswordReset     = "password_reset"
+	TemplateEmailVerification = "email_verification"
+	TemplateAccountLocked     = "account_locked"
+	TemplateOrderConfirmation = "order_confirmation"
+	TemplateOrderPaid         = "order_paid"
+	TemplateOrderShipped      = "order_shipped"
+	TemplateOrderDelivered    = "order_delivered"
+	TemplateOrderCancelled    = "order_cancelled"
+	TemplateCartReminder      = "cart_reminder"
+	TemplateBackInStock       = "back_in_stock"
+	TemplateReviewApproved    = "review_approved"
+)
+
+// The layout shared by every locale, and the texts of the layout in each locale
+const (
+	layoutTemplate  = "layout"
+	stringsTemplate = "strings"
+)
 
-	return smtp.SendMail(
-		cfg.SMTPHost+":"+cfg.SMTPPort,
-		auth,
-		cfg.From,
-		to,
-		msg,
-	)
+var EmailTemplateNames = []string{
+	TemplateAccountCreated,
+	TemplatePasswordChanged,
+	TemplatePasswordReset,
+	TemplateEmailVerification,
+	TemplateAccountLocked,
+	TemplateOrderConfirmation,
+	TemplateOrderPaid,
+	TemplateOrderShipped,
+	TemplateOrderDelivered,
+	TemplateOrderCancelled,
+	TemplateCartReminder,
+	TemplateBackInStock,
+	TemplateReviewApproved,
 }
 
-func RenderTemplate(name string, data any) (string, error) {
-	path := templateFile(name)
+// EmailTemplates renders the emails in the user's locale, from the templates embedded in the
+// binary or from the override directory. Templates are parsed on each render, so overrides
+// apply without a restart.
+type EmailTemplates struct {
+	embedded fs.FS
+	override fs.FS
+}
 
-	t, err := template.ParseFiles(path)
+func NewEmailTemplates(overrideDir string) *EmailTemplates {
+	embedded, err := fs.Sub(embeddedTemplates, "templates")
 	if err != nil {
-		return "", err
+		panic(err)
 	}
 
-	var buf bytes.Buffer
-	if err := t.Execute(&buf, data); err != nil {
-		return "", err
+	templates := &EmailTemplates{embedded: embedded}
+	if overrideDir != "" {
+		templates.override = os.DirFS(overrideDir)
 	}
 
-	return buf.String(), nil
+	return templates
 }
 
-func templateFile(name string) string {
-	_, b, _, _ := runtime.Caller(0)
-	baseDir := filepath.Dir(b)
-	return filepath.Join(baseDir, "templates", name)
+// render renders the template in the locale, with the layout, and returns the subject and body
+func (t *EmailTemplates) render(locale, name string, data map[string]any) (string, string, error) {
+	tmpl, err := t.parse(locale, name)
+	if err != nil {
+		return "", "", err
+	}
+
+	subject, err := executeText(tmpl, "subject", data)
+	if err != nil {
+		return "", "", err
+	}
+
+	var body bytes.Buffer
+	if err = tmpl.ExecuteTemplate(&body, "layout", data); err != nil {
+		return "", "", errors.Join(fmt.Errorf("failed to render email template %q", name), err)
+	}
+
+	return subject, body.String(), nil
 }
 
-func SendAccountCreatedEmail(cfg EmailConfig, to []string, user entities.User) error {
-	html, err := RenderTemplate("account_created.gohtml", map[string]any{
-		"Name":  user.Email,
-		"Email": user.Email,
-		"Year":  time.Now().Year(),
-	})
+// parse parses the template of the locale along with the shared layout and the locale's strings
+func (t *EmailTemplates) parse(locale, name string) (*template.Template, error) {
+	layout, err := t.readFile(layoutTemplate + ".gohtml")
+	if err != nil {
+		return nil, err
+	}
+
+	if layout == nil {
+		return nil, fmt.Errorf("email template %q not found", layoutTemplate)
+	}
+
+	tmpl := template.New("email").Funcs(templateFuncs)
+	if _, err = tmpl.New(layoutTemplate).Parse(string(layout)); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to parse email template %q", layoutTemplate), err)
+	}
+
+	for _, file := range []string{stringsTemplate, name} {
+		content, err := t.readTemplate(locale, file)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = tmpl.New(file).Parse(string(content)); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to parse email template %q", file), err)
+		}
+	}
+
+	return tmpl, nil
+}
+
+// executeText renders a part of the template meant as plain text, such as the subject
+func executeText(tmpl *template.Template, part string, data map[string]any) (string, error) {
+	var text bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&text, part, data); err != nil {
+		return "", errors.Join(fmt.Errorf("failed to render %q of email template", part), err)
+	}
+
+	return html.UnescapeString(strings.TrimSpace(text.String())), nil
+}
 
+// readTemplate reads the template of the locale, preferring the override directory, and falls
+// back to entities.DefaultLocale for the templates that were not translated
+func (t *EmailTemplates) readTemplate(locale, name string) ([]byte, error) {
+	locales := []string{locale}
+	if locale != entities.DefaultLocale {
+		locales = append(locales, entities.DefaultLocale)
+	}
+
+	for _, locale := range locales {
+		content, err := t.readFile(locale + "/" + name + ".gohtml")
+		if err != nil || content != nil {
+			return content, err
+		}
+	}
+
+	return nil, fmt.Errorf("email template %q not found", name)
+}
+
+// readFile reads the file from the override directory or else from the embedded templates, and
+// returns nil if neither has it
+func (t *EmailTemplates) readFile(path string) ([]byte, error) {
+	for _, fsys := range []fs.FS{t.override, t.embedded} {
+		if fsys == nil {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, path)
+		if err == nil {
+			return content, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, errors.Join(fmt.Errorf("failed to read email template %q", path), err)
+		}
+	}
+
+	return nil, nil
+}
+
+// newEmail renders the template in the user's locale and addresses the email to the user
+func (t *EmailTemplates) newEmail(user entities.User, name string, data map[string]any) (entities.Email, error) {
+	subject, body, err := t.render(user.Locale, name, withUser(user, data))
 	if err != nil {
-		return err
+		return entities.Email{}, err
 	}
 
-	return SendEmail(cfg, to, "Conta criada com sucesso", html)
+	return entities.Email{To: user.Email, Subject: subject, Body: body}, nil
 }
 
-func SendPasswordChangedEmail(cfg EmailConfig, to []string, user entities.User) error {
-	html, err := RenderTemplate("password_changed.gohtml", map[string]any{
-		"Name":  user.Email,
-		"Email": user.Email,
-		"Year":  time.Now().Year(),
-	})
+// newNotification renders the template in the user's locale as an in-app notification, titled
+// with the "subject" of the template and with its "summary" as body, and as an email
+func (t *EmailTemplates) newNotification(
+	user entities.User,
+	notificationType entities.NotificationType,
+	name, link string,
+	data map[string]any,
+) (entities.Notification, entities.Email, error) {
+	data["Link"] = link
+	data = withUser(user, data)
+
+	tmpl, err := t.parse(user.Locale, name)
+	if err != nil {
+		return entities.Notification{}, entities.Email{}, err
+	}
 
+	title, err := executeText(tmpl, "subject", data)
 	if err != nil {
-		return err
+		return entities.Notification{}, entities.Email{}, err
+	}
+
+	summary, err := executeText(tmpl, "summary", data)
+	if err != nil {
+		return entities.Notification{}, entities.Email{}, err
+	}
+
+	var body bytes.Buffer
+	if err = tmpl.ExecuteTemplate(&body, "layout", data); err != nil {
+		return entities.Notification{}, entities.Email{}, errors.Join(fmt.Errorf("failed to render email template %q", name), err)
+	}
+
+	notification := entities.Notification{
+		UserID: int64(user.ID),
+		Type:   notificationType,
+		Title:  title,
+		Body:   summary,
+		Link:   link,
+	}
+
+	return notification, entities.Email{To: user.Email, Subject: title, Body: body.String()}, nil
+}
+
+// withUser adds the recipient to the data of the template, for the layout
+func withUser(user entities.User, data map[string]any) map[string]any {
+	data["Name"] = user.DisplayName()
+	data["Email"] = user.Email
+	data["Year"] = time.Now().Year()
+	return data
+}
+
+func (t *EmailTemplates) NewAccountCreatedEmail(user entities.User) (entities.Email, error) {
+	return t.newEmail(user, TemplateAccountCreated, map[string]any{})
+}
+
+func (t *EmailTemplates) NewPasswordChangedEmail(user entities.User) (entities.Email, error) {
+	return t.newEmail(user, TemplatePasswordChanged, map[string]any{})
+}
+
+func (t *EmailTemplates) NewPasswordResetEmail(user entities.User, link string, expiresIn time.Duration) (entities.Email, error) {
+	return t.newEmail(user, TemplatePasswordReset, map[string]any{
+		"Link":      link,
+		"ExpiresIn": int(expiresIn.Minutes()),
+	})
+}
+
+func (t *EmailTemplates) NewEmailVerificationEmail(user entities.User, link string, expiresIn time.Duration) (entities.Email, error) {
+	return t.newEmail(user, TemplateEmailVerification, map[string]any{
+		"Link":      link,
+		"ExpiresIn": int(expiresIn.Hours()),
+	})
+}
+
+func (t *EmailTemplates) NewAccountLockedEmail(user entities.User, ipAddress string, lockedFor time.Duration) (entities.Email, error) {
+	return t.newEmail(user, TemplateAccountLocked, map[string]any{
+		"IPAddress": ipAddress,
+		"LockedFor": int(lockedFor.Minutes()),
+	})
+}
+
+// orderTemplates are the templates of the email sent when an order reaches each status
+var orderTemplates = map[entities.OrderStatus]string{
+	entities.OrderStatusPending:   TemplateOrderConfirmation,
+	entities.OrderStatusPaid:      TemplateOrderPaid,
+	entities.OrderStatusShipped:   TemplateOrderShipped,
+	entities.OrderStatusDelivered: TemplateOrderDelivered,
+	entities.OrderStatusCancelled: TemplateOrderCancelled,
+}
+
+// NewOrderEmail renders the email telling the user that the order reached its current status:
+// the confirmation for a new order, then payment, shipping, delivery or cancellation. The
+// order items must have their products loaded.
+func (t *EmailTemplates) NewOrderEmail(user entities.User, order entities.Order, link string) (entities.Email, error) {
+	_, email, err := t.NewOrderNotification(user, order, link)
+	return email, err
+}
+
+// NewOrderNotification renders the notification of the current status of the order, along
+// with its email, as NewOrderEmail
+func (t *EmailTemplates) NewOrderNotification(user entities.User, order entities.Order, link string) (entities.Notification, entities.Email, error) {
+	name, ok := orderTemplates[order.Status]
+	if !ok {
+		return entities.Notification{}, entities.Email{}, fmt.Errorf("no email for order status %q", order.Status)
+	}
+
+	return t.newNotification(user, entities.NotificationOrderStatus, name, link, map[string]any{
+		"Order": order,
+	})
+}
+
+// NewBackInStockNotification tells the user that a product of the wishlist is available again.
+// The product must have the photo URLs.
+func (t *EmailTemplates) NewBackInStockNotification(user entities.User, product entities.Product, link string) (entities.Notification, entities.Email, error) {
+	return t.newNotification(user, entities.NotificationBackInStock, TemplateBackInStock, link, map[string]any{
+		"Product": product,
+		"Price":   float64(product.Price),
+	})
+}
+
+// NewReviewApprovedNotification tells the user that the review of the product was published
+func (t *EmailTemplates) NewReviewApprovedNotification(
+	user entities.User,
+	review entities.Review,
+	product entities.Product,
+	link string,
+) (entities.Notification, entities.Email, error) {
+	return t.newNotification(user, entities.NotificationReviewApproved, TemplateReviewApproved, link, map[string]any{
+		"Review":  review,
+		"Product": product,
+	})
+}
+
+// NewCartReminderEmail renders the reminder of a cart left without checking out. The cart items
+// must have their products loaded, with the photo URLs.
+func (t *EmailTemplates) NewCartReminderEmail(user entities.User, cart entities.Cart, link string) (entities.Email, error) {
+	return t.newEmail(user, TemplateCartReminder, map[string]any{
+		"Cart": cart,
+		"Link": link,
+	})
+}
+
+// Preview renders the template in the locale with sample data, for the back office
+func (t *EmailTemplates) Preview(name, locale string) (entities.Email, error) {
+	birthDate := time.Date(1990, time.May, 20, 0, 0, 0, 0, time.Local)
+	user := entities.User{
+		ID:        1,
+		Email:     "maria@example.com",
+		Name:      "Maria da Silva",
+		BirthDate: &birthDate,
+		Locale:    locale,
+	}
+
+	order := entities.Order{
+		ID:           1234,
+		UserID:       1,
+		Subtotal:     289.70,
+		Discount:     28.97,
+		TotalAmount:  260.73,
+		TrackingCode: "AA123456789BR",
+		CreatedAt:    time.Now(),
+		ShippingAddress: &entities.ShippingAddress{
+			RecipientName: "Maria da Silva",
+			ZipCode:       "01310100",
+			Street:        "Avenida Paulista",
+			Number:        "1000",
+			Complement:    "Apto 12",
+			District:      "Bela Vista",
+			City:          "São Paulo",
+			State:         "SP",
+		},
+		Items: []entities.OrderItem{
+			{ProductID: 1, Quantity: 2, Price: 89.90, Product: &entities.Product{ID: 1, Name: "Cachaça Ouro Envelhecida 700ml"}},
+			{ProductID: 2, Quantity: 1, Price: 109.90, Product: &entities.Product{ID: 2, Name: "Cachaça Prata Artesanal 1L"}},
+		},
+	}
+
+	link := "https://example.com/link"
+	switch name {
+	case TemplateAccountCreated:
+		return t.NewAccountCreatedEmail(user)
+	case TemplatePasswordChanged:
+		return t.NewPasswordChangedEmail(user)
+	case TemplatePasswordReset:
+		return t.NewPasswordResetEmail(user, link, time.Hour)
+	case TemplateEmailVerification:
+		return t.NewEmailVerificationEmail(user, link, 24*time.Hour)
+	case TemplateAccountLocked:
+		return t.NewAccountLockedEmail(user, "203.0.113.10", 15*time.Minute)
+	case TemplateCartReminder:
+		return t.NewCartReminderEmail(user, sampleCart(order), link)
+	case TemplateBackInStock:
+		cart := sampleCart(order)
+		_, email, err := t.NewBackInStockNotification(user, *cart.Items[0].Product, link)
+		return email, err
+	case TemplateReviewApproved:
+		review := entities.Review{ID: 1, ProductID: 1, UserID: 1, Rating: 5, Comment: "Excelente cachaça, muito suave.", Status: entities.ReviewStatusApproved}
+		_, email, err := t.NewReviewApprovedNotification(user, review, *order.Items[0].Product, link)
+		return email, err
+	}
+
+	for status, template := range orderTemplates {
+		if template == name {
+			order.Status = status
+			return t.NewOrderEmail(user, order, link)
+		}
+	}
+
+	return entities.Email{}, fmt.Errorf("email template %q not found", name)
+}
+
+// sampleCart is a cart with the items of the sample order
+func sampleCart(order entities.Order) entities.Cart {
+	cart := entities.Cart{Subtotal: order.Subtotal, Total: order.Subtotal}
+	for _, item := range order.Items {
+		product := *item.Product
+		product.Photos = []string{"https://example.com/images/products/" + strconv.FormatInt(product.ID, 10) + ".jpg"}
+
+		total := item.Price * float64(item.Quantity)
+		cart.Items = append(cart.Items, &entities.CartItem{
+			Product:   &product,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.Price,
+			Subtotal:  total,
+			Total:     total,
+		})
+	}
+
+	return cart
+}
+
+var templateFuncs = template.FuncMap{
+	"price":   FormatPrice,
+	"zipCode": formatZipCode,
+	"itemTotal": func(item entities.OrderItem) float64 {
+		return item.Price * float64(item.Quantity)
+	},
+	"stars": func(rating int) string {
+		rating = min(max(rating, 0), 5)
+		return strings.Repeat("★", rating) + strings.Repeat("☆", 5-rating)
+	},
+}
+
+// FormatPrice formats the value in reais, as in "R$ 1.234,50"
+func FormatPrice(value float64) string {
+	cents := int64(math.Round(math.Abs(value) * 100))
+	digits := strconv.FormatInt(cents/100, 10)
+
+	var integer strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			integer.WriteByte('.')
+		}
+		integer.WriteRune(digit)
+	}
+
+	sign := ""
+	if value < 0 && cents > 0 {
+		sign = "-"
+	}
+
+	return fmt.Sprintf("%sR$ %s,%02d", sign, integer.String(), cents%100)
+}
+
+// formatZipCode formats a CEP stored as digits, as in "01310-100"
+func formatZipCode(zipCode string) string {
+	if len(zipCode) != 8 {
+		return zipCode
 	}
 
-	return SendEmail(cfg, to, "Senha alterada com sucesso", html)
+	return zipCode[:5] + "-" + zipCode[5:]
 }
@@ -0,0 +1,154 @@
+package util
+
+import (
+	"bytes"
+	"cachacariaapi/domain/entities"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Mailers and connection security accepted in the [EmailConfig] section of the config
+const (
+	MailerSMTP   = "smtp"
+	MailerFile   = "file"
+	MailerMemory = "memory"
+
+	SecuritySTARTTLS = "starttls"
+	SecurityTLS      = "tls"
+	SecurityNone     = "none"
+)
+
+// Mailer delivers a rendered email. The outbox worker is its only caller, so implementations
+// do not need to retry.
+type Mailer interface {
+	Send(ctx context.Context, email entities.Email) error
+}
+
+// NewMailer creates the mailer chosen in the config: SMTP by default, a directory of .eml
+// files for development, or memory for tests
+func NewMailer(cfg EmailConfig) Mailer {
+	switch cfg.Mailer {
+	case MailerFile:
+		return NewFileMailer(cfg.Dir, cfg.sender())
+	case MailerMemory:
+		return NewMemoryMailer()
+	default:
+		return NewSMTPMailer(cfg)
+	}
+}
+
+// sender is the From address, with the display name when one is configured
+func (cfg EmailConfig) sender() mail.Address {
+	return mail.Address{Name: cfg.FromName, Address: cfg.From}
+}
+
+// buildMessage encodes the email as a multipart/alternative MIME message, with a plain text
+// version of the HTML body for the clients that do not display HTML
+func buildMessage(from mail.Address, email entities.Email, now time.Time) ([]byte, error) {
+	messageID, err := newMessageID(from.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	if err = writePart(writer, "text/plain", htmlToText(email.Body)); err != nil {
+		return nil, err
+	}
+
+	if err = writePart(writer, "text/html", email.Body); err != nil {
+		return nil, err
+	}
+
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+
+	to := mail.Address{Address: email.To}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", to.String())
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: %s\r\n", messageID)
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", writer.Boundary())
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+func writePart(writer *multipart.Writer, contentType, content string) error {
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=\"utf-8\""},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := quotedprintable.NewWriter(part)
+	if _, err = encoder.Write([]byte(content)); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// newMessageID returns a unique Message-ID in the domain of the sender
+func newMessageID(from string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain), nil
+}
+
+var (
+	htmlHiddenPattern    = regexp.MustCompile(`(?is)<(head|style|script)[^>]*>.*?</(head|style|script)>`)
+	htmlLinkPattern      = regexp.MustCompile(`(?is)<a\s[^>]*href="([^"]*)"[^>]*>(.*?)</a>`)
+	htmlParagraphPattern = regexp.MustCompile(`(?i)</(p|h[1-6]|table)>`)
+	htmlBreakPattern     = regexp.MustCompile(`(?i)<br\s*/?>|</(div|li|tr)>`)
+	htmlTagPattern       = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern    = regexp.MustCompile(`\s+`)
+	blankLinesPattern    = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText renders the HTML body as plain text, keeping the paragraphs, table rows and links
+func htmlToText(body string) string {
+	text := htmlHiddenPattern.ReplaceAllString(body, "")
+	text = htmlLinkPattern.ReplaceAllString(text, "$2 ($1)")
+	text = whitespacePattern.ReplaceAllString(text, " ")
+	text = htmlParagraphPattern.ReplaceAllString(text, "\n\n")
+	text = htmlBreakPattern.ReplaceAllString(text, "\n")
+	text = htmlTagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+
+	text = strings.Join(lines, "\n")
+	text = blankLinesPattern.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text) + "\n"
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"cachacariaapi/domain/entities"
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"time"
+)
+
+// FileMailer writes each email to a .eml file in a directory instead of sending it, so that
+// development does not email real addresses. The files open in any email client.
+type FileMailer struct {
+	dir  string
+	from mail.Address
+}
+
+func NewFileMailer(dir string, from mail.Address) Mailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (m *FileMailer) Send(ctx context.Context, email entities.Email) error {
+	now := time.Now()
+
+	msg, err := buildMessage(m.from, email, now)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to build email"), err)
+	}
+
+	if err = os.MkdirAll(m.dir, 0o755); err != nil {
+		return errors.Join(fmt.Errorf("failed to create email directory"), err)
+	}
+
+	file, err := os.CreateTemp(m.dir, now.Format("20060102-150405")+"-*.eml")
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to create email file"), err)
+	}
+
+	_, err = file.Write(msg)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to write email file"), err)
+	}
+
+	return nil
+}
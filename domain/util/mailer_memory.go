@@ -0,0 +1,42 @@
+package util
+
+import (
+	"cachacariaapi/domain/entities"
+	"context"
+	"slices"
+	"sync"
+)
+
+// MemoryMailer keeps the emails in memory instead of sending them, for tests
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []entities.Email
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, email entities.Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, email)
+	return nil
+}
+
+// Sent returns the emails sent so far, oldest first
+func (m *MemoryMailer) Sent() []entities.Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.sent)
+}
+
+// Reset forgets the emails sent so far
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = nil
+}
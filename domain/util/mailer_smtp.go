@@ -0,0 +1,109 @@
+package util
+
+import (
+	"cachacariaapi/domain/entities"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer sends emails through an SMTP server, upgrading the connection with STARTTLS by
+// default, or connecting with implicit TLS, usually on port 465
+type SMTPMailer struct {
+	cfg EmailConfig
+}
+
+func NewSMTPMailer(cfg EmailConfig) Mailer {
+	return &SMTPMailer{cfg: cfg}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, email entities.Email) error {
+	msg, err := buildMessage(m.cfg.sender(), email, time.Now())
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to build email"), err)
+	}
+
+	client, err := m.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.cfg.Username != "" {
+		auth := smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.SMTPHost)
+		if err = client.Auth(auth); err != nil {
+			return errors.Join(fmt.Errorf("failed to authenticate to smtp server"), err)
+		}
+	}
+
+	if err = client.Mail(m.cfg.From); err != nil {
+		return errors.Join(fmt.Errorf("failed to set email sender"), err)
+	}
+
+	if err = client.Rcpt(email.To); err != nil {
+		return errors.Join(fmt.Errorf("failed to set email recipient"), err)
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to start email data"), err)
+	}
+
+	if _, err = writer.Write(msg); err != nil {
+		return errors.Join(fmt.Errorf("failed to write email"), err)
+	}
+
+	if err = writer.Close(); err != nil {
+		return errors.Join(fmt.Errorf("failed to send email"), err)
+	}
+
+	return client.Quit()
+}
+
+// connect opens the connection to the server, secured as configured, with a deadline so that a
+// stuck server does not hold the outbox worker
+func (m *SMTPMailer) connect(ctx context.Context) (*smtp.Client, error) {
+	address := net.JoinHostPort(m.cfg.SMTPHost, m.cfg.SMTPPort)
+	tlsConfig := &tls.Config{ServerName: m.cfg.SMTPHost}
+
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to connect to smtp server"), err)
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+
+	if err = conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, errors.Join(fmt.Errorf("failed to set smtp deadline"), err)
+	}
+
+	if m.cfg.Security == SecurityTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Join(fmt.Errorf("failed to start smtp session"), err)
+	}
+
+	if m.cfg.Security == SecuritySTARTTLS || m.cfg.Security == "" {
+		if err = client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, errors.Join(fmt.Errorf("failed to start tls"), err)
+		}
+	}
+
+	return client, nil
+}
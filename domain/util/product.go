@@ -2,6 +2,18 @@ package util
 
 import "fmt"
 
+// ImagesDir is where the product images and user avatars are stored, served under /images/
+const ImagesDir = "C:/Users/Gsdagustavo/Documents/imagesCacahcaria"
+
 func GetProductImageURL(filename string, baseURL string) string {
 	return fmt.Sprintf("%s/images/%s", baseURL, filename)
 }
+
+// GetAvatarURL returns the URL of a user's avatar, or an empty string for users without one
+func GetAvatarURL(filename string, baseURL string) string {
+	if filename == "" {
+		return ""
+	}
+
+	return GetProductImageURL(filename, baseURL)
+}
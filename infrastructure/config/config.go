@@ -4,6 +4,7 @@ import (
 	"cachacariaapi/domain/util"
 	"cachacariaapi/infrastructure/modules"
 	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -21,6 +22,11 @@ type Config struct {
 	Server       Server           `toml:"Server"`
 	SymmetricKey string           `toml:"symmetric_key"`
 	Email        util.EmailConfig `toml:"EmailConfig"`
+	Jobs         Jobs             `toml:"Jobs"`
+	Auth         Auth             `toml:"Auth"`
+	OIDC         map[string]OIDC  `toml:"OIDC"`
+	Legal        Legal            `toml:"Legal"`
+	CartReminder CartReminder     `toml:"CartReminder"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -46,15 +52,93 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	if cfg.Jobs.PriceSchedulerInterval <= 0 {
+		cfg.Jobs.PriceSchedulerInterval = time.Minute
+	}
+
+	if cfg.Jobs.TokenCleanupInterval <= 0 {
+		cfg.Jobs.TokenCleanupInterval = time.Hour
+	}
+
+	if cfg.Jobs.EmailOutboxInterval <= 0 {
+		cfg.Jobs.EmailOutboxInterval = 10 * time.Second
+	}
+
+	if cfg.Jobs.CartReminderInterval <= 0 {
+		cfg.Jobs.CartReminderInterval = 15 * time.Minute
+	}
+
+	if cfg.CartReminder.AbandonedAfter <= 0 {
+		cfg.CartReminder.AbandonedAfter = 24 * time.Hour
+	}
+
+	if cfg.CartReminder.MaxReminders < 0 {
+		return nil, fmt.Errorf("invalid max_reminders %d in config", cfg.CartReminder.MaxReminders)
+	}
+
+	if cfg.Email.Mailer == "" {
+		cfg.Email.Mailer = util.MailerSMTP
+	}
+
+	if cfg.Email.Security == "" {
+		cfg.Email.Security = util.SecuritySTARTTLS
+	}
+
+	if err = validateEmailConfig(cfg.Email); err != nil {
+		return nil, err
+	}
+
+	if cfg.Legal.TermsVersion == "" || cfg.Legal.PrivacyPolicyVersion == "" {
+		return nil, fmt.Errorf("missing terms and privacy policy versions in config")
+	}
+
 	return &cfg, nil
 }
 
+// validateEmailConfig checks the mailer settings, so that a typo does not silently keep every
+// email in the outbox
+func validateEmailConfig(cfg util.EmailConfig) error {
+	switch cfg.Mailer {
+	case util.MailerSMTP:
+		if cfg.SMTPHost == "" || cfg.SMTPPort == "" || cfg.From == "" {
+			return fmt.Errorf("missing smtp host, port or sender in config")
+		}
+
+		if cfg.Security != util.SecuritySTARTTLS && cfg.Security != util.SecurityTLS && cfg.Security != util.SecurityNone {
+			return fmt.Errorf("invalid smtp security %q in config", cfg.Security)
+		}
+	case util.MailerFile:
+		if cfg.Dir == "" {
+			return fmt.Errorf("missing email directory in config")
+		}
+	case util.MailerMemory:
+	default:
+		return fmt.Errorf("invalid mailer %q in config", cfg.Mailer)
+	}
+
+	if cfg.TemplatesDir != "" {
+		info, err := os.Stat(cfg.TemplatesDir)
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to read email templates directory"), err)
+		}
+
+		if !info.IsDir() {
+			return fmt.Errorf("email templates path %q is not a directory", cfg.TemplatesDir)
+		}
+	}
+
+	return nil
+}
+
 type Server struct {
 	Port    int    `toml:"port"`
 	Host    string `toml:"host"`
 	BaseURL string `toml:"base_url"`
-	Router  *mux.Router
-	Server  *http.Server
+	// TrustedProxies are the addresses or CIDR ranges of the reverse proxies in front of the
+	// API, the only ones whose X-Forwarded-For header is read
+	TrustedProxies []string `toml:"trusted_proxies"`
+	Router         *mux.Router
+	Server         *http.Server
 }
 
 func (s Server) RegisterModules(router *mux.Router, modules ...modules.Module) {
@@ -71,7 +155,7 @@ func (s Server) Run(cfg Config) error {
 	s.Router.Use(CORSMiddleware)
 
 	s.Router.PathPrefix("/images/").Handler(http.StripPrefix("/images/",
-		http.FileServer(http.Dir("C:/Users/Gsdagustavo/Documents/imagesCacahcaria")),
+		http.FileServer(noDirListing{http.Dir(util.ImagesDir)}),
 	))
 
 	s.Router.PathPrefix("/").Handler(http.FileServer(http.Dir("./public")))
@@ -79,6 +163,39 @@ func (s Server) Run(cfg Config) error {
 	return http.ListenAndServe(address, s.Router)
 }
 
+type Jobs struct {
+	PriceSchedulerInterval time.Duration `toml:"price_scheduler_interval"`
+	TokenCleanupInterval   time.Duration `toml:"token_cleanup_interval"`
+	EmailOutboxInterval    time.Duration `toml:"email_outbox_interval"`
+	CartReminderInterval   time.Duration `toml:"cart_reminder_interval"`
+}
+
+// CartReminder configures the abandoned cart reminders: a cart untouched for AbandonedAfter
+// gets a reminder, and another one every AbandonedAfter, up to MaxReminders
+type CartReminder struct {
+	AbandonedAfter time.Duration `toml:"abandoned_after"`
+	MaxReminders   int           `toml:"max_reminders"`
+}
+
+type Auth struct {
+	// RequireAdminTwoFactor closes the admin routes to admins without two-factor authentication
+	RequireAdminTwoFactor bool `toml:"require_admin_two_factor"`
+}
+
+// OIDC is an OpenID Connect login provider. Providers without a client id are disabled.
+type OIDC struct {
+	Issuer       string `toml:"issuer"`
+	ClientID     string `toml:"client_id"`
+	ClientSecret string `toml:"client_secret"`
+}
+
+// Legal holds the versions of the published terms of use and privacy policy. Publishing a new
+// version asks every user to accept it again.
+type Legal struct {
+	TermsVersion         string `toml:"terms_version"`
+	PrivacyPolicyVersion string `toml:"privacy_policy_version"`
+}
+
 type Database struct {
 	Driver   string `toml:"driver"`
 	Host     string `toml:"host"`
@@ -121,6 +238,32 @@ func Connect(cfg *Config) error {
 	return nil
 }
 
+// noDirListing hides the directories of a file system, so that the file server only answers
+// for files whose names are known instead of listing every product image and avatar
+type noDirListing struct {
+	fs http.FileSystem
+}
+
+func (n noDirListing) Open(name string) (http.File, error) {
+	file, err := n.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if info.IsDir() {
+		file.Close()
+		return nil, os.ErrNotExist
+	}
+
+	return file, nil
+}
+
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -3,28 +3,123 @@ package repositories
 import (
 	"cachacariaapi/domain/entities"
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type AuthRepository interface {
-	AddUser(ctx context.Context, user *entities.User) (int64, error)
+	AddUser(ctx context.Context, user *entities.User, consents []entities.Consent) (int64, error)
 	GetUserByEmail(ctx context.Context, email string) (*entities.User, error)
 	GetUserByPhone(ctx context.Context, phone string) (*entities.User, error)
 	GetUserByID(ctx context.Context, id int64) (*entities.User, error)
 	GetUserByUUID(ctx context.Context, uuid uuid.UUID) (*entities.User, error)
 	DeleteUser(ctx context.Context, id int) error
-	UpdateUserPassword(ctx context.Context, userID int64, newPassword string) error
+	UpdateUserPassword(ctx context.Context, userID int64, newPassword string, notification *entities.Email) error
+	AddPasswordReset(ctx context.Context, reset *entities.PasswordReset, notification entities.Email) (int64, error)
+	GetPasswordResetByHash(ctx context.Context, hash string) (*entities.PasswordReset, error)
+	ResetPassword(ctx context.Context, reset *entities.PasswordReset, newPassword string, notification *entities.Email) (bool, error)
+	AddEmailVerification(ctx context.Context, verification *entities.EmailVerification, notification entities.Email) (int64, error)
+	GetEmailVerificationByHash(ctx context.Context, hash string) (*entities.EmailVerification, error)
+	GetLatestEmailVerification(ctx context.Context, userID int64) (*entities.EmailVerification, error)
+	VerifyEmail(ctx context.Context, verification *entities.EmailVerification, notification *entities.Email) (bool, error)
+	GetTwoFactor(ctx context.Context, userID int64) (*entities.TwoFactor, error)
+	SaveTwoFactorSecret(ctx context.Context, userID int64, secret string) error
+	EnableTwoFactor(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) (bool, error)
+	UseTwoFactorStep(ctx context.Context, userID int64, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes []string) error
+	DisableTwoFactor(ctx context.Context, userID int64) error
+}
+
+type TokenRepository interface {
+	AddRefreshToken(ctx context.Context, token *entities.RefreshToken) (int64, error)
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*entities.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, old *entities.RefreshToken, token *entities.RefreshToken) error
+	RevokeTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeUserTokens(ctx context.Context, userID int64) error
+	RevokeAccessToken(ctx context.Context, tokenID uuid.UUID, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, tokenID, sessionID uuid.UUID) (bool, error)
+	DeleteExpiredTokens(ctx context.Context, now time.Time) error
+	AddSession(ctx context.Context, session *entities.Session) error
+	GetSession(ctx context.Context, sessionID uuid.UUID) (*entities.Session, error)
+	GetActiveSessions(ctx context.Context, userID int64, now time.Time) ([]entities.Session, error)
+	TouchSession(ctx context.Context, sessionID uuid.UUID, ipAddress string) error
+}
+
+type LoginAttemptRepository interface {
+	AddLoginFailure(ctx context.Context, email, ipAddress string, notification *entities.Email) error
+	GetEmailFailures(ctx context.Context, email string, since time.Time) (*entities.LoginFailures, error)
+	GetIPFailures(ctx context.Context, ipAddress string, since time.Time) (*entities.LoginFailures, error)
+	ClearEmailFailures(ctx context.Context, email string) error
+	DeleteLoginFailuresBefore(ctx context.Context, before time.Time) error
+}
+
+type OIDCRepository interface {
+	AddState(ctx context.Context, state *entities.OIDCState) error
+	ConsumeState(ctx context.Context, stateHash string) (*entities.OIDCState, error)
+	DeleteExpiredStates(ctx context.Context, now time.Time) error
+	GetIdentity(ctx context.Context, provider, subject string) (*entities.UserIdentity, error)
+	AddIdentity(ctx context.Context, identity *entities.UserIdentity) (int64, error)
+	GetIdentitiesByUser(ctx context.Context, userID int64) ([]entities.UserIdentity, error)
+}
+
+type APIKeyRepository interface {
+	AddAPIKey(ctx context.Context, key *entities.APIKey) (int64, error)
+	GetAPIKeysByUser(ctx context.Context, userID int64) ([]entities.APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error)
+	CountActiveAPIKeys(ctx context.Context, userID int64, now time.Time) (int, error)
+	RevokeAPIKey(ctx context.Context, id, userID int64) (bool, error)
+	TouchAPIKey(ctx context.Context, id int64, ipAddress string) error
+}
+
+type ConsentRepository interface {
+	AddConsents(ctx context.Context, userID int64, consents []entities.Consent) error
+	GetCurrentConsents(ctx context.Context, userID int64) ([]entities.Consent, error)
+	GetConsentHistory(ctx context.Context, userID int64) ([]entities.Consent, error)
+	HasConsent(ctx context.Context, userID int64, consentType entities.ConsentType) (bool, error)
+}
+
+type EmailOutboxRepository interface {
+	AddEmail(ctx context.Context, email entities.Email) error
+	ClaimDueEmails(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]entities.OutboxEmail, error)
+	MarkSent(ctx context.Context, id int64, sentAt time.Time) error
+	MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt *time.Time) error
+	GetEmails(ctx context.Context, status entities.OutboxStatus, limit, offset int) ([]entities.OutboxEmail, int, error)
+	RetryEmail(ctx context.Context, id int64, now time.Time) (bool, error)
+	DeleteSentEmails(ctx context.Context, before time.Time) error
+}
+
+type NotificationRepository interface {
+	AddNotification(ctx context.Context, notification *entities.Notification, email *entities.Email) (int64, error)
+	GetNotifications(ctx context.Context, userID int64, unreadOnly bool, limit, offset int) ([]entities.Notification, int, error)
+	GetNotificationsByUser(ctx context.Context, userID int64) ([]entities.Notification, error)
+	CountUnread(ctx context.Context, userID int64) (int, error)
+	MarkRead(ctx context.Context, userID, id int64, readAt time.Time) (bool, error)
+	MarkAllRead(ctx context.Context, userID int64, readAt time.Time) error
+	DeleteReadNotifications(ctx context.Context, before time.Time) error
+	GetPreferences(ctx context.Context, userID int64) ([]entities.NotificationPreference, error)
+	SetPreferences(ctx context.Context, userID int64, preferences []entities.NotificationPreference) error
 }
 
 type UserRepository interface {
-	GetAll() ([]entities.User, error)
+	Search(ctx context.Context, filter entities.UserFilter) ([]entities.User, int, error)
 	Add(user entities.User) error
-	Delete(userId int64) error
+	Delete(ctx context.Context, userId int64) error
 	FindByEmail(email string) (*entities.User, error)
 	FindByPhone(phone string) (*entities.User, error)
 	FindById(userid int64) (*entities.User, error)
-	Update(user entities.User) error
+	Update(ctx context.Context, user entities.User, emailChanged bool, verification *entities.EmailVerification, notification *entities.Email) error
+	FindByCPF(ctx context.Context, cpf string) (*entities.User, error)
+	ConfirmAge(ctx context.Context, confirmation *entities.AgeConfirmation) error
+	UpdateRole(ctx context.Context, userID int64, role entities.Role) error
+	CountByRole(ctx context.Context, role entities.Role) (int, error)
+	Disable(ctx context.Context, userID int64) (bool, error)
+	Enable(ctx context.Context, userID int64) (bool, error)
+	GetAgeConfirmations(ctx context.Context, userID int64) ([]entities.AgeConfirmation, error)
+	Anonymize(ctx context.Context, userID int64, email string) error
+	UpdateProfile(ctx context.Context, user entities.User) error
+	UpdateAvatar(ctx context.Context, userID int64, avatar string) error
 }
 
 type ProductRepository interface {
@@ -35,6 +130,14 @@ type ProductRepository interface {
 	DeleteProduct(id int64) error
 	UpdateProduct(id int64, product entities.UpdateProductRequest) error
 	DecrementStock(id int64, quantity int) error
+	AddProductPrice(ctx context.Context, price *entities.ProductPrice) (int64, error)
+	GetProductPrices(ctx context.Context, productID int64) ([]entities.ProductPrice, error)
+	GetProductPrice(ctx context.Context, id int64) (*entities.ProductPrice, error)
+	CancelProductPrice(ctx context.Context, id int64) error
+	GetPricesToStart(ctx context.Context, now time.Time) ([]entities.ProductPrice, error)
+	GetSalesToEnd(ctx context.Context, now time.Time) ([]entities.ProductPrice, error)
+	StartProductPrice(ctx context.Context, price entities.ProductPrice) error
+	EndProductSale(ctx context.Context, price entities.ProductPrice) error
 }
 
 type CartRepository interface {
@@ -43,10 +146,64 @@ type CartRepository interface {
 	UpdateCartItem(ctx context.Context, userID, productID int64, quantity int) error
 	DeleteCartItem(ctx context.Context, userID, productID int64) error
 	ClearCart(ctx context.Context, userID int64) error
+	SetCartCoupon(ctx context.Context, userID, couponID int64) error
+	GetCartCoupon(ctx context.Context, userID int64) (*entities.Coupon, error)
+	RemoveCartCoupon(ctx context.Context, userID int64) error
+	Checkout(
+		ctx context.Context,
+		userID int64,
+		cart *entities.Cart,
+		address entities.ShippingAddress,
+		confirmation func(orderID int64) *entities.Email,
+	) (int64, error)
+	GetAbandonedCarts(ctx context.Context, before time.Time, maxReminders, limit int) ([]entities.AbandonedCart, error)
+	AddCartReminder(ctx context.Context, cart entities.AbandonedCart, sentAt time.Time, email *entities.Email) error
+	AddCartReminderFailure(ctx context.Context, cart entities.AbandonedCart, failedAt time.Time) error
+}
+
+type CouponRepository interface {
+	AddCoupon(ctx context.Context, coupon *entities.Coupon) (int64, error)
+	GetCoupons(ctx context.Context) ([]entities.Coupon, error)
+	GetCouponByID(ctx context.Context, id int64) (*entities.Coupon, error)
+	GetCouponByCode(ctx context.Context, code string) (*entities.Coupon, error)
+	DisableCoupon(ctx context.Context, id int64) error
+	CountUserUsages(ctx context.Context, couponID, userID int64) (int, error)
+}
+
+type PromotionRepository interface {
+	AddPromotion(ctx context.Context, promotion *entities.Promotion) (int64, error)
+	GetPromotions(ctx context.Context) ([]entities.Promotion, error)
+	GetActivePromotions(ctx context.Context, now time.Time) ([]entities.Promotion, error)
+	GetPromotionByID(ctx context.Context, id int64) (*entities.Promotion, error)
+	DisablePromotion(ctx context.Context, id int64) error
 }
 
 type OrderRepository interface {
 	CreateOrder(ctx context.Context, userID int64) (int64, error)
 	AddOrderItem(ctx context.Context, orderID, productID int64, quantity int) error
 	GetOrders(ctx context.Context, userID int64) ([]entities.Order, error)
+	GetAllOrders(ctx context.Context) ([]entities.Order, error)
+	GetOrder(ctx context.Context, orderID int64) (*entities.Order, error)
+	UpdateOrderStatus(ctx context.Context, order *entities.Order, status entities.OrderStatus, trackingCode string, notification *entities.Email) (bool, []int64, error)
+	HasPurchasedProduct(ctx context.Context, userID, productID int64) (bool, error)
+}
+
+type WishlistRepository interface {
+	AddToWishlist(ctx context.Context, userID, productID int64) error
+	GetWishlistItems(ctx context.Context, userID int64) ([]entities.WishlistItem, error)
+	IsInWishlist(ctx context.Context, userID, productID int64) (bool, error)
+	GetWishlistUserIDs(ctx context.Context, productID int64) ([]int64, error)
+	RemoveFromWishlist(ctx context.Context, userID, productID int64) error
+}
+
+type ReviewRepository interface {
+	AddReview(ctx context.Context, review *entities.Review) (int64, error)
+	GetReview(ctx context.Context, id int64) (*entities.Review, error)
+	GetUserReview(ctx context.Context, userID, productID int64) (*entities.Review, error)
+	GetProductReviews(ctx context.Context, productID int64, status entities.ReviewStatus, limit, offset int) ([]entities.Review, int, error)
+	GetReviewsByStatus(ctx context.Context, status entities.ReviewStatus) ([]entities.Review, error)
+	GetReviewsByUser(ctx context.Context, userID int64) ([]entities.Review, error)
+	UpdateReview(ctx context.Context, review *entities.Review) error
+	UpdateReviewStatus(ctx context.Context, id int64, status entities.ReviewStatus) error
+	DeleteReview(ctx context.Context, id int64) error
 }
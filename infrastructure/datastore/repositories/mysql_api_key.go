@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/infrastructure/datastore"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type MySQLAPIKeyRepository struct {
+	DB *sql.DB
+}
+
+func NewMySQLAPIKeyRepository(db *sql.DB) repositories.APIKeyRepository {
+	return &MySQLAPIKeyRepository{DB: db}
+}
+
+func (r *MySQLAPIKeyRepository) AddAPIKey(ctx context.Context, key *entities.APIKey) (int64, error) {
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at) VALUES (?, ?, ?, ?, ?, ?)
+	`, key.UserID, key.Name, key.Prefix, key.KeyHash, strings.Join(scopes, ","), key.ExpiresAt)
+	if err != nil {
+		return 0, errors.Join(fmt.Errorf("failed to add api key"), err)
+	}
+
+	return res.LastInsertId()
+}
+
+func (r *MySQLAPIKeyRepository) GetAPIKeysByUser(ctx context.Context, userID int64) ([]entities.APIKey, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT k.id, k.user_id, k.name, k.prefix, k.key_hash, k.scopes, k.last_used_at, k.last_used_ip,
+		       k.expires_at, k.revoked_at, k.created_at, u.role, u.status_code
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.user_id = ?
+		ORDER BY k.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query api keys"), err)
+	}
+	defer rows.Close()
+
+	keys := make([]entities.APIKey, 0)
+	for rows.Next() {
+		var key entities.APIKey
+		if err = scanAPIKey(rows, &key); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan api key"), err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to scan api keys"), err)
+	}
+
+	return keys, nil
+}
+
+// GetAPIKeyByPrefix returns the key along with the current role and status of its owner
+func (r *MySQLAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error) {
+	row := r.DB.QueryRowContext(ctx, `
+		SELECT k.id, k.user_id, k.name, k.prefix, k.key_hash, k.scopes, k.last_used_at, k.last_used_ip,
+		       k.expires_at, k.revoked_at, k.created_at, u.role, u.status_code
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.prefix = ?
+	`, prefix)
+
+	var key entities.APIKey
+	if err := scanAPIKey(row, &key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, errors.Join(fmt.Errorf("failed to scan api key"), err)
+	}
+
+	return &key, nil
+}
+
+func (r *MySQLAPIKeyRepository) CountActiveAPIKeys(ctx context.Context, userID int64, now time.Time) (int, error) {
+	var count int
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM api_keys
+		WHERE user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)
+	`, userID, now).Scan(&count)
+	if err != nil {
+		return 0, errors.Join(fmt.Errorf("failed to count api keys"), err)
+	}
+
+	return count, nil
+}
+
+// RevokeAPIKey revokes the key if it belongs to the user and is not revoked yet
+func (r *MySQLAPIKeyRepository) RevokeAPIKey(ctx context.Context, id, userID int64) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND revoked_at IS NULL
+	`, id, userID)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to revoke api key"), err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check revoked api key"), err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *MySQLAPIKeyRepository) TouchAPIKey(ctx context.Context, id int64, ipAddress string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP, last_used_ip = ? WHERE id = ?
+	`, ipAddress, id)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to update api key last use"), err)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row rowScanner, key *entities.APIKey) error {
+	var scopes string
+	var lastUsedAt, expiresAt, revokedAt sql.NullTime
+	var lastUsedIP sql.NullString
+
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&scopes,
+		&lastUsedAt,
+		&lastUsedIP,
+		&expiresAt,
+		&revokedAt,
+		&key.CreatedAt,
+		&key.OwnerRole,
+		&key.OwnerStatus,
+	)
+	if err != nil {
+		return err
+	}
+
+	key.Scopes = []entities.Permission{}
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope != "" {
+			key.Scopes = append(key.Scopes, entities.Permission(scope))
+		}
+	}
+
+	key.LastUsedAt = nullTimePtr(lastUsedAt)
+	key.LastUsedIP = lastUsedIP.String
+	key.ExpiresAt = nullTimePtr(expiresAt)
+	key.RevokedAt = nullTimePtr(revokedAt)
+
+	return nil
+}
@@ -21,38 +21,65 @@ func NewMySQLAuthRepository(db *sql.DB) repositories.AuthRepository {
 	}
 }
 
-func (r MySQLAuthRepository) AddUser(ctx context.Context, user *entities.User) (int64, error) {
+// AddUser stores the user along with the consents given at registration
+func (r MySQLAuthRepository) AddUser(ctx context.Context, user *entities.User, consents []entities.Consent) (int64, error) {
 	const query = `
-		INSERT INTO users (uuid, email, password, phone, is_adm) VALUES (?, ?, ?, ?, ?)
+		INSERT INTO users (uuid, email, verified_email, password, name, phone, role, status_code, locale)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?)
 	`
 
-	result, err := r.db.ExecContext(
+	if user.Locale == "" {
+		user.Locale = entities.DefaultLocale
+	}
+
+	var verifiedEmail *string
+	if user.IsVerified() {
+		verifiedEmail = &user.Email
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
 		ctx,
 		query,
 		user.UUID,
 		user.Email,
+		verifiedEmail,
 		user.Password,
+		user.Name,
 		user.Phone,
-		user.IsAdm,
+		user.Role,
+		user.Status,
+		user.Locale,
 	)
 	if err != nil {
 		return -1, errors.Join(fmt.Errorf("failed to add user"), err)
 	}
 
-	return result.LastInsertId()
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to get user id"), err)
+	}
+
+	if err = insertConsents(ctx, tx, id, consents); err != nil {
+		return -1, err
+	}
+
+	return id, tx.Commit()
 }
 
 func (r MySQLAuthRepository) GetUserByEmail(
 	ctx context.Context,
 	email string,
 ) (*entities.User, error) {
-	const query = `
-		SELECT id, uuid, email, password, phone, is_adm FROM users WHERE email = ?
-	`
+	const query = "SELECT " + userColumns + " FROM users WHERE email = ?"
 
 	var user entities.User
-	err := r.db.QueryRowContext(ctx, query, email).
-		Scan(&user.ID, &user.UUID, &user.Email, &user.Password, &user.Phone, &user.IsAdm)
+	err := scanUser(r.db.QueryRowContext(ctx, query, email), &user)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -68,13 +95,10 @@ func (r MySQLAuthRepository) GetUserByPhone(
 	ctx context.Context,
 	phone string,
 ) (*entities.User, error) {
-	const query = `
-		SELECT id, uuid, email, password, phone, is_adm FROM users WHERE phone = ?
-	`
+	const query = "SELECT " + userColumns + " FROM users WHERE phone = ?"
 
 	var user entities.User
-	err := r.db.QueryRowContext(ctx, query, phone).
-		Scan(&user.ID, &user.UUID, &user.Email, &user.Password, &user.Phone, &user.IsAdm)
+	err := scanUser(r.db.QueryRowContext(ctx, query, phone), &user)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -87,13 +111,10 @@ func (r MySQLAuthRepository) GetUserByPhone(
 }
 
 func (r MySQLAuthRepository) GetUserByID(ctx context.Context, id int64) (*entities.User, error) {
-	const query = `
-		SELECT id, uuid, email, password, phone, is_adm FROM users WHERE id = ?
-	`
+	const query = "SELECT " + userColumns + " FROM users WHERE id = ?"
 
 	var user entities.User
-	err := r.db.QueryRowContext(ctx, query, id).
-		Scan(&user.ID, &user.UUID, &user.Email, &user.Password, &user.Phone, &user.IsAdm)
+	err := scanUser(r.db.QueryRowContext(ctx, query, id), &user)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -109,13 +130,10 @@ func (r MySQLAuthRepository) GetUserByUUID(
 	ctx context.Context,
 	uuid uuid.UUID,
 ) (*entities.User, error) {
-	const query = `
-		SELECT id, uuid, email, password, phone, is_adm FROM users WHERE uuid = ?
-	`
+	const query = "SELECT " + userColumns + " FROM users WHERE uuid = ?"
 
 	var user entities.User
-	err := r.db.QueryRowContext(ctx, query, uuid).
-		Scan(&user.ID, &user.UUID, &user.Email, &user.Password, &user.Phone, &user.IsAdm)
+	err := scanUser(r.db.QueryRowContext(ctx, query, uuid), &user)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -140,15 +158,441 @@ func (r MySQLAuthRepository) DeleteUser(ctx context.Context, id int) error {
 	return nil
 }
 
-func (r MySQLAuthRepository) UpdateUserPassword(ctx context.Context, userID int64, newPassword string) error {
+// UpdateUserPassword changes the password and queues the notification of the change, if any
+func (r MySQLAuthRepository) UpdateUserPassword(ctx context.Context, userID int64, newPassword string, notification *entities.Email) error {
 	const query = `
 		UPDATE users SET password = ? WHERE id = ?
 	`
 
-	_, err := r.db.ExecContext(ctx, query, newPassword, userID)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, query, newPassword, userID)
 	if err != nil {
 		return errors.Join(fmt.Errorf("failed to execute udpate user password query"), err)
 	}
 
+	if notification != nil {
+		if err = insertOutboxEmail(ctx, tx, *notification); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AddPasswordReset stores the reset token and queues the email with its link
+func (r MySQLAuthRepository) AddPasswordReset(ctx context.Context, reset *entities.PasswordReset, notification entities.Email) (int64, error) {
+	const query = `
+		INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES (?, ?, ?)
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, reset.UserID, reset.TokenHash, reset.ExpiresAt)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to add password reset"), err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to get password reset id"), err)
+	}
+
+	if err = insertOutboxEmail(ctx, tx, notification); err != nil {
+		return -1, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (r MySQLAuthRepository) GetPasswordResetByHash(ctx context.Context, hash string) (*entities.PasswordReset, error) {
+	const query = `
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at FROM password_resets WHERE token_hash = ?
+	`
+
+	var reset entities.PasswordReset
+	var usedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, hash).
+		Scan(&reset.ID, &reset.UserID, &reset.TokenHash, &reset.ExpiresAt, &usedAt, &reset.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, errors.Join(fmt.Errorf("failed to query/scan password reset"), err)
+	}
+
+	reset.UsedAt = nullTimePtr(usedAt)
+	return &reset, nil
+}
+
+// ResetPassword consumes the reset token, updates the user password and queues the
+// notification of the change, if any. Every other pending reset token of the user is consumed
+// too. It returns false if the token was already used.
+func (r MySQLAuthRepository) ResetPassword(ctx context.Context, reset *entities.PasswordReset, newPassword string, notification *entities.Email) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE password_resets SET used_at = CURRENT_TIMESTAMP WHERE id = ? AND used_at IS NULL
+	`, reset.ID)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to use password reset"), err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check password reset"), err)
+	}
+
+	if affected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE password_resets SET used_at = CURRENT_TIMESTAMP WHERE user_id = ? AND used_at IS NULL
+	`, reset.UserID)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to invalidate password resets"), err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE users SET password = ? WHERE id = ?`, newPassword, reset.UserID)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to update user password"), err)
+	}
+
+	if notification != nil {
+		if err = insertOutboxEmail(ctx, tx, *notification); err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// AddEmailVerification stores the verification token and queues the email with its link
+func (r MySQLAuthRepository) AddEmailVerification(ctx context.Context, verification *entities.EmailVerification, notification entities.Email) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	id, err := insertEmailVerification(ctx, tx, verification)
+	if err != nil {
+		return -1, err
+	}
+
+	if err = insertOutboxEmail(ctx, tx, notification); err != nil {
+		return -1, err
+	}
+
+	return id, tx.Commit()
+}
+
+func insertEmailVerification(ctx context.Context, db execer, verification *entities.EmailVerification) (int64, error) {
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO email_verifications (user_id, token_hash, expires_at) VALUES (?, ?, ?)
+	`, verification.UserID, verification.TokenHash, verification.ExpiresAt)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to add email verification"), err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to get email verification id"), err)
+	}
+
+	return id, nil
+}
+
+func (r MySQLAuthRepository) GetEmailVerificationByHash(ctx context.Context, hash string) (*entities.EmailVerification, error) {
+	const query = `
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at FROM email_verifications WHERE token_hash = ?
+	`
+
+	return r.getEmailVerification(ctx, query, hash)
+}
+
+func (r MySQLAuthRepository) GetLatestEmailVerification(ctx context.Context, userID int64) (*entities.EmailVerification, error) {
+	const query = `
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at
+		FROM email_verifications
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+
+	return r.getEmailVerification(ctx, query, userID)
+}
+
+// VerifyEmail consumes the verification token, marks the user as verified and queues the
+// welcome email, if any, unless the user had already verified an email before, as when
+// confirming a changed address. Every other pending verification token of the user is consumed
+// too. It returns false if the token was already used or the user is not unverified, so that a
+// leftover link cannot enable a disabled or erased account.
+func (r MySQLAuthRepository) VerifyEmail(ctx context.Context, verification *entities.EmailVerification, notification *entities.Email) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	var verifiedBefore bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT verified_email IS NOT NULL FROM users WHERE id = ?
+	`, verification.UserID).Scan(&verifiedBefore)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, errors.Join(fmt.Errorf("failed to check previous email verifications"), err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE email_verifications SET used_at = CURRENT_TIMESTAMP WHERE id = ? AND used_at IS NULL
+	`, verification.ID)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to use email verification"), err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check email verification"), err)
+	}
+
+	if affected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE email_verifications SET used_at = CURRENT_TIMESTAMP WHERE user_id = ? AND used_at IS NULL
+	`, verification.UserID)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to invalidate email verifications"), err)
+	}
+
+	result, err = tx.ExecContext(ctx, `
+		UPDATE users SET status_code = ?, verified_email = email WHERE id = ? AND status_code = ?
+	`, entities.UserStatusVerified, verification.UserID, entities.UserStatusUnverified)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to update user status"), err)
+	}
+
+	affected, err = result.RowsAffected()
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check verified user"), err)
+	}
+
+	if affected == 0 {
+		return false, nil
+	}
+
+	if notification != nil && !verifiedBefore {
+		if err = insertOutboxEmail(ctx, tx, *notification); err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+func (r MySQLAuthRepository) getEmailVerification(ctx context.Context, query string, args ...any) (*entities.EmailVerification, error) {
+	var verification entities.EmailVerification
+	var usedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&verification.ID,
+		&verification.UserID,
+		&verification.TokenHash,
+		&verification.ExpiresAt,
+		&usedAt,
+		&verification.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, errors.Join(fmt.Errorf("failed to query/scan email verification"), err)
+	}
+
+	verification.UsedAt = nullTimePtr(usedAt)
+	return &verification, nil
+}
+
+func (r MySQLAuthRepository) GetTwoFactor(ctx context.Context, userID int64) (*entities.TwoFactor, error) {
+	const query = `
+		SELECT user_id, secret, enabled_at, last_used_step, created_at
+		FROM user_two_factor
+		WHERE user_id = ?
+	`
+
+	var twoFactor entities.TwoFactor
+	var enabledAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&twoFactor.UserID,
+		&twoFactor.Secret,
+		&enabledAt,
+		&twoFactor.LastUsedStep,
+		&twoFactor.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, errors.Join(fmt.Errorf("failed to query/scan two factor"), err)
+	}
+
+	twoFactor.EnabledAt = nullTimePtr(enabledAt)
+	return &twoFactor, nil
+}
+
+// SaveTwoFactorSecret stores a pending TOTP secret, replacing any previous pending one. Enabled
+// secrets are left untouched.
+func (r MySQLAuthRepository) SaveTwoFactorSecret(ctx context.Context, userID int64, secret string) error {
+	const query = `
+		INSERT INTO user_two_factor (user_id, secret) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE
+			secret = IF(enabled_at IS NULL, VALUES(secret), secret),
+			last_used_step = IF(enabled_at IS NULL, 0, last_used_step),
+			created_at = IF(enabled_at IS NULL, CURRENT_TIMESTAMP, created_at)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, secret); err != nil {
+		return errors.Join(fmt.Errorf("failed to save two factor secret"), err)
+	}
+
+	return nil
+}
+
+// EnableTwoFactor enables the pending secret of the user, recording the time step of the code
+// that confirmed it, and replaces the recovery codes. It returns false if 2FA was already enabled.
+func (r MySQLAuthRepository) EnableTwoFactor(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE user_two_factor SET enabled_at = CURRENT_TIMESTAMP, last_used_step = ?
+		WHERE user_id = ? AND enabled_at IS NULL
+	`, step, userID)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to enable two factor"), err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check two factor"), err)
+	}
+
+	if affected == 0 {
+		return false, nil
+	}
+
+	if err = replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// UseTwoFactorStep records the time step of an accepted TOTP code. It returns false if a code of
+// the same or a later step was already used, so a code cannot be replayed.
+func (r MySQLAuthRepository) UseTwoFactorStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_two_factor SET last_used_step = ?
+		WHERE user_id = ? AND enabled_at IS NOT NULL AND last_used_step < ?
+	`, step, userID, step)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to update two factor step"), err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check two factor step"), err)
+	}
+
+	return affected > 0, nil
+}
+
+// UseRecoveryCode consumes a recovery code. It returns false if the code does not exist or was
+// already used.
+func (r MySQLAuthRepository) UseRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE recovery_codes SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`, userID, hash)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to use recovery code"), err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check recovery code"), err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r MySQLAuthRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	if err = replaceRecoveryCodes(ctx, tx, userID, hashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DisableTwoFactor removes the TOTP secret and the recovery codes of the user
+func (r MySQLAuthRepository) DisableTwoFactor(ctx context.Context, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return errors.Join(fmt.Errorf("failed to delete recovery codes"), err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM user_two_factor WHERE user_id = ?`, userID); err != nil {
+		return errors.Join(fmt.Errorf("failed to delete two factor"), err)
+	}
+
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, db execer, userID int64, hashes []string) error {
+	if _, err := db.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return errors.Join(fmt.Errorf("failed to delete recovery codes"), err)
+	}
+
+	for _, hash := range hashes {
+		_, err := db.ExecContext(ctx, `INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)`, userID, hash)
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to add recovery code"), err)
+		}
+	}
+
 	return nil
 }
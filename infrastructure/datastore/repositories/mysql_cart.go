@@ -62,7 +62,7 @@ func (repo *MySQLCartRepository) GetCartItems(ctx context.Context, userID int64)
 	rows, err := repo.DB.QueryContext(ctx, `
         SELECT 
             cp.id, cp.user_id, cp.product_id, cp.quantity, cp.created_at, cp.modified_at,
-            p.id, p.name, p.description, p.price, p.stock,
+            p.id, p.category_id, p.name, p.description, p.price, p.stock,
             GROUP_CONCAT(pp.filename) AS photos
         FROM carts_products cp
         JOIN products p ON cp.product_id = p.id
@@ -81,14 +81,19 @@ func (repo *MySQLCartRepository) GetCartItems(ctx context.Context, userID int64)
 	for rows.Next() {
 		var item entities.CartItem
 		var product entities.Product
+		var categoryID sql.NullInt64
 		var photosStr sql.NullString
 
-		err = rows.Scan(&item.ID, &item.UserID, &item.ProductID, &item.Quantity, &item.CreatedAt, &item.ModifiedAt, &product.ID, &product.Name, &product.Description, &product.Price, &product.Stock, &photosStr)
+		err = rows.Scan(&item.ID, &item.UserID, &item.ProductID, &item.Quantity, &item.CreatedAt, &item.ModifiedAt, &product.ID, &categoryID, &product.Name, &product.Description, &product.Price, &product.Stock, &photosStr)
 
 		if err != nil {
 			return nil, errors.Join(fmt.Errorf("failed to scan cart"), err)
 		}
 
+		if categoryID.Valid {
+			product.CategoryID = &categoryID.Int64
+		}
+
 		if photosStr.Valid {
 			product.Photos = strings.Split(photosStr.String, ",")
 		} else {
@@ -134,57 +139,190 @@ func (repo *MySQLCartRepository) ClearCart(ctx context.Context, userID int64) er
 	}
 	defer tx.Rollback()
 
-	rows, err := tx.QueryContext(ctx, `
-        SELECT product_id, quantity
-        FROM carts_products
+	_, err = tx.ExecContext(ctx, `
+        DELETE FROM carts_products
         WHERE user_id = ?;
     `, userID)
 	if err != nil {
-		return errors.Join(fmt.Errorf("failed to query cart before checkout"), err)
+		return errors.Join(fmt.Errorf("failed to clear cart"), err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        DELETE FROM carts_coupons
+        WHERE user_id = ?;
+    `, userID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to remove cart coupon"), err)
 	}
-	defer rows.Close()
 
-	items := []struct {
-		ProductID int64
-		Quantity  int
-	}{}
+	if err = deleteCartReminders(ctx, tx, userID); err != nil {
+		return err
+	}
 
-	for rows.Next() {
-		var item struct {
-			ProductID int64
-			Quantity  int
-		}
-		if err = rows.Scan(&item.ProductID, &item.Quantity); err != nil {
-			return errors.Join(fmt.Errorf("failed to scan cart item"), err)
+	return tx.Commit()
+}
+
+func (repo *MySQLCartRepository) SetCartCoupon(ctx context.Context, userID, couponID int64) error {
+	_, err := repo.DB.ExecContext(ctx, `
+        INSERT INTO carts_coupons (user_id, coupon_id)
+        VALUES (?, ?)
+        ON DUPLICATE KEY UPDATE coupon_id = VALUES(coupon_id), created_at = CURRENT_TIMESTAMP;
+    `, userID, couponID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to set cart coupon"), err)
+	}
+
+	return nil
+}
+
+func (repo *MySQLCartRepository) GetCartCoupon(ctx context.Context, userID int64) (*entities.Coupon, error) {
+	row := repo.DB.QueryRowContext(ctx, `
+        SELECT `+couponColumns+`
+        FROM carts_coupons cc
+        JOIN coupons c ON c.id = cc.coupon_id
+        WHERE cc.user_id = ?;
+    `, userID)
+
+	var coupon entities.Coupon
+	if err := scanCoupon(row, &coupon); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
 		}
-		items = append(items, item)
+
+		return nil, errors.Join(fmt.Errorf("failed to scan cart coupon"), err)
+	}
+
+	if err := loadCouponRestrictions(ctx, repo.DB, &coupon); err != nil {
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+func (repo *MySQLCartRepository) RemoveCartCoupon(ctx context.Context, userID int64) error {
+	_, err := repo.DB.ExecContext(ctx, `
+        DELETE FROM carts_coupons
+        WHERE user_id = ?;
+    `, userID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to remove cart coupon"), err)
+	}
+
+	return nil
+}
+
+// Checkout turns the given priced cart into an order shipped to the address in a single
+// transaction, decrementing the products stock and consuming one usage of the applied coupon,
+// if any. The confirmation email, rendered by confirmation once the order ID is known, is queued
+// in the same transaction; confirmation may return nil to skip it.
+//
+// Returns entities.ErrCouponUnavailable if the coupon reached its limits in the meantime,
+// or entities.ErrInsufficientStock if any product ran out of stock.
+func (repo *MySQLCartRepository) Checkout(
+	ctx context.Context,
+	userID int64,
+	cart *entities.Cart,
+	address entities.ShippingAddress,
+	confirmation func(orderID int64) *entities.Email,
+) (int64, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to begin transaction"), err)
 	}
+	defer tx.Rollback()
+
+	var couponID *int64
+	if cart.Coupon != nil {
+		var (
+			active         bool
+			startsAt       time.Time
+			expiresAt      time.Time
+			maxUses        int
+			maxUsesPerUser int
+			usedCount      int
+		)
+
+		err = tx.QueryRowContext(ctx, `
+			SELECT active, starts_at, expires_at, max_uses, max_uses_per_user, used_count
+			FROM coupons
+			WHERE id = ? FOR UPDATE;
+		`, cart.Coupon.ID).Scan(&active, &startsAt, &expiresAt, &maxUses, &maxUsesPerUser, &usedCount)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return -1, entities.ErrCouponUnavailable
+			}
+
+			return -1, errors.Join(fmt.Errorf("failed to lock coupon"), err)
+		}
+
+		now := time.Now()
+		if !active || now.Before(startsAt) || !now.Before(expiresAt) || (maxUses > 0 && usedCount >= maxUses) {
+			return -1, entities.ErrCouponUnavailable
+		}
+
+		if maxUsesPerUser > 0 {
+			var userUsages int
+			err = tx.QueryRowContext(ctx, `
+				SELECT COUNT(*) FROM coupons_usages
+				WHERE coupon_id = ? AND user_id = ? FOR UPDATE;
+			`, cart.Coupon.ID, userID).Scan(&userUsages)
+			if err != nil {
+				return -1, errors.Join(fmt.Errorf("failed to count coupon usages"), err)
+			}
+
+			if userUsages >= maxUsesPerUser {
+				return -1, entities.ErrCouponUnavailable
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE coupons SET used_count = used_count + 1 WHERE id = ?;
+		`, cart.Coupon.ID)
+		if err != nil {
+			return -1, errors.Join(fmt.Errorf("failed to consume coupon"), err)
+		}
 
-	if rows.Err() != nil {
-		return rows.Err()
+		couponID = &cart.Coupon.ID
 	}
 
 	res, err := tx.ExecContext(ctx, `
-		INSERT INTO orders (user_id)
-		VALUES (?);
-	`, userID)
+		INSERT INTO orders (
+			user_id, coupon_id, subtotal, discount, total, shipping_name, shipping_zip_code, shipping_street,
+			shipping_number, shipping_complement, shipping_district, shipping_city, shipping_state
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?);
+	`,
+		userID,
+		couponID,
+		cart.Subtotal,
+		cart.Discount,
+		cart.Total,
+		address.RecipientName,
+		address.ZipCode,
+		address.Street,
+		address.Number,
+		address.Complement,
+		address.District,
+		address.City,
+		address.State,
+	)
 	if err != nil {
-		return errors.Join(fmt.Errorf("failed to create order"), err)
+		return -1, errors.Join(fmt.Errorf("failed to create order"), err)
 	}
 
 	orderID, err := res.LastInsertId()
 	if err != nil {
-		return errors.Join(fmt.Errorf("failed to retrieve order ID"), err)
+		return -1, errors.Join(fmt.Errorf("failed to retrieve order ID"), err)
 	}
 
-	for _, it := range items {
+	for _, it := range cart.Items {
 		_, err = tx.ExecContext(ctx, `
-		INSERT INTO order_items (order_id, product_id, quantity)
-		VALUES (?, ?, ?);
-		`, orderID, it.ProductID, it.Quantity)
+		INSERT INTO order_items (order_id, product_id, quantity, price)
+		VALUES (?, ?, ?, ?);
+		`, orderID, it.ProductID, it.Quantity, it.UnitPrice)
 
 		if err != nil {
-			return errors.Join(fmt.Errorf("failed to insert order item"), err)
+			return -1, errors.Join(fmt.Errorf("failed to insert order item"), err)
 		}
 
 		res, err = tx.ExecContext(ctx, `
@@ -194,14 +332,23 @@ func (repo *MySQLCartRepository) ClearCart(ctx context.Context, userID int64) er
 		`, it.Quantity, it.ProductID, it.Quantity)
 
 		if err != nil {
-			return errors.Join(fmt.Errorf("failed to update stock"), err)
+			return -1, errors.Join(fmt.Errorf("failed to update stock"), err)
 		}
 
 		rowsAffected, _ := res.RowsAffected()
 		if rowsAffected == 0 {
-			return fmt.Errorf("insufficient stock for product_id=%d", it.ProductID)
+			return -1, errors.Join(fmt.Errorf("product_id=%d", it.ProductID), entities.ErrInsufficientStock)
 		}
+	}
 
+	if couponID != nil {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO coupons_usages (coupon_id, user_id, order_id)
+			VALUES (?, ?, ?);
+		`, *couponID, userID, orderID)
+		if err != nil {
+			return -1, errors.Join(fmt.Errorf("failed to register coupon usage"), err)
+		}
 	}
 
 	_, err = tx.ExecContext(ctx, `
@@ -209,12 +356,128 @@ func (repo *MySQLCartRepository) ClearCart(ctx context.Context, userID int64) er
         WHERE user_id = ?;
     `, userID)
 	if err != nil {
-		return errors.Join(fmt.Errorf("failed to clear cart"), err)
+		return -1, errors.Join(fmt.Errorf("failed to clear cart"), err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        DELETE FROM carts_coupons
+        WHERE user_id = ?;
+    `, userID)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to remove cart coupon"), err)
+	}
+
+	if err = deleteCartReminders(ctx, tx, userID); err != nil {
+		return -1, err
+	}
+
+	if email := confirmation(orderID); email != nil {
+		if err = insertOutboxEmail(ctx, tx, *email); err != nil {
+			return -1, err
+		}
+	}
+
+	return orderID, tx.Commit()
+}
+
+// GetAbandonedCarts returns the carts untouched since before that are due a reminder: those
+// never reminded since they were last modified, and those with fewer than maxReminders
+// reminders, the last one sent before before. Carts whose last reminder failed after before are
+// skipped, and the ones that failed earlier come last, so that they cannot fill the batch.
+func (repo *MySQLCartRepository) GetAbandonedCarts(ctx context.Context, before time.Time, maxReminders, limit int) ([]entities.AbandonedCart, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT c.user_id, c.modified_at, IF(r.cart_modified_at = c.modified_at, r.reminders_sent, 0)
+        FROM (
+            SELECT user_id, MAX(modified_at) AS modified_at
+            FROM carts_products
+            GROUP BY user_id
+        ) c
+        LEFT JOIN cart_reminders r ON r.user_id = c.user_id
+        WHERE c.modified_at < ?
+          AND (r.user_id IS NULL
+            OR r.cart_modified_at <> c.modified_at
+            OR (r.reminders_sent < ? AND (r.last_sent_at IS NULL OR r.last_sent_at < ?)))
+          AND (r.failed_at IS NULL OR r.failed_at < ?)
+        ORDER BY r.failed_at IS NOT NULL, c.modified_at
+        LIMIT ?;
+    `, before, maxReminders, before, before, limit)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query abandoned carts"), err)
+	}
+	defer rows.Close()
+
+	var carts []entities.AbandonedCart
+	for rows.Next() {
+		var cart entities.AbandonedCart
+		if err = rows.Scan(&cart.UserID, &cart.ModifiedAt, &cart.RemindersSent); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan abandoned cart"), err)
+		}
+
+		carts = append(carts, cart)
+	}
+
+	return carts, rows.Err()
+}
+
+// AddCartReminder counts a reminder for the cart, starting again from one when the cart was
+// modified since the previous reminder, and queues its email, if any, in the same transaction
+func (repo *MySQLCartRepository) AddCartReminder(ctx context.Context, cart entities.AbandonedCart, sentAt time.Time, email *entities.Email) error {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO cart_reminders (user_id, cart_modified_at, reminders_sent, last_sent_at)
+        VALUES (?, ?, 1, ?)
+        ON DUPLICATE KEY UPDATE
+            reminders_sent   = IF(cart_modified_at = VALUES(cart_modified_at), reminders_sent + 1, 1),
+            cart_modified_at = VALUES(cart_modified_at),
+            last_sent_at     = VALUES(last_sent_at),
+            failed_at        = NULL;
+    `, cart.UserID, cart.ModifiedAt, sentAt)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to record cart reminder"), err)
+	}
+
+	if email != nil {
+		if err = insertOutboxEmail(ctx, tx, *email); err != nil {
+			return err
+		}
 	}
 
 	return tx.Commit()
 }
 
+// AddCartReminderFailure records that the reminder of the cart could not be sent, without
+// counting it, so that the cart waits before being tried again
+func (repo *MySQLCartRepository) AddCartReminderFailure(ctx context.Context, cart entities.AbandonedCart, failedAt time.Time) error {
+	_, err := repo.DB.ExecContext(ctx, `
+        INSERT INTO cart_reminders (user_id, cart_modified_at, reminders_sent, failed_at)
+        VALUES (?, ?, 0, ?)
+        ON DUPLICATE KEY UPDATE failed_at = VALUES(failed_at);
+    `, cart.UserID, cart.ModifiedAt, failedAt)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to record cart reminder failure"), err)
+	}
+
+	return nil
+}
+
+// deleteCartReminders forgets the reminders of a cart that was checked out or cleared
+func deleteCartReminders(ctx context.Context, tx *sql.Tx, userID int64) error {
+	_, err := tx.ExecContext(ctx, `
+        DELETE FROM cart_reminders
+        WHERE user_id = ?;
+    `, userID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to delete cart reminders"), err)
+	}
+
+	return nil
+}
+
 func (repo *MySQLCartRepository) GetOrdersByUserID(ctx context.Context, userID int64) ([]*entities.Order, error) {
 	rows, err := repo.DB.QueryContext(ctx, `
         SELECT
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/infrastructure/datastore"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type MySQLConsentRepository struct {
+	DB *sql.DB
+}
+
+func NewMySQLConsentRepository(db *sql.DB) repositories.ConsentRepository {
+	return &MySQLConsentRepository{DB: db}
+}
+
+func (r *MySQLConsentRepository) AddConsents(ctx context.Context, userID int64, consents []entities.Consent) error {
+	return insertConsents(ctx, r.DB, userID, consents)
+}
+
+// GetCurrentConsents returns the consents in force, that is the latest record of each type
+func (r *MySQLConsentRepository) GetCurrentConsents(ctx context.Context, userID int64) ([]entities.Consent, error) {
+	return r.queryConsents(ctx, `
+		SELECT c.id, c.user_id, c.consent_type, c.version, c.granted, c.ip_address, c.user_agent, c.created_at
+		FROM user_consents c
+		WHERE c.user_id = ?
+		  AND c.id = (
+		      SELECT MAX(l.id) FROM user_consents l
+		      WHERE l.user_id = c.user_id AND l.consent_type = c.consent_type
+		  )
+		ORDER BY c.consent_type
+	`, userID)
+}
+
+// GetConsentHistory returns every consent record of the user, most recent first
+func (r *MySQLConsentRepository) GetConsentHistory(ctx context.Context, userID int64) ([]entities.Consent, error) {
+	return r.queryConsents(ctx, `
+		SELECT id, user_id, consent_type, version, granted, ip_address, user_agent, created_at
+		FROM user_consents
+		WHERE user_id = ?
+		ORDER BY id DESC
+	`, userID)
+}
+
+// HasConsent tells whether the latest record of the consent type grants it. Users who never
+// answered did not consent.
+func (r *MySQLConsentRepository) HasConsent(ctx context.Context, userID int64, consentType entities.ConsentType) (bool, error) {
+	var granted bool
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT granted FROM user_consents
+		WHERE user_id = ? AND consent_type = ?
+		ORDER BY id DESC LIMIT 1
+	`, userID, consentType).Scan(&granted)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, errors.Join(fmt.Errorf("failed to query consent"), err)
+	}
+
+	return granted, nil
+}
+
+func (r *MySQLConsentRepository) queryConsents(ctx context.Context, query string, args ...any) ([]entities.Consent, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query consents"), err)
+	}
+	defer rows.Close()
+
+	consents := make([]entities.Consent, 0)
+	for rows.Next() {
+		var consent entities.Consent
+		var version, userAgent sql.NullString
+
+		err = rows.Scan(
+			&consent.ID,
+			&consent.UserID,
+			&consent.Type,
+			&version,
+			&consent.Granted,
+			&consent.IPAddress,
+			&userAgent,
+			&consent.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan consent"), err)
+		}
+
+		consent.Version = version.String
+		consent.UserAgent = userAgent.String
+		consents = append(consents, consent)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to scan consents"), err)
+	}
+
+	return consents, nil
+}
+
+func insertConsents(ctx context.Context, db execer, userID int64, consents []entities.Consent) error {
+	if len(consents) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(consents))
+	args := make([]any, 0, len(consents)*6)
+	for _, consent := range consents {
+		var version sql.NullString
+		if consent.Version != "" {
+			version = sql.NullString{String: consent.Version, Valid: true}
+		}
+
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?)")
+		args = append(args, userID, consent.Type, version, consent.Granted, consent.IPAddress, consent.UserAgent)
+	}
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO user_consents (user_id, consent_type, version, granted, ip_address, user_agent)
+		VALUES `+strings.Join(placeholders, ", "), args...)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to add consents"), err)
+	}
+
+	return nil
+}
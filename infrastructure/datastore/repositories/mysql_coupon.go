@@ -0,0 +1,211 @@
+package repositories
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/infrastructure/datastore"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const couponColumns = `
+	c.id, c.code, c.type, c.value, c.min_order_value, c.starts_at, c.expires_at,
+	c.max_uses, c.max_uses_per_user, c.used_count, c.active, c.created_at
+`
+
+type MySQLCouponRepository struct {
+	DB *sql.DB
+}
+
+func NewMySQLCouponRepository(db *sql.DB) repositories.CouponRepository {
+	return &MySQLCouponRepository{DB: db}
+}
+
+func (r *MySQLCouponRepository) AddCoupon(ctx context.Context, coupon *entities.Coupon) (int64, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO coupons (code, type, value, min_order_value, starts_at, expires_at, max_uses, max_uses_per_user)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+	`,
+		coupon.Code,
+		coupon.Type,
+		coupon.Value,
+		coupon.MinOrderValue,
+		coupon.StartsAt,
+		coupon.ExpiresAt,
+		coupon.MaxUses,
+		coupon.MaxUsesPerUser,
+	)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to insert coupon"), err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to retrieve coupon ID"), err)
+	}
+
+	for _, productID := range coupon.ProductIDs {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO coupons_products (coupon_id, product_id) VALUES (?, ?);
+		`, id, productID)
+		if err != nil {
+			return -1, errors.Join(fmt.Errorf("failed to insert coupon product"), err)
+		}
+	}
+
+	for _, categoryID := range coupon.CategoryIDs {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO coupons_categories (coupon_id, category_id) VALUES (?, ?);
+		`, id, categoryID)
+		if err != nil {
+			return -1, errors.Join(fmt.Errorf("failed to insert coupon category"), err)
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *MySQLCouponRepository) GetCoupons(ctx context.Context) ([]entities.Coupon, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT `+couponColumns+` FROM coupons c ORDER BY c.id`)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query coupons"), err)
+	}
+	defer rows.Close()
+
+	coupons := make([]entities.Coupon, 0)
+	for rows.Next() {
+		var coupon entities.Coupon
+		if err = scanCoupon(rows, &coupon); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan coupon"), err)
+		}
+		coupons = append(coupons, coupon)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to scan coupons"), err)
+	}
+
+	for i := range coupons {
+		if err = loadCouponRestrictions(ctx, r.DB, &coupons[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return coupons, nil
+}
+
+func (r *MySQLCouponRepository) GetCouponByID(ctx context.Context, id int64) (*entities.Coupon, error) {
+	row := r.DB.QueryRowContext(ctx, `SELECT `+couponColumns+` FROM coupons c WHERE c.id = ?`, id)
+	return r.getCoupon(ctx, row)
+}
+
+func (r *MySQLCouponRepository) GetCouponByCode(ctx context.Context, code string) (*entities.Coupon, error) {
+	row := r.DB.QueryRowContext(ctx, `SELECT `+couponColumns+` FROM coupons c WHERE c.code = ?`, code)
+	return r.getCoupon(ctx, row)
+}
+
+func (r *MySQLCouponRepository) getCoupon(ctx context.Context, row *sql.Row) (*entities.Coupon, error) {
+	var coupon entities.Coupon
+	if err := scanCoupon(row, &coupon); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, errors.Join(fmt.Errorf("failed to scan coupon"), err)
+	}
+
+	if err := loadCouponRestrictions(ctx, r.DB, &coupon); err != nil {
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+func (r *MySQLCouponRepository) DisableCoupon(ctx context.Context, id int64) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE coupons SET active = FALSE WHERE id = ?`, id)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to disable coupon"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLCouponRepository) CountUserUsages(ctx context.Context, couponID, userID int64) (int, error) {
+	var count int
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM coupons_usages WHERE coupon_id = ? AND user_id = ?
+	`, couponID, userID).Scan(&count)
+	if err != nil {
+		return 0, errors.Join(fmt.Errorf("failed to count coupon usages"), err)
+	}
+
+	return count, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func scanCoupon(row rowScanner, coupon *entities.Coupon) error {
+	return row.Scan(
+		&coupon.ID,
+		&coupon.Code,
+		&coupon.Type,
+		&coupon.Value,
+		&coupon.MinOrderValue,
+		&coupon.StartsAt,
+		&coupon.ExpiresAt,
+		&coupon.MaxUses,
+		&coupon.MaxUsesPerUser,
+		&coupon.UsedCount,
+		&coupon.Active,
+		&coupon.CreatedAt,
+	)
+}
+
+// loadCouponRestrictions fills the product and category restrictions of the given coupon
+func loadCouponRestrictions(ctx context.Context, q queryer, coupon *entities.Coupon) error {
+	productIDs, err := queryIDs(ctx, q, `SELECT product_id FROM coupons_products WHERE coupon_id = ?`, coupon.ID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to query coupon products"), err)
+	}
+
+	categoryIDs, err := queryIDs(ctx, q, `SELECT category_id FROM coupons_categories WHERE coupon_id = ?`, coupon.ID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to query coupon categories"), err)
+	}
+
+	coupon.ProductIDs = productIDs
+	coupon.CategoryIDs = categoryIDs
+	return nil
+}
+
+func queryIDs(ctx context.Context, q queryer, query string, args ...any) ([]int64, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,206 @@
+package repositories
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/infrastructure/datastore"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type MySQLEmailOutboxRepository struct {
+	DB *sql.DB
+}
+
+func NewMySQLEmailOutboxRepository(db *sql.DB) repositories.EmailOutboxRepository {
+	return &MySQLEmailOutboxRepository{DB: db}
+}
+
+func (r *MySQLEmailOutboxRepository) AddEmail(ctx context.Context, email entities.Email) error {
+	return insertOutboxEmail(ctx, r.DB, email)
+}
+
+// ClaimDueEmails returns up to limit pending emails due for delivery and postpones them by the
+// lease, so that another worker does not deliver them at the same time. Emails left unsent
+// when the lease expires, because the worker stopped, are delivered again.
+func (r *MySQLEmailOutboxRepository) ClaimDueEmails(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]entities.OutboxEmail, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+outboxColumns+`
+		FROM email_outbox
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, id
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`, entities.OutboxStatusPending, now, limit)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query due emails"), err)
+	}
+
+	emails, err := scanOutboxEmails(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, email := range emails {
+		_, err = tx.ExecContext(ctx, `UPDATE email_outbox SET next_attempt_at = ? WHERE id = ?`, now.Add(lease), email.ID)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to claim email"), err)
+		}
+	}
+
+	return emails, tx.Commit()
+}
+
+// MarkSent records the delivery and clears the body, which may hold single-use links
+func (r *MySQLEmailOutboxRepository) MarkSent(ctx context.Context, id int64, sentAt time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE email_outbox
+		SET status = ?, body = '', attempts = attempts + 1, last_error = NULL, sent_at = ?
+		WHERE id = ?
+	`, entities.OutboxStatusSent, sentAt, id)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to mark email as sent"), err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed delivery, to be attempted again at nextAttemptAt or, when nil,
+// set aside as failed
+func (r *MySQLEmailOutboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt *time.Time) error {
+	status := entities.OutboxStatusPending
+	next := sql.NullTime{}
+	if nextAttemptAt == nil {
+		status = entities.OutboxStatusFailed
+	} else {
+		next = sql.NullTime{Time: *nextAttemptAt, Valid: true}
+	}
+
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE email_outbox
+		SET status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = COALESCE(?, next_attempt_at)
+		WHERE id = ?
+	`, status, lastError, next, id)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to mark email as failed"), err)
+	}
+
+	return nil
+}
+
+// GetEmails returns a page of the emails with the status, or of every email if empty, most
+// recent first, along with their total
+func (r *MySQLEmailOutboxRepository) GetEmails(ctx context.Context, status entities.OutboxStatus, limit, offset int) ([]entities.OutboxEmail, int, error) {
+	where := "WHERE 1 = 1"
+	var args []any
+
+	if status != "" {
+		where += " AND status = ?"
+		args = append(args, status)
+	}
+
+	var total int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM email_outbox "+where, args...).Scan(&total); err != nil {
+		return nil, 0, errors.Join(fmt.Errorf("failed to count emails"), err)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+outboxColumns+" FROM email_outbox "+where+" ORDER BY id DESC LIMIT ? OFFSET ?",
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, errors.Join(fmt.Errorf("failed to query emails"), err)
+	}
+
+	emails, err := scanOutboxEmails(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return emails, total, nil
+}
+
+// RetryEmail queues a failed email for delivery again, with a fresh count of attempts. It
+// returns false if there is no failed email with the id.
+func (r *MySQLEmailOutboxRepository) RetryEmail(ctx context.Context, id int64, now time.Time) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE email_outbox SET status = ?, attempts = 0, next_attempt_at = ? WHERE id = ? AND status = ?
+	`, entities.OutboxStatusPending, now, id, entities.OutboxStatusFailed)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to retry email"), err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check retried email"), err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *MySQLEmailOutboxRepository) DeleteSentEmails(ctx context.Context, before time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `DELETE FROM email_outbox WHERE status = ? AND sent_at < ?`, entities.OutboxStatusSent, before)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to delete sent emails"), err)
+	}
+
+	return nil
+}
+
+const outboxColumns = "id, recipient, subject, body, status, attempts, next_attempt_at, last_error, created_at, sent_at"
+
+func scanOutboxEmails(rows *sql.Rows) ([]entities.OutboxEmail, error) {
+	defer rows.Close()
+
+	emails := make([]entities.OutboxEmail, 0)
+	for rows.Next() {
+		var email entities.OutboxEmail
+		var lastError sql.NullString
+		var sentAt sql.NullTime
+
+		err := rows.Scan(
+			&email.ID,
+			&email.Recipient,
+			&email.Subject,
+			&email.Body,
+			&email.Status,
+			&email.Attempts,
+			&email.NextAttemptAt,
+			&lastError,
+			&email.CreatedAt,
+			&sentAt,
+		)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan email"), err)
+		}
+
+		email.LastError = lastError.String
+		email.SentAt = nullTimePtr(sentAt)
+		emails = append(emails, email)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to scan emails"), err)
+	}
+
+	return emails, nil
+}
+
+// insertOutboxEmail queues the email for delivery. Given the transaction of the event it
+// reports, the email is only sent if the event is committed.
+func insertOutboxEmail(ctx context.Context, db execer, email entities.Email) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO email_outbox (recipient, subject, body, status) VALUES (?, ?, ?, ?)
+	`, email.To, email.Subject, email.Body, entities.OutboxStatusPending)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to queue email"), err)
+	}
+
+	return nil
+}
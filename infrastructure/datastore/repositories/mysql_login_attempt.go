@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/infrastructure/datastore"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type MySQLLoginAttemptRepository struct {
+	DB *sql.DB
+}
+
+func NewMySQLLoginAttemptRepository(db *sql.DB) repositories.LoginAttemptRepository {
+	return &MySQLLoginAttemptRepository{DB: db}
+}
+
+// AddLoginFailure records the failed attempt along with the notification, if any, of the
+// lockout it causes
+func (r *MySQLLoginAttemptRepository) AddLoginFailure(ctx context.Context, email, ipAddress string, notification *entities.Email) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO login_failures (email, ip_address) VALUES (?, ?)
+	`, email, ipAddress)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to add login failure"), err)
+	}
+
+	if notification != nil {
+		if err = insertOutboxEmail(ctx, tx, *notification); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *MySQLLoginAttemptRepository) GetEmailFailures(ctx context.Context, email string, since time.Time) (*entities.LoginFailures, error) {
+	return r.getFailures(ctx, `
+		SELECT COUNT(*), MAX(created_at) FROM login_failures WHERE email = ? AND created_at > ?
+	`, email, since)
+}
+
+func (r *MySQLLoginAttemptRepository) GetIPFailures(ctx context.Context, ipAddress string, since time.Time) (*entities.LoginFailures, error) {
+	return r.getFailures(ctx, `
+		SELECT COUNT(*), MAX(created_at) FROM login_failures WHERE ip_address = ? AND created_at > ?
+	`, ipAddress, since)
+}
+
+func (r *MySQLLoginAttemptRepository) ClearEmailFailures(ctx context.Context, email string) error {
+	if _, err := r.DB.ExecContext(ctx, `DELETE FROM login_failures WHERE email = ?`, email); err != nil {
+		return errors.Join(fmt.Errorf("failed to clear login failures"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLLoginAttemptRepository) DeleteLoginFailuresBefore(ctx context.Context, before time.Time) error {
+	if _, err := r.DB.ExecContext(ctx, `DELETE FROM login_failures WHERE created_at < ?`, before); err != nil {
+		return errors.Join(fmt.Errorf("failed to delete login failures"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLLoginAttemptRepository) getFailures(ctx context.Context, query string, args ...any) (*entities.LoginFailures, error) {
+	var failures entities.LoginFailures
+	var lastAt sql.NullTime
+
+	if err := r.DB.QueryRowContext(ctx, query, args...).Scan(&failures.Count, &lastAt); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query/scan login failures"), err)
+	}
+
+	failures.LastAt = nullTimePtr(lastAt)
+	return &failures, nil
+}
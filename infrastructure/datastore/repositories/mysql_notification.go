@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/infrastructure/datastore"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type MySQLNotificationRepository struct {
+	DB *sql.DB
+}
+
+func NewMySQLNotificationRepository(db *sql.DB) repositories.NotificationRepository {
+	return &MySQLNotificationRepository{DB: db}
+}
+
+// AddNotification records the notification and, when given, queues its email in the same
+// transaction
+func (r *MySQLNotificationRepository) AddNotification(ctx context.Context, notification *entities.Notification, email *entities.Email) (int64, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, type, title, body, link) VALUES (?, ?, ?, ?, ?)
+	`, notification.UserID, notification.Type, notification.Title, notification.Body, notification.Link)
+	if err != nil {
+		return 0, errors.Join(fmt.Errorf("failed to insert notification"), err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Join(fmt.Errorf("failed to get notification id"), err)
+	}
+
+	if email != nil {
+		if err = insertOutboxEmail(ctx, tx, *email); err != nil {
+			return 0, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+// GetNotifications returns a page of the user's notifications, or of the unread ones, most
+// recent first, along with their total
+func (r *MySQLNotificationRepository) GetNotifications(ctx context.Context, userID int64, unreadOnly bool, limit, offset int) ([]entities.Notification, int, error) {
+	where := "WHERE user_id = ?"
+	if unreadOnly {
+		where += " AND read_at IS NULL"
+	}
+
+	var total int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM notifications "+where, userID).Scan(&total); err != nil {
+		return nil, 0, errors.Join(fmt.Errorf("failed to count notifications"), err)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+notificationColumns+" FROM notifications "+where+" ORDER BY id DESC LIMIT ? OFFSET ?",
+		userID, limit, offset)
+	if err != nil {
+		return nil, 0, errors.Join(fmt.Errorf("failed to query notifications"), err)
+	}
+
+	notifications, err := scanNotifications(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
+
+func (r *MySQLNotificationRepository) GetNotificationsByUser(ctx context.Context, userID int64) ([]entities.Notification, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+notificationColumns+" FROM notifications WHERE user_id = ? ORDER BY id DESC", userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query notifications"), err)
+	}
+
+	return scanNotifications(rows)
+}
+
+func (r *MySQLNotificationRepository) CountUnread(ctx context.Context, userID int64) (int, error) {
+	var unread int
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL
+	`, userID).Scan(&unread)
+	if err != nil {
+		return 0, errors.Join(fmt.Errorf("failed to count unread notifications"), err)
+	}
+
+	return unread, nil
+}
+
+// MarkRead marks the user's notification as read, keeping the time it was first read. It
+// returns false if the user has no notification with the id.
+func (r *MySQLNotificationRepository) MarkRead(ctx context.Context, userID, id int64, readAt time.Time) (bool, error) {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE notifications SET read_at = ? WHERE id = ? AND user_id = ? AND read_at IS NULL
+	`, readAt, id, userID)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to mark notification as read"), err)
+	}
+
+	var exists bool
+	err = r.DB.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM notifications WHERE id = ? AND user_id = ?)
+	`, id, userID).Scan(&exists)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check notification"), err)
+	}
+
+	return exists, nil
+}
+
+func (r *MySQLNotificationRepository) MarkAllRead(ctx context.Context, userID int64, readAt time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL
+	`, readAt, userID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to mark notifications as read"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLNotificationRepository) DeleteReadNotifications(ctx context.Context, before time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `DELETE FROM notifications WHERE read_at < ?`, before)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to delete read notifications"), err)
+	}
+
+	return nil
+}
+
+// GetPreferences returns the preferences the user chose. Types without a row keep their default.
+func (r *MySQLNotificationRepository) GetPreferences(ctx context.Context, userID int64) ([]entities.NotificationPreference, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT type, email FROM notification_preferences WHERE user_id = ?
+	`, userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query notification preferences"), err)
+	}
+	defer rows.Close()
+
+	preferences := make([]entities.NotificationPreference, 0)
+	for rows.Next() {
+		var preference entities.NotificationPreference
+		if err = rows.Scan(&preference.Type, &preference.Email); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan notification preference"), err)
+		}
+
+		preferences = append(preferences, preference)
+	}
+
+	return preferences, rows.Err()
+}
+
+func (r *MySQLNotificationRepository) SetPreferences(ctx context.Context, userID int64, preferences []entities.NotificationPreference) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	for _, preference := range preferences {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO notification_preferences (user_id, type, email) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE email = VALUES(email)
+		`, userID, preference.Type, preference.Email)
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to save notification preference"), err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+const notificationColumns = "id, user_id, type, title, body, link, read_at, created_at"
+
+func scanNotifications(rows *sql.Rows) ([]entities.Notification, error) {
+	defer rows.Close()
+
+	notifications := make([]entities.Notification, 0)
+	for rows.Next() {
+		var notification entities.Notification
+		var link sql.NullString
+		var readAt sql.NullTime
+
+		err := rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.Type,
+			&notification.Title,
+			&notification.Body,
+			&link,
+			&readAt,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan notification"), err)
+		}
+
+		notification.Link = link.String
+		notification.ReadAt = nullTimePtr(readAt)
+		notifications = append(notifications, notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to scan notifications"), err)
+	}
+
+	return notifications, nil
+}
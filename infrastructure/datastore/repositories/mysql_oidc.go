@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/infrastructure/datastore"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type MySQLOIDCRepository struct {
+	DB *sql.DB
+}
+
+func NewMySQLOIDCRepository(db *sql.DB) repositories.OIDCRepository {
+	return &MySQLOIDCRepository{DB: db}
+}
+
+func (r *MySQLOIDCRepository) AddState(ctx context.Context, state *entities.OIDCState) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO oidc_states (state_hash, provider, nonce, code_verifier, expires_at) VALUES (?, ?, ?, ?, ?)
+	`, state.StateHash, state.Provider, state.Nonce, state.CodeVerifier, state.ExpiresAt)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to add oidc state"), err)
+	}
+
+	return nil
+}
+
+// ConsumeState returns the state and deletes it, so a callback can only be completed once.
+// It returns nil if the state does not exist or was already consumed.
+func (r *MySQLOIDCRepository) ConsumeState(ctx context.Context, stateHash string) (*entities.OIDCState, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	var state entities.OIDCState
+	err = tx.QueryRowContext(ctx, `
+		SELECT state_hash, provider, nonce, code_verifier, expires_at, created_at
+		FROM oidc_states WHERE state_hash = ? FOR UPDATE
+	`, stateHash).Scan(
+		&state.StateHash,
+		&state.Provider,
+		&state.Nonce,
+		&state.CodeVerifier,
+		&state.ExpiresAt,
+		&state.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, errors.Join(fmt.Errorf("failed to query/scan oidc state"), err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM oidc_states WHERE state_hash = ?`, stateHash); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to delete oidc state"), err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to commit transaction"), err)
+	}
+
+	return &state, nil
+}
+
+func (r *MySQLOIDCRepository) DeleteExpiredStates(ctx context.Context, now time.Time) error {
+	if _, err := r.DB.ExecContext(ctx, `DELETE FROM oidc_states WHERE expires_at < ?`, now); err != nil {
+		return errors.Join(fmt.Errorf("failed to delete expired oidc states"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLOIDCRepository) GetIdentity(ctx context.Context, provider, subject string) (*entities.UserIdentity, error) {
+	var identity entities.UserIdentity
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, user_id, provider, subject, email, created_at
+		FROM user_identities WHERE provider = ? AND subject = ?
+	`, provider, subject).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, errors.Join(fmt.Errorf("failed to query/scan user identity"), err)
+	}
+
+	return &identity, nil
+}
+
+func (r *MySQLOIDCRepository) AddIdentity(ctx context.Context, identity *entities.UserIdentity) (int64, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO user_identities (user_id, provider, subject, email) VALUES (?, ?, ?, ?)
+	`, identity.UserID, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		return 0, errors.Join(fmt.Errorf("failed to add user identity"), err)
+	}
+
+	return res.LastInsertId()
+}
+
+func (r *MySQLOIDCRepository) GetIdentitiesByUser(ctx context.Context, userID int64) ([]entities.UserIdentity, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, user_id, provider, subject, email, created_at
+		FROM user_identities WHERE user_id = ? ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query user identities"), err)
+	}
+	defer rows.Close()
+
+	identities := make([]entities.UserIdentity, 0)
+	for rows.Next() {
+		var identity entities.UserIdentity
+		err = rows.Scan(
+			&identity.ID,
+			&identity.UserID,
+			&identity.Provider,
+			&identity.Subject,
+			&identity.Email,
+			&identity.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan user identity"), err)
+		}
+		identities = append(identities, identity)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to scan user identities"), err)
+	}
+
+	return identities, nil
+}
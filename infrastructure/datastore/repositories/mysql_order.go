@@ -5,6 +5,8 @@ import (
 	repositories "cachacariaapi/infrastructure/datastore"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 )
 
 type MYSQLOrderRepository struct {
@@ -48,22 +50,161 @@ func (r *MYSQLOrderRepository) AddOrderItem(ctx context.Context, orderID, produc
 }
 
 func (r *MYSQLOrderRepository) GetOrders(ctx context.Context, userID int64) ([]entities.Order, error) {
-	const query = `
+	return r.getOrders(ctx, "WHERE o.user_id = ?", userID)
+}
+
+// GetAllOrders returns the orders of every user, for the back office
+func (r *MYSQLOrderRepository) GetAllOrders(ctx context.Context) ([]entities.Order, error) {
+	return r.getOrders(ctx, "")
+}
+
+func (r *MYSQLOrderRepository) GetOrder(ctx context.Context, orderID int64) (*entities.Order, error) {
+	orders, err := r.getOrders(ctx, "WHERE o.id = ?", orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(orders) == 0 {
+		return nil, nil
+	}
+
+	return &orders[0], nil
+}
+
+// UpdateOrderStatus moves the order from its current status to the given one and queues the
+// notification in the same transaction. Cancelling an order returns its items to the stock and
+// releases the coupon usage, and the IDs of the products that were out of stock are returned.
+// It returns false if the order status changed in the meantime.
+func (r *MYSQLOrderRepository) UpdateOrderStatus(
+	ctx context.Context,
+	order *entities.Order,
+	status entities.OrderStatus,
+	trackingCode string,
+	notification *entities.Email,
+) (bool, []int64, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, nil, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE orders SET status = ?, tracking_code = COALESCE(NULLIF(?, ''), tracking_code)
+		WHERE id = ? AND status = ?
+	`, status, trackingCode, order.ID, order.Status)
+	if err != nil {
+		return false, nil, errors.Join(fmt.Errorf("failed to update order status"), err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, nil, errors.Join(fmt.Errorf("failed to check updated order"), err)
+	}
+
+	if affected == 0 {
+		return false, nil, nil
+	}
+
+	var restocked []int64
+	if status == entities.OrderStatusCancelled {
+		restocked, err = soldOutOrderProducts(ctx, tx, order.ID)
+		if err != nil {
+			return false, nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE products p
+			JOIN order_items oi ON oi.product_id = p.id
+			SET p.stock = p.stock + oi.quantity
+			WHERE oi.order_id = ?
+		`, order.ID)
+		if err != nil {
+			return false, nil, errors.Join(fmt.Errorf("failed to restock order items"), err)
+		}
+
+		if order.CouponID != nil {
+			if _, err = tx.ExecContext(ctx, `DELETE FROM coupons_usages WHERE order_id = ?`, order.ID); err != nil {
+				return false, nil, errors.Join(fmt.Errorf("failed to delete coupon usage"), err)
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				UPDATE coupons SET used_count = used_count - 1 WHERE id = ? AND used_count > 0
+			`, *order.CouponID)
+			if err != nil {
+				return false, nil, errors.Join(fmt.Errorf("failed to release coupon"), err)
+			}
+		}
+	}
+
+	if notification != nil {
+		if err = insertOutboxEmail(ctx, tx, *notification); err != nil {
+			return false, nil, err
+		}
+	}
+
+	return true, restocked, tx.Commit()
+}
+
+// soldOutOrderProducts locks the products of the order that are out of stock, which the
+// cancellation is about to restock, and returns their IDs
+func soldOutOrderProducts(ctx context.Context, tx *sql.Tx, orderID int64) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT p.id
+		FROM products p
+		JOIN order_items oi ON oi.product_id = p.id
+		WHERE oi.order_id = ? AND p.stock = 0
+		FOR UPDATE
+	`, orderID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to get sold out products"), err)
+	}
+	defer rows.Close()
+
+	var productIDs []int64
+	for rows.Next() {
+		var productID int64
+		if err = rows.Scan(&productID); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan product id"), err)
+		}
+
+		productIDs = append(productIDs, productID)
+	}
+
+	return productIDs, rows.Err()
+}
+
+func (r *MYSQLOrderRepository) getOrders(ctx context.Context, where string, args ...any) ([]entities.Order, error) {
+	query := `
 		SELECT 
 			o.id,
 			o.user_id,
+			o.coupon_id,
+			o.subtotal,
+			o.discount,
+			o.total,
+			o.status,
+			o.tracking_code,
+			o.shipping_name,
+			o.shipping_zip_code,
+			o.shipping_street,
+			o.shipping_number,
+			o.shipping_complement,
+			o.shipping_district,
+			o.shipping_city,
+			o.shipping_state,
 			o.created_at,
 			o.modified_at,
 			oi.id AS item_id,
 			oi.product_id,
-			oi.quantity
+			oi.quantity,
+			oi.price
 		FROM orders o
 		LEFT JOIN order_items oi ON o.id = oi.order_id
-		WHERE o.user_id = ?
+		` + where + `
 		ORDER BY o.id
 	`
 
-	rows, err := r.DB.QueryContext(ctx, query, userID)
+	rows, err := r.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -75,10 +216,27 @@ func (r *MYSQLOrderRepository) GetOrders(ctx context.Context, userID int64) ([]e
 	for rows.Next() {
 		var o entities.Order
 		var item entities.OrderItem
+		var couponID sql.NullInt64
+		var trackingCode sql.NullString
+		var address shippingAddressColumns
 
 		err = rows.Scan(
 			&o.ID,
 			&o.UserID,
+			&couponID,
+			&o.Subtotal,
+			&o.Discount,
+			&o.TotalAmount,
+			&o.Status,
+			&trackingCode,
+			&address.RecipientName,
+			&address.ZipCode,
+			&address.Street,
+			&address.Number,
+			&address.Complement,
+			&address.District,
+			&address.City,
+			&address.State,
 			&o.CreatedAt,
 			&o.ModifiedAt,
 			&item.ID,
@@ -90,6 +248,13 @@ func (r *MYSQLOrderRepository) GetOrders(ctx context.Context, userID int64) ([]e
 			return nil, err
 		}
 
+		if couponID.Valid {
+			o.CouponID = &couponID.Int64
+		}
+
+		o.TrackingCode = trackingCode.String
+		o.ShippingAddress = address.toAddress()
+
 		if currentOrder == nil || currentOrder.ID != o.ID {
 			if currentOrder != nil {
 				orders = append(orders, *currentOrder)
@@ -108,3 +273,56 @@ func (r *MYSQLOrderRepository) GetOrders(ctx context.Context, userID int64) ([]e
 
 	return orders, nil
 }
+
+// HasPurchasedProduct reports whether the user paid for the product, in an order that was not
+// cancelled since
+func (r *MYSQLOrderRepository) HasPurchasedProduct(ctx context.Context, userID, productID int64) (bool, error) {
+	const query = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.user_id = ? AND oi.product_id = ? AND o.status IN (?, ?, ?)
+		)
+	`
+
+	var purchased bool
+	err := r.DB.QueryRowContext(ctx, query, userID, productID,
+		entities.OrderStatusPaid, entities.OrderStatusShipped, entities.OrderStatusDelivered,
+	).Scan(&purchased)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check product purchase"), err)
+	}
+
+	return purchased, nil
+}
+
+// shippingAddressColumns scans the shipping address of an order, which is empty for the orders
+// placed before addresses were stored
+type shippingAddressColumns struct {
+	RecipientName sql.NullString
+	ZipCode       sql.NullString
+	Street        sql.NullString
+	Number        sql.NullString
+	Complement    sql.NullString
+	District      sql.NullString
+	City          sql.NullString
+	State         sql.NullString
+}
+
+func (c shippingAddressColumns) toAddress() *entities.ShippingAddress {
+	if !c.RecipientName.Valid {
+		return nil
+	}
+
+	return &entities.ShippingAddress{
+		RecipientName: c.RecipientName.String,
+		ZipCode:       c.ZipCode.String,
+		Street:        c.Street.String,
+		Number:        c.Number.String,
+		Complement:    c.Complement.String,
+		District:      c.District.String,
+		City:          c.City.String,
+		State:         c.State.String,
+	}
+}
@@ -6,8 +6,17 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"math"
 )
 
+// productRatingsQuery aggregates the approved reviews of each product
+const productRatingsQuery = `
+	SELECT product_id, ROUND(AVG(rating), 1) AS average_rating, COUNT(*) AS rating_count
+	FROM product_reviews
+	WHERE status = 'approved'
+	GROUP BY product_id
+`
+
 type MySQLProductRepository struct {
 	DB *sql.DB
 }
@@ -17,15 +26,21 @@ func NewMySQLProductRepository(db *sql.DB) repositories.ProductRepository {
 }
 
 func (r *MySQLProductRepository) AddProduct(product entities.AddProductRequest) (int64, error) {
-	query := "INSERT INTO products (name, description, price, stock) VALUES (?, ?, ?, ?)"
+	query := "INSERT INTO products (category_id, name, description, price, stock) VALUES (?, ?, ?, ?, ?)"
 
-	res, err := r.DB.Exec(query, product.Name, product.Description, product.Price, product.Stock)
+	res, err := r.DB.Exec(query, product.CategoryID, product.Name, product.Description, product.Price, product.Stock)
 	if err != nil {
 		return -1, errors.Join(fmt.Errorf("failed to insert product"), err)
 	}
 
 	id, _ := res.LastInsertId()
 
+	const priceQuery = "INSERT INTO product_prices (product_id, type, price, applied_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)"
+	_, err = r.DB.Exec(priceQuery, id, entities.ProductPriceTypeRegular, product.Price)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to insert product price history"), err)
+	}
+
 	return id, nil
 }
 
@@ -42,7 +57,14 @@ func (r *MySQLProductRepository) AddProductPhotos(productID int64, filenames []s
 func (r *MySQLProductRepository) GetAll() ([]entities.Product, error) {
 	products := make([]entities.Product, 0)
 
-	const query = "SELECT id, name, description, price, stock FROM products WHERE status_code != 1 ORDER BY id"
+	const query = `
+		SELECT p.id, p.category_id, p.name, p.description, p.price, p.original_price, p.stock,
+		       COALESCE(r.average_rating, 0), COALESCE(r.rating_count, 0)
+		FROM products p
+		LEFT JOIN (` + productRatingsQuery + `) r ON r.product_id = p.id
+		WHERE p.status_code != 1
+		ORDER BY p.id
+	`
 	rows, err := r.DB.Query(query)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -56,10 +78,21 @@ func (r *MySQLProductRepository) GetAll() ([]entities.Product, error) {
 
 	for rows.Next() {
 		var product entities.Product
-		if err = rows.Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Stock); err != nil {
+		var categoryID sql.NullInt64
+		var originalPrice sql.NullFloat64
+		if err = rows.Scan(&product.ID, &categoryID, &product.Name, &product.Description, &product.Price, &originalPrice, &product.Stock, &product.AverageRating, &product.RatingCount); err != nil {
 			return nil, errors.Join(fmt.Errorf("failed to scan product"), err)
 		}
 
+		if categoryID.Valid {
+			product.CategoryID = &categoryID.Int64
+		}
+
+		if originalPrice.Valid {
+			price := float32(originalPrice.Float64)
+			product.OriginalPrice = &price
+		}
+
 		var photos []string
 		const query = "SELECT id, filename FROM products_photos WHERE product_id = ?"
 		photoRows, err := r.DB.Query(query, product.ID)
@@ -89,12 +122,20 @@ func (r *MySQLProductRepository) GetAll() ([]entities.Product, error) {
 }
 
 func (r *MySQLProductRepository) GetProduct(id int64) (*entities.Product, error) {
-	const query = "SELECT id, name, description, price, stock FROM products WHERE id = ? AND status_code != 1"
+	const query = `
+		SELECT p.id, p.category_id, p.name, p.description, p.price, p.original_price, p.stock,
+		       COALESCE(r.average_rating, 0), COALESCE(r.rating_count, 0)
+		FROM products p
+		LEFT JOIN (` + productRatingsQuery + `) r ON r.product_id = p.id
+		WHERE p.id = ? AND p.status_code != 1
+	`
 	row := r.DB.QueryRow(query, id)
 
 	var product entities.Product
+	var categoryID sql.NullInt64
+	var originalPrice sql.NullFloat64
 
-	if err := row.Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Stock); err != nil {
+	if err := row.Scan(&product.ID, &categoryID, &product.Name, &product.Description, &product.Price, &originalPrice, &product.Stock, &product.AverageRating, &product.RatingCount); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -102,6 +143,15 @@ func (r *MySQLProductRepository) GetProduct(id int64) (*entities.Product, error)
 		return nil, errors.Join(errors.New("failed to scan product"), err)
 	}
 
+	if categoryID.Valid {
+		product.CategoryID = &categoryID.Int64
+	}
+
+	if originalPrice.Valid {
+		price := float32(originalPrice.Float64)
+		product.OriginalPrice = &price
+	}
+
 	const photoQuery = "SELECT id, filename FROM products_photos WHERE product_id = ?"
 	photoRows, err := r.DB.Query(photoQuery, product.ID)
 	if err != nil {
@@ -140,14 +190,43 @@ func (r *MySQLProductRepository) DeleteProduct(id int64) error {
 	return nil
 }
 
+// UpdateProduct updates the product data and its regular price, recording the price change in
+// the price history. While the product is on sale, the new price becomes its original price.
 func (r *MySQLProductRepository) UpdateProduct(id int64, product entities.UpdateProductRequest) error {
-	const query = "UPDATE products SET name = ?, description = ?, price = ?, stock = ? WHERE id = ?"
-	_, err := r.DB.Exec(query, product.Name, product.Description, product.Price, product.Stock, id)
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	var price float64
+	var originalPrice sql.NullFloat64
+	const lockQuery = "SELECT price, original_price FROM products WHERE id = ? FOR UPDATE"
+	if err = tx.QueryRow(lockQuery, id).Scan(&price, &originalPrice); err != nil {
+		return errors.Join(fmt.Errorf("failed to lock product"), err)
+	}
+
+	regularPrice := price
+	query := "UPDATE products SET category_id = ?, name = ?, description = ?, price = ?, stock = ? WHERE id = ?"
+	if originalPrice.Valid {
+		regularPrice = originalPrice.Float64
+		query = "UPDATE products SET category_id = ?, name = ?, description = ?, original_price = ?, stock = ? WHERE id = ?"
+	}
+
+	_, err = tx.Exec(query, product.CategoryID, product.Name, product.Description, product.Price, product.Stock, id)
 	if err != nil {
 		return errors.Join(fmt.Errorf("failed to update product"), err)
 	}
 
-	return nil
+	if math.Abs(regularPrice-float64(product.Price)) >= 0.005 {
+		const priceQuery = "INSERT INTO product_prices (product_id, type, price, applied_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)"
+		_, err = tx.Exec(priceQuery, id, entities.ProductPriceTypeRegular, product.Price)
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to insert product price history"), err)
+		}
+	}
+
+	return tx.Commit()
 }
 
 func (r *MySQLProductRepository) DecrementStock(id int64, quantity int) error {
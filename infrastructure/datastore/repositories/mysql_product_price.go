@@ -0,0 +1,209 @@
+package repositories
+
+import (
+	"cachacariaapi/domain/entities"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const productPriceColumns = `
+	id, product_id, type, price, starts_at, ends_at, applied_at, ended_at, cancelled_at, created_at
+`
+
+func (r *MySQLProductRepository) AddProductPrice(ctx context.Context, price *entities.ProductPrice) (int64, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO product_prices (product_id, type, price, starts_at, ends_at)
+		VALUES (?, ?, ?, ?, ?);
+	`, price.ProductID, price.Type, price.Price, price.StartsAt, price.EndsAt)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to insert product price"), err)
+	}
+
+	return res.LastInsertId()
+}
+
+func (r *MySQLProductRepository) GetProductPrices(ctx context.Context, productID int64) ([]entities.ProductPrice, error) {
+	return r.queryProductPrices(ctx, `
+		SELECT `+productPriceColumns+`
+		FROM product_prices
+		WHERE product_id = ?
+		ORDER BY starts_at DESC, id DESC
+	`, productID)
+}
+
+func (r *MySQLProductRepository) GetProductPrice(ctx context.Context, id int64) (*entities.ProductPrice, error) {
+	prices, err := r.queryProductPrices(ctx, `SELECT `+productPriceColumns+` FROM product_prices WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prices) == 0 {
+		return nil, nil
+	}
+
+	return &prices[0], nil
+}
+
+func (r *MySQLProductRepository) CancelProductPrice(ctx context.Context, id int64) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE product_prices SET cancelled_at = CURRENT_TIMESTAMP WHERE id = ? AND applied_at IS NULL
+	`, id)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to cancel product price"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLProductRepository) GetPricesToStart(ctx context.Context, now time.Time) ([]entities.ProductPrice, error) {
+	return r.queryProductPrices(ctx, `
+		SELECT `+productPriceColumns+`
+		FROM product_prices
+		WHERE applied_at IS NULL AND cancelled_at IS NULL AND ended_at IS NULL
+		  AND starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)
+		ORDER BY starts_at, id
+	`, now, now)
+}
+
+func (r *MySQLProductRepository) GetSalesToEnd(ctx context.Context, now time.Time) ([]entities.ProductPrice, error) {
+	return r.queryProductPrices(ctx, `
+		SELECT `+productPriceColumns+`
+		FROM product_prices
+		WHERE type = ? AND cancelled_at IS NULL AND ended_at IS NULL AND ends_at <= ?
+		ORDER BY ends_at, id
+	`, entities.ProductPriceTypeSale, now)
+}
+
+// StartProductPrice applies a scheduled price to its product.
+//
+// A regular price replaces the product price or, if the product is on sale, the original
+// price restored when the sale ends. A sale price replaces the product price, keeping the
+// regular price as the original price, and ends any other running sale of the product.
+func (r *MySQLProductRepository) StartProductPrice(ctx context.Context, price entities.ProductPrice) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	var originalPrice sql.NullFloat64
+	err = tx.QueryRowContext(ctx, `
+		SELECT original_price FROM products WHERE id = ? FOR UPDATE
+	`, price.ProductID).Scan(&originalPrice)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to lock product"), err)
+	}
+
+	switch {
+	case price.Type == entities.ProductPriceTypeRegular && originalPrice.Valid:
+		_, err = tx.ExecContext(ctx, `UPDATE products SET original_price = ? WHERE id = ?`, price.Price, price.ProductID)
+	case price.Type == entities.ProductPriceTypeRegular:
+		_, err = tx.ExecContext(ctx, `UPDATE products SET price = ? WHERE id = ?`, price.Price, price.ProductID)
+	case originalPrice.Valid:
+		_, err = tx.ExecContext(ctx, `UPDATE products SET price = ? WHERE id = ?`, price.Price, price.ProductID)
+	default:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE products SET original_price = price, price = ? WHERE id = ?
+		`, price.Price, price.ProductID)
+	}
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to update product price"), err)
+	}
+
+	if price.Type == entities.ProductPriceTypeSale {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE product_prices SET ended_at = CURRENT_TIMESTAMP
+			WHERE product_id = ? AND type = ? AND applied_at IS NOT NULL AND ended_at IS NULL AND id != ?
+		`, price.ProductID, entities.ProductPriceTypeSale, price.ID)
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to end previous sale"), err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE product_prices SET applied_at = CURRENT_TIMESTAMP WHERE id = ?`, price.ID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to mark product price as applied"), err)
+	}
+
+	return tx.Commit()
+}
+
+// EndProductSale restores the original price of a product whose sale is over. Sales that
+// never started are only marked as ended.
+func (r *MySQLProductRepository) EndProductSale(ctx context.Context, price entities.ProductPrice) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	if price.AppliedAt != nil {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE products SET price = original_price, original_price = NULL
+			WHERE id = ? AND original_price IS NOT NULL
+		`, price.ProductID)
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to restore product price"), err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE product_prices SET ended_at = CURRENT_TIMESTAMP WHERE id = ?`, price.ID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to mark sale as ended"), err)
+	}
+
+	return tx.Commit()
+}
+
+func (r *MySQLProductRepository) queryProductPrices(ctx context.Context, query string, args ...any) ([]entities.ProductPrice, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query product prices"), err)
+	}
+	defer rows.Close()
+
+	prices := make([]entities.ProductPrice, 0)
+	for rows.Next() {
+		var price entities.ProductPrice
+		var endsAt, appliedAt, endedAt, cancelledAt sql.NullTime
+
+		err = rows.Scan(
+			&price.ID,
+			&price.ProductID,
+			&price.Type,
+			&price.Price,
+			&price.StartsAt,
+			&endsAt,
+			&appliedAt,
+			&endedAt,
+			&cancelledAt,
+			&price.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan product price"), err)
+		}
+
+		price.EndsAt = nullTimePtr(endsAt)
+		price.AppliedAt = nullTimePtr(appliedAt)
+		price.EndedAt = nullTimePtr(endedAt)
+		price.CancelledAt = nullTimePtr(cancelledAt)
+		prices = append(prices, price)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to scan product prices"), err)
+	}
+
+	return prices, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
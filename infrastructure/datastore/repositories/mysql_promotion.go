@@ -0,0 +1,208 @@
+package repositories
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/infrastructure/datastore"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const promotionColumns = `
+	id, name, type, category_id, buy_quantity, pay_quantity, kit_price, priority,
+	starts_at, ends_at, active, created_at
+`
+
+type MySQLPromotionRepository struct {
+	DB *sql.DB
+}
+
+func NewMySQLPromotionRepository(db *sql.DB) repositories.PromotionRepository {
+	return &MySQLPromotionRepository{DB: db}
+}
+
+func (r *MySQLPromotionRepository) AddPromotion(ctx context.Context, promotion *entities.Promotion) (int64, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO promotions (name, type, category_id, buy_quantity, pay_quantity, kit_price, priority, starts_at, ends_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+	`,
+		promotion.Name,
+		promotion.Type,
+		promotion.CategoryID,
+		promotion.BuyQuantity,
+		promotion.PayQuantity,
+		promotion.KitPrice,
+		promotion.Priority,
+		promotion.StartsAt,
+		promotion.EndsAt,
+	)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to insert promotion"), err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to retrieve promotion ID"), err)
+	}
+
+	for _, product := range promotion.Products {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO promotions_products (promotion_id, product_id, quantity) VALUES (?, ?, ?);
+		`, id, product.ProductID, product.Quantity)
+		if err != nil {
+			return -1, errors.Join(fmt.Errorf("failed to insert promotion product"), err)
+		}
+	}
+
+	for _, tier := range promotion.Tiers {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO promotions_tiers (promotion_id, min_quantity, discount_percent) VALUES (?, ?, ?);
+		`, id, tier.MinQuantity, tier.DiscountPercent)
+		if err != nil {
+			return -1, errors.Join(fmt.Errorf("failed to insert promotion tier"), err)
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *MySQLPromotionRepository) GetPromotions(ctx context.Context) ([]entities.Promotion, error) {
+	return r.queryPromotions(ctx, `SELECT `+promotionColumns+` FROM promotions ORDER BY id`)
+}
+
+func (r *MySQLPromotionRepository) GetActivePromotions(ctx context.Context, now time.Time) ([]entities.Promotion, error) {
+	return r.queryPromotions(ctx, `
+		SELECT `+promotionColumns+`
+		FROM promotions
+		WHERE active = TRUE AND starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)
+		ORDER BY priority DESC, id
+	`, now, now)
+}
+
+func (r *MySQLPromotionRepository) GetPromotionByID(ctx context.Context, id int64) (*entities.Promotion, error) {
+	promotions, err := r.queryPromotions(ctx, `SELECT `+promotionColumns+` FROM promotions WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(promotions) == 0 {
+		return nil, nil
+	}
+
+	return &promotions[0], nil
+}
+
+func (r *MySQLPromotionRepository) DisablePromotion(ctx context.Context, id int64) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE promotions SET active = FALSE WHERE id = ?`, id)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to disable promotion"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLPromotionRepository) queryPromotions(ctx context.Context, query string, args ...any) ([]entities.Promotion, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query promotions"), err)
+	}
+	defer rows.Close()
+
+	promotions := make([]entities.Promotion, 0)
+	for rows.Next() {
+		var promotion entities.Promotion
+		var categoryID sql.NullInt64
+		var endsAt sql.NullTime
+
+		err = rows.Scan(
+			&promotion.ID,
+			&promotion.Name,
+			&promotion.Type,
+			&categoryID,
+			&promotion.BuyQuantity,
+			&promotion.PayQuantity,
+			&promotion.KitPrice,
+			&promotion.Priority,
+			&promotion.StartsAt,
+			&endsAt,
+			&promotion.Active,
+			&promotion.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan promotion"), err)
+		}
+
+		if categoryID.Valid {
+			promotion.CategoryID = &categoryID.Int64
+		}
+
+		if endsAt.Valid {
+			promotion.EndsAt = &endsAt.Time
+		}
+
+		promotions = append(promotions, promotion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to scan promotions"), err)
+	}
+
+	for i := range promotions {
+		if err = r.loadPromotionRules(ctx, &promotions[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return promotions, nil
+}
+
+// loadPromotionRules fills the products and quantity tiers of the given promotion
+func (r *MySQLPromotionRepository) loadPromotionRules(ctx context.Context, promotion *entities.Promotion) error {
+	productRows, err := r.DB.QueryContext(ctx, `
+		SELECT product_id, quantity FROM promotions_products WHERE promotion_id = ?
+	`, promotion.ID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to query promotion products"), err)
+	}
+	defer productRows.Close()
+
+	promotion.Products = make([]entities.PromotionProduct, 0)
+	for productRows.Next() {
+		var product entities.PromotionProduct
+		if err = productRows.Scan(&product.ProductID, &product.Quantity); err != nil {
+			return errors.Join(fmt.Errorf("failed to scan promotion product"), err)
+		}
+		promotion.Products = append(promotion.Products, product)
+	}
+
+	if err = productRows.Err(); err != nil {
+		return errors.Join(fmt.Errorf("failed to scan promotion products"), err)
+	}
+
+	tierRows, err := r.DB.QueryContext(ctx, `
+		SELECT min_quantity, discount_percent FROM promotions_tiers WHERE promotion_id = ? ORDER BY min_quantity
+	`, promotion.ID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to query promotion tiers"), err)
+	}
+	defer tierRows.Close()
+
+	promotion.Tiers = make([]entities.PromotionTier, 0)
+	for tierRows.Next() {
+		var tier entities.PromotionTier
+		if err = tierRows.Scan(&tier.MinQuantity, &tier.DiscountPercent); err != nil {
+			return errors.Join(fmt.Errorf("failed to scan promotion tier"), err)
+		}
+		promotion.Tiers = append(promotion.Tiers, tier)
+	}
+
+	return tierRows.Err()
+}
@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/infrastructure/datastore"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const reviewColumns = `id, product_id, user_id, rating, comment, status, created_at, modified_at`
+
+type MySQLReviewRepository struct {
+	DB *sql.DB
+}
+
+func NewMySQLReviewRepository(db *sql.DB) repositories.ReviewRepository {
+	return &MySQLReviewRepository{DB: db}
+}
+
+func (r *MySQLReviewRepository) AddReview(ctx context.Context, review *entities.Review) (int64, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO product_reviews (product_id, user_id, rating, comment, status)
+		VALUES (?, ?, ?, ?, ?);
+	`, review.ProductID, review.UserID, review.Rating, review.Comment, review.Status)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to insert review"), err)
+	}
+
+	return res.LastInsertId()
+}
+
+func (r *MySQLReviewRepository) GetReview(ctx context.Context, id int64) (*entities.Review, error) {
+	row := r.DB.QueryRowContext(ctx, `SELECT `+reviewColumns+` FROM product_reviews WHERE id = ?`, id)
+	return getReview(row)
+}
+
+func (r *MySQLReviewRepository) GetUserReview(ctx context.Context, userID, productID int64) (*entities.Review, error) {
+	row := r.DB.QueryRowContext(ctx, `
+		SELECT `+reviewColumns+` FROM product_reviews WHERE user_id = ? AND product_id = ?
+	`, userID, productID)
+	return getReview(row)
+}
+
+func (r *MySQLReviewRepository) GetProductReviews(
+	ctx context.Context,
+	productID int64,
+	status entities.ReviewStatus,
+	limit, offset int,
+) ([]entities.Review, int, error) {
+	var total int
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM product_reviews WHERE product_id = ? AND status = ?
+	`, productID, status).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.Join(fmt.Errorf("failed to count reviews"), err)
+	}
+
+	reviews, err := r.queryReviews(ctx, `
+		SELECT `+reviewColumns+`
+		FROM product_reviews
+		WHERE product_id = ? AND status = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, productID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, total, nil
+}
+
+func (r *MySQLReviewRepository) GetReviewsByStatus(ctx context.Context, status entities.ReviewStatus) ([]entities.Review, error) {
+	return r.queryReviews(ctx, `
+		SELECT `+reviewColumns+` FROM product_reviews WHERE status = ? ORDER BY created_at, id
+	`, status)
+}
+
+func (r *MySQLReviewRepository) GetReviewsByUser(ctx context.Context, userID int64) ([]entities.Review, error) {
+	return r.queryReviews(ctx, `
+		SELECT `+reviewColumns+` FROM product_reviews WHERE user_id = ? ORDER BY created_at, id
+	`, userID)
+}
+
+func (r *MySQLReviewRepository) UpdateReview(ctx context.Context, review *entities.Review) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE product_reviews SET rating = ?, comment = ?, status = ? WHERE id = ?
+	`, review.Rating, review.Comment, review.Status, review.ID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to update review"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLReviewRepository) UpdateReviewStatus(ctx context.Context, id int64, status entities.ReviewStatus) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE product_reviews SET status = ? WHERE id = ?`, status, id)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to update review status"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLReviewRepository) DeleteReview(ctx context.Context, id int64) error {
+	_, err := r.DB.ExecContext(ctx, `DELETE FROM product_reviews WHERE id = ?`, id)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to delete review"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLReviewRepository) queryReviews(ctx context.Context, query string, args ...any) ([]entities.Review, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query reviews"), err)
+	}
+	defer rows.Close()
+
+	reviews := make([]entities.Review, 0)
+	for rows.Next() {
+		var review entities.Review
+		if err = scanReview(rows, &review); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan review"), err)
+		}
+		reviews = append(reviews, review)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to scan reviews"), err)
+	}
+
+	return reviews, nil
+}
+
+func getReview(row *sql.Row) (*entities.Review, error) {
+	var review entities.Review
+	if err := scanReview(row, &review); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, errors.Join(fmt.Errorf("failed to scan review"), err)
+	}
+
+	return &review, nil
+}
+
+func scanReview(row rowScanner, review *entities.Review) error {
+	var comment sql.NullString
+	err := row.Scan(
+		&review.ID,
+		&review.ProductID,
+		&review.UserID,
+		&review.Rating,
+		&comment,
+		&review.Status,
+		&review.CreatedAt,
+		&review.ModifiedAt,
+	)
+	review.Comment = comment.String
+	return err
+}
@@ -0,0 +1,309 @@
+package repositories
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/infrastructure/datastore"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MySQLTokenRepository struct {
+	DB *sql.DB
+}
+
+func NewMySQLTokenRepository(db *sql.DB) repositories.TokenRepository {
+	return &MySQLTokenRepository{DB: db}
+}
+
+func (r *MySQLTokenRepository) AddRefreshToken(ctx context.Context, token *entities.RefreshToken) (int64, error) {
+	return insertRefreshToken(ctx, r.DB, token)
+}
+
+func (r *MySQLTokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*entities.RefreshToken, error) {
+	var token entities.RefreshToken
+	var revokedAt sql.NullTime
+
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, user_id, family_id, token_hash, access_token_id, access_token_expires_at,
+		       expires_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = ?
+	`, hash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.AccessTokenID,
+		&token.AccessTokenExpiresAt,
+		&token.ExpiresAt,
+		&revokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, errors.Join(fmt.Errorf("failed to query/scan refresh token"), err)
+	}
+
+	token.RevokedAt = nullTimePtr(revokedAt)
+	return &token, nil
+}
+
+// RotateRefreshToken revokes the old refresh token, and the access token issued with it, and
+// stores its replacement. It returns entities.ErrRefreshTokenReused if the old token was
+// already revoked, which also covers two concurrent refreshes with the same token.
+func (r *MySQLTokenRepository) RotateRefreshToken(ctx context.Context, old *entities.RefreshToken, token *entities.RefreshToken) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL
+	`, old.ID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to revoke refresh token"), err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to check revoked refresh token"), err)
+	}
+
+	if affected == 0 {
+		return entities.ErrRefreshTokenReused
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT IGNORE INTO revoked_tokens (token_id, expires_at) VALUES (?, ?)
+	`, old.AccessTokenID, old.AccessTokenExpiresAt)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to revoke access token"), err)
+	}
+
+	if _, err = insertRefreshToken(ctx, tx, token); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *MySQLTokenRepository) RevokeTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.revokeRefreshTokens(ctx, "family_id = ?", familyID)
+}
+
+func (r *MySQLTokenRepository) RevokeUserTokens(ctx context.Context, userID int64) error {
+	return r.revokeRefreshTokens(ctx, "user_id = ?", userID)
+}
+
+func (r *MySQLTokenRepository) RevokeAccessToken(ctx context.Context, tokenID uuid.UUID, expiresAt time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT IGNORE INTO revoked_tokens (token_id, expires_at) VALUES (?, ?)
+	`, tokenID, expiresAt)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to revoke access token"), err)
+	}
+
+	return nil
+}
+
+// IsTokenRevoked tells whether the access token, or the session it belongs to, was revoked
+func (r *MySQLTokenRepository) IsTokenRevoked(ctx context.Context, tokenID, sessionID uuid.UUID) (bool, error) {
+	var revoked bool
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE token_id = ?)
+		    OR EXISTS(SELECT 1 FROM sessions WHERE family_id = ? AND revoked_at IS NOT NULL)
+	`, tokenID, sessionID).Scan(&revoked)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check revoked token"), err)
+	}
+
+	return revoked, nil
+}
+
+// DeleteExpiredTokens removes the revoked access tokens and the refresh tokens that expired,
+// since expired tokens are rejected anyway, and the sessions left without refresh tokens
+func (r *MySQLTokenRepository) DeleteExpiredTokens(ctx context.Context, now time.Time) error {
+	if _, err := r.DB.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= ?`, now); err != nil {
+		return errors.Join(fmt.Errorf("failed to delete expired revoked tokens"), err)
+	}
+
+	if _, err := r.DB.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at <= ?`, now); err != nil {
+		return errors.Join(fmt.Errorf("failed to delete expired refresh tokens"), err)
+	}
+
+	_, err := r.DB.ExecContext(ctx, `
+		DELETE FROM sessions
+		WHERE NOT EXISTS(SELECT 1 FROM refresh_tokens rt WHERE rt.family_id = sessions.family_id)
+	`)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to delete expired sessions"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLTokenRepository) AddSession(ctx context.Context, session *entities.Session) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO sessions (family_id, user_id, device, ip_address, user_agent) VALUES (?, ?, ?, ?, ?)
+	`, session.ID, session.UserID, session.Device, session.IPAddress, session.UserAgent)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to add session"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLTokenRepository) GetSession(ctx context.Context, sessionID uuid.UUID) (*entities.Session, error) {
+	row := r.DB.QueryRowContext(ctx, `
+		SELECT family_id, user_id, device, ip_address, user_agent, created_at, last_seen_at, revoked_at
+		FROM sessions WHERE family_id = ?
+	`, sessionID)
+
+	var session entities.Session
+	if err := scanSession(row, &session); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, errors.Join(fmt.Errorf("failed to scan session"), err)
+	}
+
+	return &session, nil
+}
+
+// GetActiveSessions returns the sessions of the user that were not revoked and still hold a
+// valid refresh token, most recently seen first
+func (r *MySQLTokenRepository) GetActiveSessions(ctx context.Context, userID int64, now time.Time) ([]entities.Session, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT s.family_id, s.user_id, s.device, s.ip_address, s.user_agent, s.created_at, s.last_seen_at, s.revoked_at
+		FROM sessions s
+		WHERE s.user_id = ? AND s.revoked_at IS NULL
+		  AND EXISTS(
+		      SELECT 1 FROM refresh_tokens rt
+		      WHERE rt.family_id = s.family_id AND rt.revoked_at IS NULL AND rt.expires_at > ?
+		  )
+		ORDER BY s.last_seen_at DESC
+	`, userID, now)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query sessions"), err)
+	}
+	defer rows.Close()
+
+	sessions := make([]entities.Session, 0)
+	for rows.Next() {
+		var session entities.Session
+		if err = scanSession(rows, &session); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan session"), err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to scan sessions"), err)
+	}
+
+	return sessions, nil
+}
+
+// TouchSession records that the session was used, from the given IP address
+func (r *MySQLTokenRepository) TouchSession(ctx context.Context, sessionID uuid.UUID, ipAddress string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE sessions SET last_seen_at = CURRENT_TIMESTAMP, ip_address = ? WHERE family_id = ?
+	`, ipAddress, sessionID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to update session"), err)
+	}
+
+	return nil
+}
+
+// revokeRefreshTokens revokes the refresh tokens matching the condition, along with the access
+// tokens issued with them that did not expire yet and their sessions
+func (r *MySQLTokenRepository) revokeRefreshTokens(ctx context.Context, condition string, args ...any) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT IGNORE INTO revoked_tokens (token_id, expires_at)
+		SELECT access_token_id, access_token_expires_at
+		FROM refresh_tokens
+		WHERE `+condition+` AND access_token_expires_at > CURRENT_TIMESTAMP
+	`, args...)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to revoke access tokens"), err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE `+condition+` AND revoked_at IS NULL
+	`, args...)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to revoke refresh tokens"), err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE `+condition+` AND revoked_at IS NULL
+	`, args...)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to revoke sessions"), err)
+	}
+
+	return tx.Commit()
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertRefreshToken(ctx context.Context, db execer, token *entities.RefreshToken) (int64, error) {
+	res, err := db.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, access_token_id, access_token_expires_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?);
+	`,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.AccessTokenID,
+		token.AccessTokenExpiresAt,
+		token.ExpiresAt,
+	)
+	if err != nil {
+		return -1, errors.Join(fmt.Errorf("failed to insert refresh token"), err)
+	}
+
+	return res.LastInsertId()
+}
+
+func scanSession(row rowScanner, session *entities.Session) error {
+	var userAgent sql.NullString
+	var revokedAt sql.NullTime
+
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.Device,
+		&session.IPAddress,
+		&userAgent,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	session.UserAgent = userAgent.String
+	session.RevokedAt = nullTimePtr(revokedAt)
+	return nil
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"cachacariaapi/domain/entities"
 	"cachacariaapi/infrastructure/datastore"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -18,38 +19,56 @@ func NewMySQLUserRepository(db *sql.DB) repositories.UserRepository {
 	return &MySQLUserRepository{DB: db}
 }
 
-// GetAll users from the database, or an error if any occurs
-func (r *MySQLUserRepository) GetAll() ([]entities.User, error) {
-	var users []entities.User
+// Search returns a page of the users matching the filter, newest first, along with the total
+// number of matching users
+func (r *MySQLUserRepository) Search(ctx context.Context, filter entities.UserFilter) ([]entities.User, int, error) {
+	where := "WHERE 1 = 1"
+	var args []any
 
-	rows, err := r.DB.Query("SELECT id, uuid, email, password, phone, is_adm FROM users")
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return users, nil
-		}
+	if filter.Search != "" {
+		where += " AND (email LIKE ? OR phone LIKE ? OR name LIKE ?)"
+		pattern := "%" + filter.Search + "%"
+		args = append(args, pattern, pattern, pattern)
+	}
+
+	if filter.Role != "" {
+		where += " AND role = ?"
+		args = append(args, filter.Role)
+	}
+
+	if filter.Status != nil {
+		where += " AND status_code = ?"
+		args = append(args, *filter.Status)
+	}
 
-		return nil, errors.Join(fmt.Errorf("failed to query all users"), err)
+	var total int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM users "+where, args...).Scan(&total); err != nil {
+		return nil, 0, errors.Join(fmt.Errorf("failed to count users"), err)
 	}
 
+	query := "SELECT " + userColumns + " FROM users " +
+		where + " ORDER BY id DESC LIMIT ? OFFSET ?"
+
+	rows, err := r.DB.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, errors.Join(fmt.Errorf("failed to query users"), err)
+	}
 	defer rows.Close()
 
+	users := []entities.User{}
 	for rows.Next() {
 		var user entities.User
-		if err = rows.Scan(&user.ID, &user.UUID, &user.Email, &user.Password, &user.Phone, &user.IsAdm); err != nil {
-			return nil, errors.Join(fmt.Errorf("failed to scan users row"), err)
+		if err = scanUser(rows, &user); err != nil {
+			return nil, 0, errors.Join(fmt.Errorf("failed to scan users row"), err)
 		}
 		users = append(users, user)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, errors.Join(fmt.Errorf("failed to scan users row"), err)
-	}
-
-	if users == nil {
-		users = []entities.User{}
+		return nil, 0, errors.Join(fmt.Errorf("failed to scan users row"), err)
 	}
 
-	return users, nil
+	return users, total, nil
 }
 
 // Add a user to the database. Returns a UserResponse or an error if any occurs
@@ -58,8 +77,12 @@ func (r *MySQLUserRepository) Add(user entities.User) error {
 		user.UUID = uuid.New()
 	}
 
-	const query = "INSERT INTO users (uuid, email, password, phone, is_adm) VALUES (?, ?, ?, ?, ?)"
-	_, err := r.DB.Exec(query, user.UUID, user.Email, user.Password, user.Phone, user.IsAdm)
+	if user.Role == "" {
+		user.Role = entities.RoleCustomer
+	}
+
+	const query = "INSERT INTO users (uuid, email, password, phone, role) VALUES (?, ?, ?, ?, ?)"
+	_, err := r.DB.Exec(query, user.UUID, user.Email, user.Password, user.Phone, user.Role)
 	if err != nil {
 		return errors.Join(fmt.Errorf("failed to insert user"), err)
 	}
@@ -67,26 +90,35 @@ func (r *MySQLUserRepository) Add(user entities.User) error {
 	return nil
 }
 
-// Delete a user from the database with the given userId. Return an error if any occurs
-func (r *MySQLUserRepository) Delete(userId int64) error {
-	const query = "DELETE FROM users WHERE id = ?"
+// Delete a user from the database with the given userId, along with the emails still queued
+// for them. Return an error if any occurs
+func (r *MySQLUserRepository) Delete(ctx context.Context, userId int64) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	if err = deleteQueuedEmails(ctx, tx, userId); err != nil {
+		return err
+	}
 
-	_, err := r.DB.Exec(query, userId)
+	_, err = tx.ExecContext(ctx, "DELETE FROM users WHERE id = ?", userId)
 	if err != nil {
 		return errors.Join(fmt.Errorf("failed to delete user"), err)
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 // FindByEmail returns the user with the given email, or an error if any occurs
 func (r *MySQLUserRepository) FindByEmail(email string) (*entities.User, error) {
-	const query = "SELECT id, uuid, email, password, phone, is_adm FROM users WHERE email = ?"
+	const query = "SELECT " + userColumns + " FROM users WHERE email = ?"
 
 	row := r.DB.QueryRow(query, email)
 
 	var user entities.User
-	if err := row.Scan(&user.ID, &user.UUID, &user.Email, &user.Password, &user.Phone, &user.IsAdm); err != nil {
+	if err := scanUser(row, &user); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -98,12 +130,12 @@ func (r *MySQLUserRepository) FindByEmail(email string) (*entities.User, error)
 }
 
 func (r *MySQLUserRepository) FindByPhone(phone string) (*entities.User, error) {
-	const query = "SELECT id, uuid, email, password, phone, is_adm FROM users WHERE phone = ?"
+	const query = "SELECT " + userColumns + " FROM users WHERE phone = ?"
 
 	row := r.DB.QueryRow(query, phone)
 
 	var user entities.User
-	if err := row.Scan(&user.ID, &user.UUID, &user.Email, &user.Password, &user.Phone, &user.IsAdm); err != nil {
+	if err := scanUser(row, &user); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -116,12 +148,12 @@ func (r *MySQLUserRepository) FindByPhone(phone string) (*entities.User, error)
 
 // FindById returns the user with the given userId in the database, or an error if any occur
 func (r *MySQLUserRepository) FindById(userId int64) (*entities.User, error) {
-	const query = "SELECT id, uuid, email, password, phone, is_adm FROM users WHERE id = ?"
+	const query = "SELECT " + userColumns + " FROM users WHERE id = ?"
 
 	row := r.DB.QueryRow(query, userId)
 
 	var user entities.User
-	if err := row.Scan(&user.ID, &user.UUID, &user.Email, &user.Password, &user.Phone, &user.IsAdm); err != nil {
+	if err := scanUser(row, &user); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -132,18 +164,335 @@ func (r *MySQLUserRepository) FindById(userId int64) (*entities.User, error) {
 	return &user, nil
 }
 
-func (r *MySQLUserRepository) Update(user entities.User) error {
-	const query = "UPDATE users SET email = ?, phone = ?, is_adm = ? WHERE id = ?"
-	_, err := r.DB.Exec(
-		query,
-		user.Email,
-		user.Phone,
-		user.IsAdm,
-		user.ID,
-	)
+// Update stores the user's email and phone. A verified user whose email changed goes back to
+// unverified, and the verification links sent to the previous address stop working. The
+// verification of the new address, if any, is added and its email queued in the same
+// transaction.
+func (r *MySQLUserRepository) Update(
+	ctx context.Context,
+	user entities.User,
+	emailChanged bool,
+	verification *entities.EmailVerification,
+	notification *entities.Email,
+) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET email = ?, phone = ? WHERE id = ?", user.Email, user.Phone, user.ID)
 	if err != nil {
 		return errors.Join(fmt.Errorf("failed to update user"), err)
 	}
 
+	if emailChanged {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users SET status_code = ? WHERE id = ? AND status_code = ?
+		`, entities.UserStatusUnverified, user.ID, entities.UserStatusVerified)
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to reset user status"), err)
+		}
+
+		// Deleted rather than marked as used, so that they do not count as verified addresses
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM email_verifications WHERE user_id = ? AND used_at IS NULL
+		`, user.ID)
+		if err != nil {
+			return errors.Join(fmt.Errorf("failed to invalidate email verifications"), err)
+		}
+	}
+
+	if verification != nil && notification != nil {
+		if _, err = insertEmailVerification(ctx, tx, verification); err != nil {
+			return err
+		}
+
+		if err = insertOutboxEmail(ctx, tx, *notification); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateProfile stores the user's name, CPF, birth date and locale
+func (r *MySQLUserRepository) UpdateProfile(ctx context.Context, user entities.User) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE users SET name = NULLIF(?, ''), cpf = ?, birth_date = ?, locale = ? WHERE id = ?
+	`, user.Name, user.CPF, user.BirthDate, user.Locale, user.ID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to update user profile"), err)
+	}
+
+	return nil
+}
+
+// UpdateAvatar stores the filename of the user's avatar, or removes it if empty
+func (r *MySQLUserRepository) UpdateAvatar(ctx context.Context, userID int64, avatar string) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE users SET avatar = NULLIF(?, '') WHERE id = ?`, avatar, userID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to update user avatar"), err)
+	}
+
+	return nil
+}
+
+// FindByCPF returns the user with the given CPF, or nil if there is none
+func (r *MySQLUserRepository) FindByCPF(ctx context.Context, cpf string) (*entities.User, error) {
+	const query = "SELECT " + userColumns + " FROM users WHERE cpf = ?"
+
+	row := r.DB.QueryRowContext(ctx, query, cpf)
+
+	var user entities.User
+	if err := scanUser(row, &user); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, errors.Join(fmt.Errorf("failed to query user"), err)
+	}
+
+	return &user, nil
+}
+
+// ConfirmAge stores the user's CPF and birth date, marks the user's age as verified and keeps
+// the confirmation for audits
+func (r *MySQLUserRepository) ConfirmAge(ctx context.Context, confirmation *entities.AgeConfirmation) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET cpf = ?, birth_date = ?, age_verified_at = ? WHERE id = ?
+	`, confirmation.CPF, confirmation.BirthDate, confirmation.AcceptedAt, confirmation.UserID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to update user age"), err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO age_confirmations (user_id, cpf, birth_date, ip_address, user_agent, accepted_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`,
+		confirmation.UserID,
+		confirmation.CPF,
+		confirmation.BirthDate,
+		confirmation.IPAddress,
+		confirmation.UserAgent,
+		confirmation.AcceptedAt,
+	)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to insert age confirmation"), err)
+	}
+
+	return tx.Commit()
+}
+
+func (r *MySQLUserRepository) UpdateRole(ctx context.Context, userID int64, role entities.Role) error {
+	if _, err := r.DB.ExecContext(ctx, "UPDATE users SET role = ? WHERE id = ?", role, userID); err != nil {
+		return errors.Join(fmt.Errorf("failed to update user role"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLUserRepository) CountByRole(ctx context.Context, role entities.Role) (int, error) {
+	var count int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE role = ?", role).Scan(&count); err != nil {
+		return 0, errors.Join(fmt.Errorf("failed to count users by role"), err)
+	}
+
+	return count, nil
+}
+
+// Disable marks the user as disabled. It returns false if the user was already disabled.
+func (r *MySQLUserRepository) Disable(ctx context.Context, userID int64) (bool, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "UPDATE users SET status_code = ? WHERE id = ? AND status_code NOT IN (?, ?)",
+		entities.UserStatusDisabled, userID, entities.UserStatusDisabled, entities.UserStatusErased)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to disable user"), err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check disabled user"), err)
+	}
+
+	if affected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE email_verifications SET used_at = CURRENT_TIMESTAMP WHERE user_id = ? AND used_at IS NULL
+	`, userID)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to invalidate email verifications"), err)
+	}
+
+	return true, tx.Commit()
+}
+
+// Enable restores a disabled user, as verified if the user confirmed their current email. It
+// returns false if the user was not disabled.
+func (r *MySQLUserRepository) Enable(ctx context.Context, userID int64) (bool, error) {
+	const query = `
+		UPDATE users SET status_code = IF(verified_email = email, ?, ?)
+		WHERE id = ? AND status_code = ?
+	`
+
+	result, err := r.DB.ExecContext(ctx, query,
+		entities.UserStatusVerified, entities.UserStatusUnverified, userID, entities.UserStatusDisabled)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to enable user"), err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check enabled user"), err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *MySQLUserRepository) GetAgeConfirmations(ctx context.Context, userID int64) ([]entities.AgeConfirmation, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, user_id, cpf, birth_date, ip_address, user_agent, accepted_at
+		FROM age_confirmations WHERE user_id = ? ORDER BY accepted_at
+	`, userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query age confirmations"), err)
+	}
+	defer rows.Close()
+
+	confirmations := make([]entities.AgeConfirmation, 0)
+	for rows.Next() {
+		var confirmation entities.AgeConfirmation
+		var ipAddress, userAgent sql.NullString
+		err = rows.Scan(
+			&confirmation.ID,
+			&confirmation.UserID,
+			&confirmation.CPF,
+			&confirmation.BirthDate,
+			&ipAddress,
+			&userAgent,
+			&confirmation.AcceptedAt,
+		)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan age confirmation"), err)
+		}
+		confirmation.IPAddress = ipAddress.String
+		confirmation.UserAgent = userAgent.String
+		confirmations = append(confirmations, confirmation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to scan age confirmations"), err)
+	}
+
+	return confirmations, nil
+}
+
+// Anonymize erases the personal data of the user while keeping the account row, which the orders
+// reference. The age confirmations are kept along with the orders, as proof that the sales were
+// legal. Everything else tied to the user is deleted.
+func (r *MySQLUserRepository) Anonymize(ctx context.Context, userID int64, email string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to begin transaction"), err)
+	}
+	defer tx.Rollback()
+
+	if err = deleteQueuedEmails(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	statements := []string{
+		`DELETE FROM login_failures WHERE email = (SELECT email FROM users WHERE id = ?)`,
+		`DELETE FROM sessions WHERE user_id = ?`,
+		`DELETE FROM refresh_tokens WHERE user_id = ?`,
+		`DELETE FROM password_resets WHERE user_id = ?`,
+		`DELETE FROM email_verifications WHERE user_id = ?`,
+		`DELETE FROM recovery_codes WHERE user_id = ?`,
+		`DELETE FROM user_two_factor WHERE user_id = ?`,
+		`DELETE FROM user_identities WHERE user_id = ?`,
+		`DELETE FROM user_consents WHERE user_id = ?`,
+		`DELETE FROM api_keys WHERE user_id = ?`,
+		`DELETE FROM carts_coupons WHERE user_id = ?`,
+		`DELETE FROM carts_products WHERE user_id = ?`,
+		`DELETE FROM cart_reminders WHERE user_id = ?`,
+		`DELETE FROM wishlists_products WHERE user_id = ?`,
+		`DELETE FROM product_reviews WHERE user_id = ?`,
+		`DELETE FROM notifications WHERE user_id = ?`,
+		`DELETE FROM notification_preferences WHERE user_id = ?`,
+	}
+
+	for _, statement := range statements {
+		if _, err = tx.ExecContext(ctx, statement, userID); err != nil {
+			return errors.Join(fmt.Errorf("failed to erase user data"), err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET email = ?, verified_email = NULL, password = '', name = NULL, phone = '', role = ?, status_code = ?, cpf = NULL,
+		    birth_date = NULL, avatar = NULL
+		WHERE id = ?
+	`, email, entities.RoleCustomer, entities.UserStatusErased, userID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to anonymize user"), err)
+	}
+
+	return tx.Commit()
+}
+
+// deleteQueuedEmails drops the emails not sent yet to the user's current address, so that no
+// personal data is mailed after the account is erased
+func deleteQueuedEmails(ctx context.Context, exec execer, userID int64) error {
+	_, err := exec.ExecContext(ctx, `
+		DELETE FROM email_outbox
+		WHERE recipient = (SELECT email FROM users WHERE id = ?) AND status <> ?
+	`, userID, entities.OutboxStatusSent)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to delete queued emails"), err)
+	}
+
+	return nil
+}
+
+const userColumns = "id, uuid, email, password, name, phone, role, status_code, cpf, birth_date, age_verified_at, avatar, locale"
+
+// scanUser scans a row selecting the userColumns
+func scanUser(row rowScanner, user *entities.User) error {
+	var name, avatar sql.NullString
+
+	err := row.Scan(
+		&user.ID,
+		&user.UUID,
+		&user.Email,
+		&user.Password,
+		&name,
+		&user.Phone,
+		&user.Role,
+		&user.Status,
+		&user.CPF,
+		&user.BirthDate,
+		&user.AgeVerifiedAt,
+		&avatar,
+		&user.Locale,
+	)
+	if err != nil {
+		return err
+	}
+
+	user.Name = name.String
+	user.Avatar = avatar.String
 	return nil
 }
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/infrastructure/datastore"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type MySQLWishlistRepository struct {
+	DB *sql.DB
+}
+
+func NewMySQLWishlistRepository(db *sql.DB) repositories.WishlistRepository {
+	return &MySQLWishlistRepository{DB: db}
+}
+
+// AddToWishlist favorites the product for the user; favoriting it again is a no-op
+func (r *MySQLWishlistRepository) AddToWishlist(ctx context.Context, userID, productID int64) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT IGNORE INTO wishlists_products (user_id, product_id) VALUES (?, ?);
+	`, userID, productID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to insert wishlist item"), err)
+	}
+
+	return nil
+}
+
+func (r *MySQLWishlistRepository) GetWishlistItems(ctx context.Context, userID int64) ([]entities.WishlistItem, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT
+			w.user_id, w.product_id, w.created_at,
+			p.id, p.category_id, p.name, p.description, p.price, p.original_price, p.stock,
+			GROUP_CONCAT(pp.filename) AS photos
+		FROM wishlists_products w
+		JOIN products p ON w.product_id = p.id
+		LEFT JOIN products_photos pp ON pp.product_id = p.id
+		WHERE w.user_id = ?
+		GROUP BY w.user_id, w.product_id, p.id
+		ORDER BY w.created_at DESC;
+	`, userID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query wishlist items"), err)
+	}
+	defer rows.Close()
+
+	items := make([]entities.WishlistItem, 0)
+	for rows.Next() {
+		var item entities.WishlistItem
+		var product entities.Product
+		var categoryID sql.NullInt64
+		var originalPrice sql.NullFloat64
+		var photosStr sql.NullString
+
+		err = rows.Scan(
+			&item.UserID,
+			&item.ProductID,
+			&item.CreatedAt,
+			&product.ID,
+			&categoryID,
+			&product.Name,
+			&product.Description,
+			&product.Price,
+			&originalPrice,
+			&product.Stock,
+			&photosStr,
+		)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan wishlist item"), err)
+		}
+
+		if categoryID.Valid {
+			product.CategoryID = &categoryID.Int64
+		}
+
+		if originalPrice.Valid {
+			price := float32(originalPrice.Float64)
+			product.OriginalPrice = &price
+		}
+
+		if photosStr.Valid {
+			product.Photos = strings.Split(photosStr.String, ",")
+		} else {
+			product.Photos = []string{}
+		}
+
+		item.Product = &product
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *MySQLWishlistRepository) IsInWishlist(ctx context.Context, userID, productID int64) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM wishlists_products WHERE user_id = ? AND product_id = ?)
+	`, userID, productID).Scan(&exists)
+	if err != nil {
+		return false, errors.Join(fmt.Errorf("failed to check wishlist item"), err)
+	}
+
+	return exists, nil
+}
+
+func (r *MySQLWishlistRepository) RemoveFromWishlist(ctx context.Context, userID, productID int64) error {
+	_, err := r.DB.ExecContext(ctx, `
+		DELETE FROM wishlists_products WHERE user_id = ? AND product_id = ?;
+	`, userID, productID)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to delete wishlist item"), err)
+	}
+
+	return nil
+}
+
+// GetWishlistUserIDs returns the users who favorited the product
+func (r *MySQLWishlistRepository) GetWishlistUserIDs(ctx context.Context, productID int64) ([]int64, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT user_id FROM wishlists_products WHERE product_id = ?;
+	`, productID)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to query wishlist users"), err)
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err = rows.Scan(&userID); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to scan wishlist user"), err)
+		}
+
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
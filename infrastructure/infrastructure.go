@@ -1,11 +1,15 @@
 package infrastructure
 
 import (
+	"cachacariaapi/domain/entities"
 	"cachacariaapi/domain/usecases"
+	util2 "cachacariaapi/domain/util"
 	"cachacariaapi/infrastructure/config"
 	"cachacariaapi/infrastructure/datastore/repositories"
+	"cachacariaapi/infrastructure/jobs"
 	"cachacariaapi/infrastructure/modules"
 	"cachacariaapi/infrastructure/util"
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -30,31 +34,112 @@ func Init() {
 		slog.Error("failed to connect to database", "error", err)
 	}
 
+	conn := cfg.Database.Conn
+
 	// Config utils
-	authManager := util.NewAuthManager(cfg.SymmetricKey)
+	if err = util.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		slog.Error("failed to set trusted proxies", "error", err)
+	}
 
-	conn := cfg.Database.Conn
+	tokenRepository := repositories.NewMySQLTokenRepository(conn)
+	apiKeyRepository := repositories.NewMySQLAPIKeyRepository(conn)
+	authManager := util.NewAuthManager(cfg.SymmetricKey, tokenRepository, apiKeyRepository, cfg.Auth.RequireAdminTwoFactor)
 
 	// Repositories
 	authRepository := repositories.NewMySQLAuthRepository(conn)
+	loginAttemptRepository := repositories.NewMySQLLoginAttemptRepository(conn)
 	userRepository := repositories.NewMySQLUserRepository(conn)
 	productRepository := repositories.NewMySQLProductRepository(conn)
 	cartRepository := repositories.NewMySQLCartRepository(conn)
 	orderRepository := repositories.NewMYSQLOrderRepository(conn)
+	couponRepository := repositories.NewMySQLCouponRepository(conn)
+	promotionRepository := repositories.NewMySQLPromotionRepository(conn)
+	reviewRepository := repositories.NewMySQLReviewRepository(conn)
+	wishlistRepository := repositories.NewMySQLWishlistRepository(conn)
+	oidcRepository := repositories.NewMySQLOIDCRepository(conn)
+	consentRepository := repositories.NewMySQLConsentRepository(conn)
+	emailOutboxRepository := repositories.NewMySQLEmailOutboxRepository(conn)
+	notificationRepository := repositories.NewMySQLNotificationRepository(conn)
 
 	// Use Cases
-	authUseCases := usecases.NewAuthUseCases(authRepository, userRepository, authManager, cfg.Email)
-	userUseCases := usecases.NewUserUseCases(userRepository, authRepository, authManager)
-	productUseCases := usecases.NewProductUseCases(productRepository, cfg.Server.BaseURL)
-	cartUseCases := usecases.NewCartUseCases(cartRepository, userRepository, productRepository, orderRepository, cfg.Server.BaseURL)
+	emailTemplates := util2.NewEmailTemplates(cfg.Email.TemplatesDir)
+	emailUseCases := usecases.NewEmailUseCases(emailOutboxRepository, util2.NewMailer(cfg.Email), emailTemplates)
+	notificationUseCases := usecases.NewNotificationUseCases(notificationRepository, userRepository, productRepository, wishlistRepository, emailTemplates, cfg.Server.BaseURL)
+	consentUseCases := usecases.NewConsentUseCases(consentRepository, emailOutboxRepository, legalVersions(cfg))
+	authUseCases := usecases.NewAuthUseCases(authRepository, userRepository, tokenRepository, loginAttemptRepository, consentUseCases, authManager, emailTemplates, cfg.Server.BaseURL)
+	userUseCases := usecases.NewUserUseCases(userRepository, authRepository, orderRepository, tokenRepository, authUseCases, authManager, cfg.Server.BaseURL)
+	productUseCases := usecases.NewProductUseCases(productRepository, notificationUseCases, cfg.Server.BaseURL)
+	orderUseCases := usecases.NewOrderUseCases(orderRepository, userRepository, productRepository, notificationUseCases, emailTemplates, cfg.Server.BaseURL)
+	cartUseCases := usecases.NewCartUseCases(cartRepository, userRepository, productRepository, orderRepository, couponRepository, promotionRepository, orderUseCases, consentUseCases, emailTemplates, cartReminderPolicy(cfg), cfg.Server.BaseURL)
+	couponUseCases := usecases.NewCouponUseCases(couponRepository)
+	promotionUseCases := usecases.NewPromotionUseCases(promotionRepository, productRepository)
+	reviewUseCases := usecases.NewReviewUseCases(reviewRepository, productRepository, orderRepository, notificationUseCases)
+	wishlistUseCases := usecases.NewWishlistUseCases(wishlistRepository, productRepository, cartUseCases, cfg.Server.BaseURL)
+	roleUseCases := usecases.NewRoleUseCases(userRepository, tokenRepository)
+	apiKeyUseCases := usecases.NewAPIKeyUseCases(apiKeyRepository, authManager)
+	privacyUseCases := usecases.NewPrivacyUseCases(userRepository, tokenRepository, oidcRepository, consentRepository, apiKeyRepository, cartRepository, wishlistRepository, reviewRepository, notificationRepository, cartUseCases, cfg.Server.BaseURL)
+	oidcUseCases := usecases.NewOIDCUseCases(oidcProviders(cfg), oidcRepository, authRepository, authUseCases, consentUseCases, authManager)
+
+	// Background jobs
+	jobs.Start(context.Background(),
+		jobs.Job{
+			Name:     "product price scheduler",
+			Interval: cfg.Jobs.PriceSchedulerInterval,
+			Run:      productUseCases.ApplyScheduledPrices,
+		},
+		jobs.Job{
+			Name:     "expired token cleanup",
+			Interval: cfg.Jobs.TokenCleanupInterval,
+			Run:      authUseCases.DeleteExpiredTokens,
+		},
+		jobs.Job{
+			Name:     "expired login failure cleanup",
+			Interval: cfg.Jobs.TokenCleanupInterval,
+			Run:      authUseCases.DeleteExpiredLoginFailures,
+		},
+		jobs.Job{
+			Name:     "expired oidc state cleanup",
+			Interval: cfg.Jobs.TokenCleanupInterval,
+			Run:      oidcUseCases.DeleteExpiredStates,
+		},
+		jobs.Job{
+			Name:     "email outbox delivery",
+			Interval: cfg.Jobs.EmailOutboxInterval,
+			Run:      emailUseCases.DeliverPendingEmails,
+		},
+		jobs.Job{
+			Name:     "sent email cleanup",
+			Interval: cfg.Jobs.TokenCleanupInterval,
+			Run:      emailUseCases.DeleteSentEmails,
+		},
+		jobs.Job{
+			Name:     "abandoned cart reminders",
+			Interval: cfg.Jobs.CartReminderInterval,
+			Run:      cartUseCases.SendCartReminders,
+		},
+		jobs.Job{
+			Name:     "read notification cleanup",
+			Interval: cfg.Jobs.TokenCleanupInterval,
+			Run:      notificationUseCases.DeleteReadNotifications,
+		},
+	)
 
 	// Modules
 	healthModule := modules.NewHealthModule()
-	authModule := modules.NewAuthModule(authUseCases)
-	userModule := modules.NewUserModule(userUseCases, authUseCases)
+	authModule := modules.NewAuthModule(authUseCases, authManager)
+	userModule := modules.NewUserModule(userUseCases, authUseCases, privacyUseCases, consentUseCases, authManager)
 	productModule := modules.NewProductModule(productUseCases, authManager)
 	cartModule := modules.NewCartModule(cartUseCases, authManager)
-	orderModule := modules.NewOrderModule(cartUseCases, authManager)
+	orderModule := modules.NewOrderModule(cartUseCases, orderUseCases, authManager)
+	couponModule := modules.NewCouponModule(couponUseCases, authManager)
+	promotionModule := modules.NewPromotionModule(promotionUseCases, authManager)
+	reviewModule := modules.NewReviewModule(reviewUseCases, authManager)
+	wishlistModule := modules.NewWishlistModule(wishlistUseCases, authManager)
+	roleModule := modules.NewRoleModule(roleUseCases, authManager)
+	oidcModule := modules.NewOIDCModule(oidcUseCases)
+	apiKeyModule := modules.NewAPIKeyModule(apiKeyUseCases, authManager)
+	emailModule := modules.NewEmailModule(emailUseCases, authManager)
+	notificationModule := modules.NewNotificationModule(notificationUseCases, authManager)
 
 	// Assign a router to the server
 	router := mux.NewRouter()
@@ -67,7 +152,7 @@ func Init() {
 	cfg.Server.RegisterModules(server.Router, healthModule)
 
 	// Register modules
-	cfg.Server.RegisterModules(apiSubrouter, authModule, userModule, productModule, cartModule, orderModule)
+	cfg.Server.RegisterModules(apiSubrouter, authModule, userModule, productModule, cartModule, orderModule, couponModule, promotionModule, reviewModule, wishlistModule, roleModule, oidcModule, apiKeyModule, emailModule, notificationModule)
 
 	slog.Info(fmt.Sprintf("server running on port %d", cfg.Server.Port))
 	
@@ -77,3 +162,35 @@ func Init() {
 		panic(err)
 	}
 }
+
+// legalVersions returns the versions of the terms and privacy policy users must accept
+func legalVersions(cfg *config.Config) entities.LegalVersions {
+	return entities.LegalVersions{
+		TermsVersion:         cfg.Legal.TermsVersion,
+		PrivacyPolicyVersion: cfg.Legal.PrivacyPolicyVersion,
+	}
+}
+
+func cartReminderPolicy(cfg *config.Config) entities.CartReminderPolicy {
+	return entities.CartReminderPolicy{
+		AbandonedAfter: cfg.CartReminder.AbandonedAfter,
+		MaxReminders:   cfg.CartReminder.MaxReminders,
+	}
+}
+
+// oidcProviders creates the enabled OpenID Connect providers, which redirect the users back to
+// /api/auth/oidc/{provider}/callback
+func oidcProviders(cfg *config.Config) map[string]*util.OIDCProvider {
+	providers := make(map[string]*util.OIDCProvider)
+	for name, provider := range cfg.OIDC {
+		if provider.ClientID == "" {
+			continue
+		}
+
+		redirectURL := fmt.Sprintf("%s/api/auth/oidc/%s/callback", cfg.Server.BaseURL, name)
+		providers[name] = util.NewOIDCProvider(name, provider.Issuer, provider.ClientID, provider.ClientSecret, redirectURL)
+		slog.Info("oidc provider enabled", "provider", name)
+	}
+
+	return providers
+}
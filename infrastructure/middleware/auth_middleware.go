@@ -1,58 +1,150 @@
 package middleware
 
 import (
+	"cachacariaapi/domain/entities"
 	"cachacariaapi/infrastructure/util"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
 )
 
-func AuthMiddlewareWithAdmin(authManager util.AuthManager, adminOnly bool) func(http.HandlerFunc) http.HandlerFunc {
+// AuthMiddleware only lets authenticated users through, adding them to the request context.
+// API keys are refused: they only open the routes of the permissions they were given.
+func AuthMiddleware(authManager util.AuthManager) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			authHeader := r.Header.Get("Authorization")
+			payload, ok := authenticate(authManager, w, r)
+			if !ok {
+				return
+			}
 
-			if authHeader == "" {
-				slog.Info("No Authorization header")
+			if payload.APIKeyID != 0 {
+				slog.Info("API key used on a user route", "api_key_id", payload.APIKeyID)
 				util.WriteResponse(w, util.ServerResponse{
-					Status:  http.StatusUnauthorized,
-					Message: "Sem autorização",
+					Status:  http.StatusForbidden,
+					Message: "Chaves de API não podem acessar esta rota",
 				})
 				return
 			}
 
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-				slog.Info("Invalid Authorization header format")
-				util.WriteResponse(w, util.ServerResponse{
-					Status:  http.StatusUnauthorized,
-					Message: "Sem autorização",
-				})
+			ctx := util.NewContextWithUser(r.Context(), payload)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+	}
+}
+
+// RequirePermission only lets through authenticated users whose role grants the permission,
+// and API keys that were also given it. When two-factor authentication is mandatory for staff,
+// the token must come from a two-factor login; API keys can only be created after one.
+func RequirePermission(authManager util.AuthManager, permission entities.Permission) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			payload, ok := authenticate(authManager, w, r)
+			if !ok {
 				return
 			}
 
-			token := parts[1]
-			payload, err := authManager.VerifyToken(token)
-			if err != nil {
-				slog.Info("Invalid token")
+			ctx := util.NewContextWithUser(r.Context(), payload)
+			user, _ := util.GetUserFromContext(ctx)
+
+			if !user.HasPermission(permission) {
+				slog.Info("Missing permission", "user_id", payload.UserID, "role", payload.Role, "api_key_id", payload.APIKeyID, "permission", permission)
 				util.WriteResponse(w, util.ServerResponse{
-					Status:  http.StatusUnauthorized,
-					Message: "Sem autorização",
+					Status:  http.StatusForbidden,
+					Message: "Sem permissão",
 				})
 				return
 			}
 
-			if adminOnly && !payload.IsAdmin {
-				slog.Info("Not admin")
+			if authManager.RequireAdminTwoFactor() && payload.APIKeyID == 0 && !payload.TwoFactor {
+				slog.Info("Staff without two-factor authentication")
 				util.WriteResponse(w, util.ServerResponse{
-					Status:  http.StatusUnauthorized,
-					Message: "Sem autorização",
+					Status:  http.StatusForbidden,
+					Message: "Ative a autenticação em dois fatores para acessar a área administrativa",
 				})
 				return
 			}
 
-			ctx := util.NewContextWithUser(r.Context(), payload.UserID, payload.IsAdmin)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		}
 	}
 }
+
+// authenticate verifies the bearer token or API key of the request, answering with an error if
+// it is missing, invalid or revoked
+func authenticate(authManager util.AuthManager, w http.ResponseWriter, r *http.Request) (*util.Payload, bool) {
+	authHeader := r.Header.Get("Authorization")
+
+	if authHeader == "" {
+		slog.Info("No Authorization header")
+		util.WriteResponse(w, util.ServerResponse{
+			Status:  http.StatusUnauthorized,
+			Message: "Sem autorização",
+		})
+		return nil, false
+	}
+
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) == 2 && strings.EqualFold(parts[0], util.APIKeyScheme) {
+		return authenticateAPIKey(authManager, w, r, parts[1])
+	}
+
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		slog.Info("Invalid Authorization header format")
+		util.WriteResponse(w, util.ServerResponse{
+			Status:  http.StatusUnauthorized,
+			Message: "Sem autorização",
+		})
+		return nil, false
+	}
+
+	token := parts[1]
+	payload, err := authManager.VerifyToken(token)
+	if err != nil {
+		slog.Info("Invalid token")
+		util.WriteResponse(w, util.ServerResponse{
+			Status:  http.StatusUnauthorized,
+			Message: "Sem autorização",
+		})
+		return nil, false
+	}
+
+	revoked, err := authManager.IsTokenRevoked(r.Context(), payload)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to check token revocation", "cause", err)
+		util.WriteInternalError(w)
+		return nil, false
+	}
+
+	if revoked {
+		slog.Info("Revoked token")
+		util.WriteResponse(w, util.ServerResponse{
+			Status:  http.StatusUnauthorized,
+			Message: "Sem autorização",
+		})
+		return nil, false
+	}
+
+	return payload, true
+}
+
+func authenticateAPIKey(authManager util.AuthManager, w http.ResponseWriter, r *http.Request, key string) (*util.Payload, bool) {
+	payload, err := authManager.VerifyAPIKey(r.Context(), key, util.GetClientIP(r))
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidToken) || errors.Is(err, util.ErrExpiredToken) {
+			slog.Info("Invalid API key")
+			util.WriteResponse(w, util.ServerResponse{
+				Status:  http.StatusUnauthorized,
+				Message: "Sem autorização",
+			})
+			return nil, false
+		}
+
+		slog.ErrorContext(r.Context(), "failed to verify api key", "cause", err)
+		util.WriteInternalError(w)
+		return nil, false
+	}
+
+	return payload, true
+}
@@ -0,0 +1,131 @@
+package modules
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/usecases"
+	"cachacariaapi/infrastructure/middleware"
+	"cachacariaapi/infrastructure/util"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// APIKeyResponse carries the created API key, shown once
+type APIKeyResponse struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	*entities.CreatedAPIKey
+}
+
+// APIKeyModule manages the API keys of the logged in user
+type APIKeyModule struct {
+	apiKeyUseCases usecases.APIKeyUseCases
+	authManager    util.AuthManager
+	name           string
+	path           string
+}
+
+func NewAPIKeyModule(apiKeyUseCases usecases.APIKeyUseCases, authManager util.AuthManager) Module {
+	return APIKeyModule{
+		apiKeyUseCases: apiKeyUseCases,
+		authManager:    authManager,
+		name:           "api-keys",
+		path:           "/auth/api-keys",
+	}
+}
+
+func (m APIKeyModule) Name() string { return m.name }
+func (m APIKeyModule) Path() string { return m.path }
+
+func (m APIKeyModule) RegisterRoutes(router *mux.Router) {
+	auth := middleware.AuthMiddleware(m.authManager)
+
+	routes := []ModuleRoute{
+		{
+			Name:    "GetAPIKeys",
+			Path:    "",
+			Handler: auth(m.getAPIKeys),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "CreateAPIKey",
+			Path:    "",
+			Handler: auth(m.createAPIKey),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "RevokeAPIKey",
+			Path:    "/{id:[0-9]+}",
+			Handler: auth(m.revokeAPIKey),
+			Methods: []string{http.MethodDelete},
+		},
+	}
+
+	for _, route := range routes {
+		router.HandleFunc(m.path+route.Path, route.Handler).Methods(route.Methods...)
+	}
+}
+
+func (m APIKeyModule) getAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	keys, err := m.apiKeyUseCases.GetAPIKeys(ctx, int64(user.UserID))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get api keys", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, keys)
+}
+
+func (m APIKeyModule) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	var req entities.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	key, status, err := m.apiKeyUseCases.CreateAPIKey(ctx, user, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create api key", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, APIKeyResponse{
+		Status:        status.Int(),
+		Message:       status.String(),
+		CreatedAPIKey: key,
+	})
+}
+
+func (m APIKeyModule) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.apiKeyUseCases.RevokeAPIKey(ctx, int64(user.UserID), id)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to revoke api key", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
@@ -3,30 +3,63 @@ package modules
 import (
 	"cachacariaapi/domain/entities"
 	"cachacariaapi/domain/usecases"
+	"cachacariaapi/infrastructure/middleware"
 	"cachacariaapi/infrastructure/util"
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
 type AuthResponse struct {
+	Status       int        `json:"status"`
+	Message      string     `json:"message"`
+	Token        string     `json:"token,omitempty"`
+	RefreshToken string     `json:"refresh_token,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// withTokens fills the response with the issued tokens, if any
+func (r AuthResponse) withTokens(tokens *entities.AuthTokens) AuthResponse {
+	if tokens != nil {
+		r.Token = tokens.AccessToken
+		r.RefreshToken = tokens.RefreshToken
+		r.ExpiresAt = &tokens.ExpiresAt
+	}
+
+	return r
+}
+
+// TwoFactorSetupResponse carries the TOTP secret, shown once during the enrollment
+type TwoFactorSetupResponse struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	*entities.TwoFactorSetup
+}
+
+// RecoveryCodesResponse carries the recovery codes, shown once when generated
+type RecoveryCodesResponse struct {
 	Status  int    `json:"status"`
 	Message string `json:"message"`
-	Token   string `json:"token,omitempty"`
+	*entities.RecoveryCodes
 }
 
 type AuthModule struct {
 	authUseCases usecases.AuthUseCases
+	authManager  util.AuthManager
 	name         string
 	path         string
 }
 
-func NewAuthModule(authUseCases usecases.AuthUseCases) Module {
+func NewAuthModule(authUseCases usecases.AuthUseCases, authManager util.AuthManager) Module {
 	return AuthModule{
 		authUseCases: authUseCases,
+		authManager:  authManager,
 		name:         "auth",
 		path:         "/auth",
 	}
@@ -41,6 +74,10 @@ func (a AuthModule) Path() string {
 }
 
 func (a AuthModule) RegisterRoutes(router *mux.Router) {
+	auth := middleware.AuthMiddleware(a.authManager)
+	revokeSessions := middleware.RequirePermission(a.authManager, entities.PermissionSessionRevoke)
+	writeUsers := middleware.RequirePermission(a.authManager, entities.PermissionUserWrite)
+
 	routes := []ModuleRoute{
 		{
 			Name:    "Login",
@@ -54,6 +91,12 @@ func (a AuthModule) RegisterRoutes(router *mux.Router) {
 			Handler: a.register,
 			Methods: []string{http.MethodPost},
 		},
+		{
+			Name:    "LegalVersions",
+			Path:    "/legal",
+			Handler: a.legalVersions,
+			Methods: []string{http.MethodGet},
+		},
 		{
 			Name:    "Change pasword",
 			Path:    "/changePassword",
@@ -66,6 +109,96 @@ func (a AuthModule) RegisterRoutes(router *mux.Router) {
 			Handler: a.getData,
 			Methods: []string{http.MethodGet},
 		},
+		{
+			Name:    "Refresh",
+			Path:    "/refresh",
+			Handler: a.refresh,
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "Logout",
+			Path:    "/logout",
+			Handler: a.logout,
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "Forgot password",
+			Path:    "/forgot-password",
+			Handler: a.forgotPassword,
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "Reset password",
+			Path:    "/reset-password",
+			Handler: a.resetPassword,
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "Verify email",
+			Path:    "/verify-email",
+			Handler: a.verifyEmail,
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "Resend email verification",
+			Path:    "/verify-email/resend",
+			Handler: a.resendVerification,
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "Setup two-factor authentication",
+			Path:    "/2fa/setup",
+			Handler: a.setupTwoFactor,
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "Enable two-factor authentication",
+			Path:    "/2fa/enable",
+			Handler: a.enableTwoFactor,
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "Disable two-factor authentication",
+			Path:    "/2fa/disable",
+			Handler: a.disableTwoFactor,
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "Regenerate recovery codes",
+			Path:    "/2fa/recovery-codes",
+			Handler: a.regenerateRecoveryCodes,
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "List sessions",
+			Path:    "/sessions",
+			Handler: auth(a.getSessions),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "Revoke session",
+			Path:    "/sessions/{session_id}",
+			Handler: auth(a.revokeSession),
+			Methods: []string{http.MethodDelete},
+		},
+		{
+			Name:    "Log out everywhere",
+			Path:    "/sessions",
+			Handler: auth(a.revokeAllSessions),
+			Methods: []string{http.MethodDelete},
+		},
+		{
+			Name:    "Revoke user tokens",
+			Path:    "/revoke/{user_id:[0-9]+}",
+			Handler: revokeSessions(a.revokeUserTokens),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "Unlock user login",
+			Path:    "/unlock/{user_id:[0-9]+}",
+			Handler: writeUsers(a.unlockUser),
+			Methods: []string{http.MethodPost},
+		},
 	}
 
 	for _, route := range routes {
@@ -83,7 +216,7 @@ func (a AuthModule) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, statusCode, err := a.authUseCases.AttemptLogin(ctx, credentials)
+	tokens, statusCode, err := a.authUseCases.AttemptLogin(ctx, credentials, util.GetSessionClient(r))
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to login", "cause", err)
 		util.WriteInternalError(w)
@@ -93,8 +226,7 @@ func (a AuthModule) login(w http.ResponseWriter, r *http.Request) {
 	response := AuthResponse{
 		Status:  statusCode.Int(),
 		Message: statusCode.String(),
-		Token:   token,
-	}
+	}.withTokens(tokens)
 
 	util.Write(w, response)
 }
@@ -103,7 +235,7 @@ func (a AuthModule) changePassword(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	token := util.GetAuthTokenFromRequest(r)
-	user, err := a.authUseCases.GetUserByAuthToken(token)
+	user, err := a.authUseCases.GetUserByAuthToken(ctx, token)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to get user by auth token", "cause", err)
 		util.WriteInternalError(w)
@@ -150,7 +282,7 @@ func (a AuthModule) register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, status, err := a.authUseCases.RegisterUser(ctx, credentials)
+	tokens, status, err := a.authUseCases.RegisterUser(ctx, credentials, util.GetSessionClient(r))
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to register user", "cause", err)
 		util.WriteInternalError(w)
@@ -160,18 +292,21 @@ func (a AuthModule) register(w http.ResponseWriter, r *http.Request) {
 	response := AuthResponse{
 		Status:  status.Int(),
 		Message: status.String(),
-		Token:   token,
-	}
+	}.withTokens(tokens)
 
 	util.Write(w, response)
 }
 
+func (a AuthModule) legalVersions(w http.ResponseWriter, r *http.Request) {
+	util.Write(w, a.authUseCases.LegalVersions())
+}
+
 func (a AuthModule) getData(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	token := util.GetAuthTokenFromRequest(r)
 
-	user, err := a.authUseCases.GetUserByAuthToken(token)
+	user, err := a.authUseCases.GetUserByAuthToken(ctx, token)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to get user by auth token", "cause", err)
 		util.WriteResponse(w, util.ServerResponse{
@@ -190,7 +325,352 @@ func (a AuthModule) getData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	util.Write(w, user)
+	util.Write(w, a.authUseCases.PublicUser(*user))
+}
+
+func (a AuthModule) refresh(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request entities.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	tokens, status, err := a.authUseCases.RefreshTokens(ctx, request.RefreshToken, util.GetSessionClient(r))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to refresh tokens", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	response := AuthResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	}.withTokens(tokens)
+
+	util.Write(w, response)
+}
+
+func (a AuthModule) logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request entities.RefreshTokenRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			util.WriteBadRequest(w)
+			return
+		}
+	}
+
+	token := util.GetAuthTokenFromRequest(r)
+
+	status, err := a.authUseCases.Logout(ctx, token, request.RefreshToken)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to logout", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (a AuthModule) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request entities.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := a.authUseCases.ForgotPassword(ctx, request)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to request password reset", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (a AuthModule) resetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request entities.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := a.authUseCases.ResetPassword(ctx, request)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to reset password", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (a AuthModule) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	status, err := a.authUseCases.VerifyEmail(ctx, r.URL.Query().Get("token"))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to verify email", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (a AuthModule) resendVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token := util.GetAuthTokenFromRequest(r)
+	user, err := a.authUseCases.GetUserByAuthToken(ctx, token)
+	if err != nil || user == nil {
+		util.WriteUnauthorized(w)
+		return
+	}
+
+	status, err := a.authUseCases.ResendVerification(ctx, user)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to resend email verification", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (a AuthModule) setupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token := util.GetAuthTokenFromRequest(r)
+	user, err := a.authUseCases.GetUserByAuthToken(ctx, token)
+	if err != nil || user == nil {
+		util.WriteUnauthorized(w)
+		return
+	}
+
+	setup, status, err := a.authUseCases.SetupTwoFactor(ctx, user)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to setup two factor", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, TwoFactorSetupResponse{
+		Status:         status.Int(),
+		Message:        status.String(),
+		TwoFactorSetup: setup,
+	})
+}
+
+func (a AuthModule) enableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token := util.GetAuthTokenFromRequest(r)
+	user, err := a.authUseCases.GetUserByAuthToken(ctx, token)
+	if err != nil || user == nil {
+		util.WriteUnauthorized(w)
+		return
+	}
+
+	var request entities.TwoFactorCodeRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	codes, status, err := a.authUseCases.EnableTwoFactor(ctx, user, request)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to enable two factor", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, RecoveryCodesResponse{
+		Status:        status.Int(),
+		Message:       status.String(),
+		RecoveryCodes: codes,
+	})
+}
+
+func (a AuthModule) disableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token := util.GetAuthTokenFromRequest(r)
+	user, err := a.authUseCases.GetUserByAuthToken(ctx, token)
+	if err != nil || user == nil {
+		util.WriteUnauthorized(w)
+		return
+	}
+
+	var request entities.DisableTwoFactorRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := a.authUseCases.DisableTwoFactor(ctx, user, request)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to disable two factor", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (a AuthModule) regenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token := util.GetAuthTokenFromRequest(r)
+	user, err := a.authUseCases.GetUserByAuthToken(ctx, token)
+	if err != nil || user == nil {
+		util.WriteUnauthorized(w)
+		return
+	}
+
+	var request entities.TwoFactorCodeRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	codes, status, err := a.authUseCases.RegenerateRecoveryCodes(ctx, user, request)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to regenerate recovery codes", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, RecoveryCodesResponse{
+		Status:        status.Int(),
+		Message:       status.String(),
+		RecoveryCodes: codes,
+	})
+}
+
+func (a AuthModule) revokeUserTokens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := strconv.ParseInt(mux.Vars(r)["user_id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := a.authUseCases.RevokeUserTokens(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to revoke user tokens", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (a AuthModule) getSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	sessions, err := a.authUseCases.GetSessions(ctx, int64(user.UserID), user.SessionID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get sessions", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, sessions)
+}
+
+func (a AuthModule) revokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	sessionID, err := uuid.Parse(mux.Vars(r)["session_id"])
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := a.authUseCases.RevokeSession(ctx, int64(user.UserID), sessionID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to revoke session", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+// revokeAllSessions logs the user out of every device, including the one making the request
+func (a AuthModule) revokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	status, err := a.authUseCases.RevokeUserTokens(ctx, int64(user.UserID))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to revoke sessions", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (a AuthModule) unlockUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := strconv.ParseInt(mux.Vars(r)["user_id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := a.authUseCases.UnlockUser(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to unlock user", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
 }
 
 func (a AuthModule) SessionMiddleware(next http.Handler) http.Handler {
@@ -199,14 +679,14 @@ func (a AuthModule) SessionMiddleware(next http.Handler) http.Handler {
 
 		if authHeader != "" {
 			token := strings.TrimPrefix(authHeader, "Bearer ")
-			user, err := a.authUseCases.GetUserByAuthToken(token)
+			user, err := a.authUseCases.GetUserByAuthToken(r.Context(), token)
 
 			if err != nil {
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
 
-			ctx := util.NewContextWithUser(r.Context(), user.ID, user.IsAdm)
+			ctx := util.NewContextWithUser(r.Context(), &util.Payload{UserID: user.ID, Role: user.Role})
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		} else {
@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"cachacariaapi/domain/entities"
 	"cachacariaapi/domain/usecases"
 	"cachacariaapi/infrastructure/middleware"
 	"cachacariaapi/infrastructure/util"
@@ -33,7 +34,7 @@ func (m CartModule) Name() string { return m.name }
 func (m CartModule) Path() string { return m.path }
 
 func (m CartModule) RegisterRoutes(router *mux.Router) {
-	auth := middleware.AuthMiddlewareWithAdmin(m.authManager, false)
+	auth := middleware.AuthMiddleware(m.authManager)
 
 	routes := []ModuleRoute{
 		{
@@ -50,13 +51,13 @@ func (m CartModule) RegisterRoutes(router *mux.Router) {
 		},
 		{
 			Name:    "UpdateCartItem",
-			Path:    "/{product_id}",
+			Path:    "/{product_id:[0-9]+}",
 			Handler: auth(m.updateCartItem),
 			Methods: []string{http.MethodPatch},
 		},
 		{
 			Name:    "DeleteCartItem",
-			Path:    "/{product_id}",
+			Path:    "/{product_id:[0-9]+}",
 			Handler: auth(m.deleteCartItem),
 			Methods: []string{http.MethodDelete},
 		},
@@ -72,6 +73,18 @@ func (m CartModule) RegisterRoutes(router *mux.Router) {
 			Handler: auth(m.clearCart),
 			Methods: []string{http.MethodDelete},
 		},
+		{
+			Name:    "ApplyCoupon",
+			Path:    "/coupon",
+			Handler: auth(m.applyCoupon),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "RemoveCoupon",
+			Path:    "/coupon",
+			Handler: auth(m.removeCoupon),
+			Methods: []string{http.MethodDelete},
+		},
 	}
 
 	for _, route := range routes {
@@ -199,9 +212,15 @@ func (m CartModule) buy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	status, err := m.cartUseCases.BuyItems(ctx, int64(user.UserID))
+	var req entities.CheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.cartUseCases.BuyItems(ctx, int64(user.UserID), req)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to clear cart", "cause", err)
+		slog.ErrorContext(ctx, "failed to buy items", "cause", err)
 		util.WriteInternalError(w)
 		return
 	}
@@ -230,3 +249,50 @@ func (m CartModule) clearCart(w http.ResponseWriter, r *http.Request) {
 
 	util.Write(w, util.ServerResponse{Status: http.StatusOK, Message: "Carrinho limpo com sucesso"})
 }
+
+func (m CartModule) applyCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	var req entities.ApplyCouponRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.cartUseCases.ApplyCoupon(ctx, int64(user.UserID), req.Code)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to apply coupon", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m CartModule) removeCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	err := m.cartUseCases.RemoveCoupon(ctx, int64(user.UserID))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to remove coupon", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{Status: http.StatusOK, Message: "Cupom removido do carrinho"})
+}
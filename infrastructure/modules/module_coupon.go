@@ -0,0 +1,120 @@
+package modules
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/usecases"
+	"cachacariaapi/infrastructure/middleware"
+	"cachacariaapi/infrastructure/util"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CouponModule handles the admin coupon endpoints
+type CouponModule struct {
+	couponUseCases usecases.CouponUseCases
+	authManager    util.AuthManager
+	name           string
+	path           string
+}
+
+func NewCouponModule(couponUseCases usecases.CouponUseCases, authManager util.AuthManager) Module {
+	return CouponModule{
+		couponUseCases: couponUseCases,
+		authManager:    authManager,
+		name:           "coupon",
+		path:           "/coupon",
+	}
+}
+
+func (m CouponModule) Name() string { return m.name }
+func (m CouponModule) Path() string { return m.path }
+
+func (m CouponModule) RegisterRoutes(router *mux.Router) {
+	auth := middleware.RequirePermission(m.authManager, entities.PermissionCouponWrite)
+
+	routes := []ModuleRoute{
+		{
+			Name:    "AddCoupon",
+			Path:    "",
+			Handler: auth(m.add),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "GetCoupons",
+			Path:    "",
+			Handler: auth(m.getAll),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "DisableCoupon",
+			Path:    "/{id}",
+			Handler: auth(m.disable),
+			Methods: []string{http.MethodDelete},
+		},
+	}
+
+	for _, route := range routes {
+		router.HandleFunc(m.path+route.Path, route.Handler).Methods(route.Methods...)
+	}
+}
+
+func (m CouponModule) add(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req entities.AddCouponRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.couponUseCases.AddCoupon(ctx, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to add coupon", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m CouponModule) getAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	coupons, err := m.couponUseCases.GetCoupons(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get coupons", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, coupons)
+}
+
+func (m CouponModule) disable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.couponUseCases.DisableCoupon(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to disable coupon", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
@@ -0,0 +1,140 @@
+package modules
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/usecases"
+	"cachacariaapi/infrastructure/middleware"
+	"cachacariaapi/infrastructure/util"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// EmailModule lets the back office inspect the email outbox, retry the emails that failed and
+// preview the email templates
+type EmailModule struct {
+	emailUseCases usecases.EmailUseCases
+	authManager   util.AuthManager
+	name          string
+	path          string
+}
+
+func NewEmailModule(emailUseCases usecases.EmailUseCases, authManager util.AuthManager) Module {
+	return EmailModule{
+		emailUseCases: emailUseCases,
+		authManager:   authManager,
+		name:          "email",
+		path:          "/emails",
+	}
+}
+
+func (m EmailModule) Name() string { return m.name }
+func (m EmailModule) Path() string { return m.path }
+
+func (m EmailModule) RegisterRoutes(router *mux.Router) {
+	manage := middleware.RequirePermission(m.authManager, entities.PermissionEmailManage)
+
+	routes := []ModuleRoute{
+		{
+			Name:    "GetEmails",
+			Path:    "",
+			Handler: manage(m.getEmails),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "RetryEmail",
+			Path:    "/{id:[0-9]+}/retry",
+			Handler: manage(m.retry),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "GetEmailTemplates",
+			Path:    "/templates",
+			Handler: manage(m.getTemplates),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "PreviewEmailTemplate",
+			Path:    "/templates/{name}/preview",
+			Handler: manage(m.preview),
+			Methods: []string{http.MethodGet},
+		},
+	}
+
+	for _, route := range routes {
+		router.HandleFunc(m.path+route.Path, route.Handler).Methods(route.Methods...)
+	}
+}
+
+func (m EmailModule) getEmails(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	status := entities.OutboxStatus(r.URL.Query().Get("status"))
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	emails, err := m.emailUseCases.GetEmails(ctx, status, page, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get emails", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, emails)
+}
+
+func (m EmailModule) retry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.emailUseCases.RetryEmail(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to retry email", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m EmailModule) getTemplates(w http.ResponseWriter, r *http.Request) {
+	util.Write(w, m.emailUseCases.GetTemplates())
+}
+
+// preview renders the template as HTML, so that it can be opened in a browser. The subject
+// goes in the X-Email-Subject header.
+func (m EmailModule) preview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	email, status, err := m.emailUseCases.PreviewTemplate(mux.Vars(r)["name"], r.URL.Query().Get("locale"))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to preview email template", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	if email == nil {
+		util.Write(w, util.ServerResponse{
+			Status:  status.Int(),
+			Message: status.String(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Email-Subject", mime.QEncoding.Encode("utf-8", email.Subject))
+	if _, err = w.Write([]byte(email.Body)); err != nil {
+		slog.ErrorContext(ctx, "failed to write email preview", "cause", err)
+	}
+}
@@ -0,0 +1,215 @@
+package modules
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/status_codes"
+	"cachacariaapi/domain/usecases"
+	"cachacariaapi/infrastructure/middleware"
+	"cachacariaapi/infrastructure/util"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// NotificationModule serves the user's notification center and notification preferences
+type NotificationModule struct {
+	notificationUseCases usecases.NotificationUseCases
+	authManager          util.AuthManager
+	name                 string
+	path                 string
+}
+
+func NewNotificationModule(notificationUseCases usecases.NotificationUseCases, authManager util.AuthManager) Module {
+	return NotificationModule{
+		notificationUseCases: notificationUseCases,
+		authManager:          authManager,
+		name:                 "notification",
+		path:                 "/notifications",
+	}
+}
+
+func (m NotificationModule) Name() string { return m.name }
+func (m NotificationModule) Path() string { return m.path }
+
+func (m NotificationModule) RegisterRoutes(router *mux.Router) {
+	auth := middleware.AuthMiddleware(m.authManager)
+
+	routes := []ModuleRoute{
+		{
+			Name:    "GetNotifications",
+			Path:    "",
+			Handler: auth(m.getAll),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "GetUnreadNotifications",
+			Path:    "/unread-count",
+			Handler: auth(m.unreadCount),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "ReadNotification",
+			Path:    "/{id:[0-9]+}/read",
+			Handler: auth(m.markRead),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "ReadAllNotifications",
+			Path:    "/read-all",
+			Handler: auth(m.markAllRead),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "GetNotificationPreferences",
+			Path:    "/preferences",
+			Handler: auth(m.getPreferences),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "UpdateNotificationPreferences",
+			Path:    "/preferences",
+			Handler: auth(m.updatePreferences),
+			Methods: []string{http.MethodPut},
+		},
+	}
+
+	for _, route := range routes {
+		router.HandleFunc(m.path+route.Path, route.Handler).Methods(route.Methods...)
+	}
+}
+
+func (m NotificationModule) getAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	notifications, err := m.notificationUseCases.GetNotifications(ctx, int64(user.UserID), unreadOnly, page, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get notifications", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, notifications)
+}
+
+func (m NotificationModule) unreadCount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	unread, err := m.notificationUseCases.GetUnreadCount(ctx, int64(user.UserID))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to count unread notifications", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, unread)
+}
+
+func (m NotificationModule) markRead(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.notificationUseCases.MarkRead(ctx, int64(user.UserID), id)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to mark notification as read", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m NotificationModule) markAllRead(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	if err := m.notificationUseCases.MarkAllRead(ctx, int64(user.UserID)); err != nil {
+		slog.ErrorContext(ctx, "failed to mark notifications as read", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	status := status_codes.ReadNotificationStatusSuccess
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m NotificationModule) getPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	preferences, err := m.notificationUseCases.GetPreferences(ctx, int64(user.UserID))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get notification preferences", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, preferences)
+}
+
+func (m NotificationModule) updatePreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	var req entities.UpdateNotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.notificationUseCases.UpdatePreferences(ctx, int64(user.UserID), req)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update notification preferences", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
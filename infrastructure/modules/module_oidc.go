@@ -0,0 +1,95 @@
+package modules
+
+import (
+	"cachacariaapi/domain/usecases"
+	"cachacariaapi/infrastructure/util"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// OIDCModule logs users in with external OpenID Connect providers
+type OIDCModule struct {
+	oidcUseCases usecases.OIDCUseCases
+	name         string
+	path         string
+}
+
+func NewOIDCModule(oidcUseCases usecases.OIDCUseCases) Module {
+	return OIDCModule{
+		oidcUseCases: oidcUseCases,
+		name:         "oidc",
+		path:         "/auth/oidc",
+	}
+}
+
+func (m OIDCModule) Name() string { return m.name }
+func (m OIDCModule) Path() string { return m.path }
+
+func (m OIDCModule) RegisterRoutes(router *mux.Router) {
+	routes := []ModuleRoute{
+		{
+			Name:    "OIDCLogin",
+			Path:    "/{provider}/login",
+			Handler: m.login,
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "OIDCCallback",
+			Path:    "/{provider}/callback",
+			Handler: m.callback,
+			Methods: []string{http.MethodGet},
+		},
+	}
+
+	for _, route := range routes {
+		router.HandleFunc(m.path+route.Path, route.Handler).Methods(route.Methods...)
+	}
+}
+
+// login redirects the user to the provider's login page
+func (m OIDCModule) login(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	url, status, err := m.oidcUseCases.StartLogin(ctx, mux.Vars(r)["provider"])
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to start oidc login", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	if url == "" {
+		util.Write(w, util.ServerResponse{
+			Status:  status.Int(),
+			Message: status.String(),
+		})
+		return
+	}
+
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// callback receives the user back from the provider and answers with our tokens
+func (m OIDCModule) callback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	if providerError := query.Get("error"); providerError != "" {
+		slog.InfoContext(ctx, "oidc login refused by the provider", "error", providerError)
+	}
+
+	tokens, status, err := m.oidcUseCases.CompleteLogin(ctx, mux.Vars(r)["provider"], query.Get("code"), query.Get("state"), util.GetSessionClient(r))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to complete oidc login", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	response := AuthResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	}.withTokens(tokens)
+
+	util.Write(w, response)
+}
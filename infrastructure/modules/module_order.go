@@ -1,29 +1,33 @@
 package modules
 
 import (
+	"cachacariaapi/domain/entities"
 	"cachacariaapi/domain/usecases"
 	"cachacariaapi/infrastructure/middleware"
 	"cachacariaapi/infrastructure/util"
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 )
 
 type OrderModule struct {
-	cartUseCases usecases.CartUseCases
-	authManager  util.AuthManager
-	name         string
-	path         string
+	cartUseCases  usecases.CartUseCases
+	orderUseCases usecases.OrderUseCases
+	authManager   util.AuthManager
+	name          string
+	path          string
 }
 
-func NewOrderModule(cartUseCases usecases.CartUseCases, authManager util.AuthManager) Module {
+func NewOrderModule(cartUseCases usecases.CartUseCases, orderUseCases usecases.OrderUseCases, authManager util.AuthManager) Module {
 	return OrderModule{
-		cartUseCases: cartUseCases,
-		authManager:  authManager,
-		name:         "orders",
-		path:         "/orders",
+		cartUseCases:  cartUseCases,
+		orderUseCases: orderUseCases,
+		authManager:   authManager,
+		name:          "orders",
+		path:          "/orders",
 	}
 }
 
@@ -31,7 +35,9 @@ func (m OrderModule) Name() string { return m.name }
 func (m OrderModule) Path() string { return m.path }
 
 func (m OrderModule) RegisterRoutes(router *mux.Router) {
-	auth := middleware.AuthMiddlewareWithAdmin(m.authManager, false)
+	auth := middleware.AuthMiddleware(m.authManager)
+	readOrders := middleware.RequirePermission(m.authManager, entities.PermissionOrderRead)
+	writeOrders := middleware.RequirePermission(m.authManager, entities.PermissionOrderWrite)
 
 	routes := []ModuleRoute{
 		{
@@ -40,6 +46,18 @@ func (m OrderModule) RegisterRoutes(router *mux.Router) {
 			Handler: auth(m.getOrders),
 			Methods: []string{http.MethodGet},
 		},
+		{
+			Name:    "GetAllOrders",
+			Path:    "/all",
+			Handler: readOrders(m.getAllOrders),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "UpdateOrderStatus",
+			Path:    "/{id:[0-9]+}/status",
+			Handler: writeOrders(m.updateStatus),
+			Methods: []string{http.MethodPatch},
+		},
 	}
 
 	for _, route := range routes {
@@ -67,3 +85,44 @@ func (m OrderModule) getOrders(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(orders)
 }
+
+func (m OrderModule) getAllOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	orders, err := m.cartUseCases.GetAllOrders(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get all orders", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, orders)
+}
+
+func (m OrderModule) updateStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	var req entities.UpdateOrderStatusRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.orderUseCases.UpdateStatus(ctx, orderID, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update order status", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
@@ -41,13 +41,15 @@ func (m productModule) Path() string {
 }
 
 func (m productModule) RegisterRoutes(router *mux.Router) {
-	auth := middleware.AuthMiddlewareWithAdmin(m.authManager, true)
+	writeProducts := middleware.RequirePermission(m.authManager, entities.PermissionProductWrite)
+	deleteProducts := middleware.RequirePermission(m.authManager, entities.PermissionProductDelete)
+	writePrices := middleware.RequirePermission(m.authManager, entities.PermissionPriceWrite)
 
 	routes := []ModuleRoute{
 		{
 			Name:    "Add",
 			Path:    "",
-			Handler: auth(m.add),
+			Handler: writeProducts(m.add),
 			Methods: []string{http.MethodPost},
 		},
 		{
@@ -65,13 +67,31 @@ func (m productModule) RegisterRoutes(router *mux.Router) {
 		{
 			Name:    "Update",
 			Path:    "/{id}",
-			Handler: auth(m.update),
+			Handler: writeProducts(m.update),
 			Methods: []string{http.MethodPut},
 		},
 		{
 			Name:    "Delete",
 			Path:    "/{id}",
-			Handler: auth(m.delete),
+			Handler: deleteProducts(m.delete),
+			Methods: []string{http.MethodDelete},
+		},
+		{
+			Name:    "GetPriceHistory",
+			Path:    "/{id}/prices",
+			Handler: writePrices(m.getPriceHistory),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "SchedulePrice",
+			Path:    "/{id}/prices",
+			Handler: writePrices(m.schedulePrice),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "CancelScheduledPrice",
+			Path:    "/{id}/prices/{price_id}",
+			Handler: writePrices(m.cancelScheduledPrice),
 			Methods: []string{http.MethodDelete},
 		},
 	}
@@ -100,6 +120,7 @@ func (m productModule) add(w http.ResponseWriter, r *http.Request) {
 	photos := r.MultipartForm.File["photos"]
 
 	request := entities.AddProductRequest{
+		CategoryID:  parseCategoryID(r.FormValue("category_id")),
 		Name:        name,
 		Description: description,
 		Price:       float32(price),
@@ -260,6 +281,7 @@ func (m productModule) update(w http.ResponseWriter, r *http.Request) {
 	photos := r.MultipartForm.File["photos"]
 
 	request := entities.UpdateProductRequest{
+		CategoryID:  parseCategoryID(r.FormValue("category_id")),
 		Name:        name,
 		Description: description,
 		Price:       float32(price),
@@ -267,7 +289,7 @@ func (m productModule) update(w http.ResponseWriter, r *http.Request) {
 		Photos:      photos,
 	}
 
-	status, err := m.productUseCases.UpdateProduct(id, request)
+	status, err := m.productUseCases.UpdateProduct(ctx, id, request)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to update product", "cause", err)
 		util.WriteInternalError(w)
@@ -285,3 +307,89 @@ func (m productModule) update(w http.ResponseWriter, r *http.Request) {
 
 	util.Write(w, resp)
 }
+
+func (m productModule) getPriceHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	prices, err := m.productUseCases.GetPriceHistory(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get product price history", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, prices)
+}
+
+func (m productModule) schedulePrice(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	var req entities.SchedulePriceRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.productUseCases.SchedulePrice(ctx, id, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to schedule product price", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m productModule) cancelScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	priceID, err := strconv.ParseInt(vars["price_id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.productUseCases.CancelScheduledPrice(ctx, id, priceID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to cancel scheduled product price", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+// parseCategoryID parses the optional category_id form value, returning nil if it is absent or invalid
+func parseCategoryID(value string) *int64 {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		return nil
+	}
+
+	return &id
+}
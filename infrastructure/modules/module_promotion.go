@@ -0,0 +1,120 @@
+package modules
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/usecases"
+	"cachacariaapi/infrastructure/middleware"
+	"cachacariaapi/infrastructure/util"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// PromotionModule handles the admin promotion endpoints
+type PromotionModule struct {
+	promotionUseCases usecases.PromotionUseCases
+	authManager       util.AuthManager
+	name              string
+	path              string
+}
+
+func NewPromotionModule(promotionUseCases usecases.PromotionUseCases, authManager util.AuthManager) Module {
+	return PromotionModule{
+		promotionUseCases: promotionUseCases,
+		authManager:       authManager,
+		name:              "promotion",
+		path:              "/promotion",
+	}
+}
+
+func (m PromotionModule) Name() string { return m.name }
+func (m PromotionModule) Path() string { return m.path }
+
+func (m PromotionModule) RegisterRoutes(router *mux.Router) {
+	auth := middleware.RequirePermission(m.authManager, entities.PermissionPromotionWrite)
+
+	routes := []ModuleRoute{
+		{
+			Name:    "AddPromotion",
+			Path:    "",
+			Handler: auth(m.add),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "GetPromotions",
+			Path:    "",
+			Handler: auth(m.getAll),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "DisablePromotion",
+			Path:    "/{id}",
+			Handler: auth(m.disable),
+			Methods: []string{http.MethodDelete},
+		},
+	}
+
+	for _, route := range routes {
+		router.HandleFunc(m.path+route.Path, route.Handler).Methods(route.Methods...)
+	}
+}
+
+func (m PromotionModule) add(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req entities.AddPromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.promotionUseCases.AddPromotion(ctx, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to add promotion", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m PromotionModule) getAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	promotions, err := m.promotionUseCases.GetPromotions(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get promotions", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, promotions)
+}
+
+func (m PromotionModule) disable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.promotionUseCases.DisablePromotion(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to disable promotion", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
@@ -0,0 +1,253 @@
+package modules
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/usecases"
+	"cachacariaapi/infrastructure/middleware"
+	"cachacariaapi/infrastructure/util"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ReviewModule handles the product review endpoints. Customers review products under
+// /product/{id}/reviews, while the moderation endpoints live under /review.
+type ReviewModule struct {
+	reviewUseCases usecases.ReviewUseCases
+	authManager    util.AuthManager
+	name           string
+	path           string
+	productPath    string
+}
+
+func NewReviewModule(reviewUseCases usecases.ReviewUseCases, authManager util.AuthManager) Module {
+	return ReviewModule{
+		reviewUseCases: reviewUseCases,
+		authManager:    authManager,
+		name:           "review",
+		path:           "/review",
+		productPath:    "/product/{id:[0-9]+}/reviews",
+	}
+}
+
+func (m ReviewModule) Name() string { return m.name }
+func (m ReviewModule) Path() string { return m.path }
+
+func (m ReviewModule) RegisterRoutes(router *mux.Router) {
+	auth := middleware.AuthMiddleware(m.authManager)
+	moderate := middleware.RequirePermission(m.authManager, entities.PermissionReviewModerate)
+
+	productRoutes := []ModuleRoute{
+		{
+			Name:    "GetProductReviews",
+			Path:    "",
+			Handler: m.getProductReviews, // Public
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "AddReview",
+			Path:    "",
+			Handler: auth(m.add),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "UpdateReview",
+			Path:    "",
+			Handler: auth(m.update),
+			Methods: []string{http.MethodPut},
+		},
+		{
+			Name:    "DeleteReview",
+			Path:    "",
+			Handler: auth(m.delete),
+			Methods: []string{http.MethodDelete},
+		},
+	}
+
+	routes := []ModuleRoute{
+		{
+			Name:    "GetReviews",
+			Path:    "",
+			Handler: moderate(m.getByStatus),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "ModerateReview",
+			Path:    "/{id:[0-9]+}",
+			Handler: moderate(m.moderate),
+			Methods: []string{http.MethodPatch},
+		},
+	}
+
+	for _, route := range productRoutes {
+		router.HandleFunc(m.productPath+route.Path, route.Handler).Methods(route.Methods...)
+	}
+
+	for _, route := range routes {
+		router.HandleFunc(m.path+route.Path, route.Handler).Methods(route.Methods...)
+	}
+}
+
+func (m ReviewModule) getProductReviews(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	reviews, err := m.reviewUseCases.GetProductReviews(ctx, productID, page, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get product reviews", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, reviews)
+}
+
+func (m ReviewModule) add(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	var req entities.ReviewRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.reviewUseCases.AddReview(ctx, int64(user.UserID), productID, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to add review", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m ReviewModule) update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	var req entities.ReviewRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.reviewUseCases.UpdateReview(ctx, int64(user.UserID), productID, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update review", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m ReviewModule) delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	productID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.reviewUseCases.DeleteReview(ctx, int64(user.UserID), productID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to delete review", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m ReviewModule) getByStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	status := entities.ReviewStatus(r.URL.Query().Get("status"))
+
+	reviews, err := m.reviewUseCases.GetReviewsByStatus(ctx, status)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get reviews", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, reviews)
+}
+
+func (m ReviewModule) moderate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	var req entities.ModerateReviewRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.reviewUseCases.ModerateReview(ctx, id, req.Status)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to moderate review", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
@@ -0,0 +1,89 @@
+package modules
+
+import (
+	"cachacariaapi/domain/entities"
+	"cachacariaapi/domain/usecases"
+	"cachacariaapi/infrastructure/middleware"
+	"cachacariaapi/infrastructure/util"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// RoleModule lists the back office roles and assigns them to users
+type RoleModule struct {
+	roleUseCases usecases.RoleUseCases
+	authManager  util.AuthManager
+	name         string
+	path         string
+}
+
+func NewRoleModule(roleUseCases usecases.RoleUseCases, authManager util.AuthManager) Module {
+	return RoleModule{
+		roleUseCases: roleUseCases,
+		authManager:  authManager,
+		name:         "role",
+		path:         "/role",
+	}
+}
+
+func (m RoleModule) Name() string { return m.name }
+func (m RoleModule) Path() string { return m.path }
+
+func (m RoleModule) RegisterRoutes(router *mux.Router) {
+	assignRoles := middleware.RequirePermission(m.authManager, entities.PermissionRoleAssign)
+
+	routes := []ModuleRoute{
+		{
+			Name:    "GetRoles",
+			Path:    "",
+			Handler: assignRoles(m.getRoles),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "AssignRole",
+			Path:    "/user/{user_id:[0-9]+}",
+			Handler: assignRoles(m.assign),
+			Methods: []string{http.MethodPut},
+		},
+	}
+
+	for _, route := range routes {
+		router.HandleFunc(m.path+route.Path, route.Handler).Methods(route.Methods...)
+	}
+}
+
+func (m RoleModule) getRoles(w http.ResponseWriter, r *http.Request) {
+	util.Write(w, m.roleUseCases.GetRoles())
+}
+
+func (m RoleModule) assign(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := strconv.ParseInt(mux.Vars(r)["user_id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	var req entities.AssignRoleRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.roleUseCases.AssignRole(ctx, userID, req.Role)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to assign role", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
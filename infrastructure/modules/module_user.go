@@ -1,29 +1,50 @@
 package modules
 
 import (
+	"archive/zip"
 	"cachacariaapi/domain/entities"
 	"cachacariaapi/domain/usecases"
+	"cachacariaapi/infrastructure/middleware"
 	"cachacariaapi/infrastructure/util"
+	"encoding/json"
 	"log/slog"
+	"maps"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/gorilla/mux"
 )
 
+// maxAvatarRequestSize bounds the avatar upload, whose image is limited to 2 MB
+const maxAvatarRequestSize = 3 << 20
+
+// moduleUser handles the user's own account and, for staff, the management of every account
 type moduleUser struct {
-	userUseCases usecases.UserUseCases
-	authUseCases usecases.AuthUseCases
-	name         string
-	path         string
+	userUseCases    usecases.UserUseCases
+	authUseCases    usecases.AuthUseCases
+	privacyUseCases usecases.PrivacyUseCases
+	consentUseCases usecases.ConsentUseCases
+	authManager     util.AuthManager
+	name            string
+	path            string
 }
 
-func NewUserModule(userUseCases usecases.UserUseCases, authUseCases usecases.AuthUseCases) Module {
+func NewUserModule(
+	userUseCases usecases.UserUseCases,
+	authUseCases usecases.AuthUseCases,
+	privacyUseCases usecases.PrivacyUseCases,
+	consentUseCases usecases.ConsentUseCases,
+	authManager util.AuthManager,
+) Module {
 	return moduleUser{
-		userUseCases: userUseCases,
-		authUseCases: authUseCases,
-		name:         "user",
-		path:         "/user",
+		userUseCases:    userUseCases,
+		authUseCases:    authUseCases,
+		privacyUseCases: privacyUseCases,
+		consentUseCases: consentUseCases,
+		authManager:     authManager,
+		name:            "user",
+		path:            "/user",
 	}
 }
 
@@ -36,13 +57,42 @@ func (m moduleUser) Path() string {
 }
 
 func (m moduleUser) RegisterRoutes(router *mux.Router) {
+	auth := middleware.AuthMiddleware(m.authManager)
+	readUsers := middleware.RequirePermission(m.authManager, entities.PermissionUserRead)
+	writeUsers := middleware.RequirePermission(m.authManager, entities.PermissionUserWrite)
+	deleteUsers := middleware.RequirePermission(m.authManager, entities.PermissionUserDelete)
+
 	routes := []ModuleRoute{
 		{
-			Name:    "GetAll",
+			Name:    "SearchUsers",
 			Path:    "",
-			Handler: m.GetAll,
+			Handler: readUsers(m.search),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "GetUser",
+			Path:    "/{id:[0-9]+}",
+			Handler: readUsers(m.get),
 			Methods: []string{http.MethodGet},
 		},
+		{
+			Name:    "DisableUser",
+			Path:    "/{id:[0-9]+}/disable",
+			Handler: writeUsers(m.disable),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "EnableUser",
+			Path:    "/{id:[0-9]+}/enable",
+			Handler: writeUsers(m.enable),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "DeleteUser",
+			Path:    "/{id:[0-9]+}",
+			Handler: deleteUsers(m.DeleteUser),
+			Methods: []string{http.MethodDelete},
+		},
 		{
 			Name:    "UpdateUser",
 			Path:    "/update",
@@ -50,9 +100,57 @@ func (m moduleUser) RegisterRoutes(router *mux.Router) {
 			Methods: []string{http.MethodPost},
 		},
 		{
-			Name:    "DeleteUser",
-			Path:    "/{id}",
-			Handler: m.DeleteUser,
+			Name:    "VerifyAge",
+			Path:    "/age-verification",
+			Handler: m.VerifyAge,
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "UpdateProfile",
+			Path:    "/me/profile",
+			Handler: auth(m.updateProfile),
+			Methods: []string{http.MethodPut},
+		},
+		{
+			Name:    "UpdateAvatar",
+			Path:    "/me/avatar",
+			Handler: auth(m.updateAvatar),
+			Methods: []string{http.MethodPut},
+		},
+		{
+			Name:    "DeleteAvatar",
+			Path:    "/me/avatar",
+			Handler: auth(m.deleteAvatar),
+			Methods: []string{http.MethodDelete},
+		},
+		{
+			Name:    "GetPreferences",
+			Path:    "/me/preferences",
+			Handler: auth(m.getPreferences),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "UpdatePreferences",
+			Path:    "/me/preferences",
+			Handler: auth(m.updatePreferences),
+			Methods: []string{http.MethodPut},
+		},
+		{
+			Name:    "GetConsentHistory",
+			Path:    "/me/consents",
+			Handler: auth(m.getConsentHistory),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "ExportUserData",
+			Path:    "/me/export",
+			Handler: auth(m.exportData),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "DeleteAccount",
+			Path:    "/me",
+			Handler: auth(m.deleteAccount),
 			Methods: []string{http.MethodDelete},
 		},
 	}
@@ -62,13 +160,32 @@ func (m moduleUser) RegisterRoutes(router *mux.Router) {
 	}
 }
 
-func (m moduleUser) GetAll(w http.ResponseWriter, r *http.Request) {
+func (m moduleUser) search(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	query := r.URL.Query()
+
+	filter := entities.UserFilter{
+		Search: query.Get("search"),
+		Role:   entities.Role(query.Get("role")),
+	}
 
-	users, err := m.userUseCases.GetAll()
+	if value := query.Get("status"); value != "" {
+		status, err := strconv.Atoi(value)
+		if err != nil {
+			util.WriteBadRequest(w)
+			return
+		}
 
+		userStatus := entities.UserStatus(status)
+		filter.Status = &userStatus
+	}
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+
+	users, err := m.userUseCases.SearchUsers(ctx, filter, page)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to get all users", "cause", err)
+		slog.ErrorContext(ctx, "failed to search users", "cause", err)
 		util.WriteInternalError(w)
 		return
 	}
@@ -76,30 +193,321 @@ func (m moduleUser) GetAll(w http.ResponseWriter, r *http.Request) {
 	util.Write(w, users)
 }
 
-func (m moduleUser) DeleteUser(w http.ResponseWriter, r *http.Request) {
+func (m moduleUser) get(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	vars := mux.Vars(r)
-	idStr := vars["id"]
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
 
-	if idStr == "" {
+	user, err := m.userUseCases.FindById(id)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get user", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	if user == nil {
+		util.WriteResponse(w, util.ServerResponse{
+			Status:  http.StatusNotFound,
+			Message: "Usuário não encontrado",
+		})
+		return
+	}
+
+	util.Write(w, m.userUseCases.PublicUser(*user))
+}
+
+func (m moduleUser) disable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	actor, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.WriteUnauthorized(w)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
 		util.WriteBadRequest(w)
 		return
 	}
 
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	status, err := m.userUseCases.DisableUser(ctx, int64(actor.UserID), actor.Role, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to disable user", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m moduleUser) enable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	actor, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.WriteUnauthorized(w)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
 	if err != nil {
 		util.WriteBadRequest(w)
 		return
 	}
 
-	err = m.userUseCases.Delete(id)
+	status, err := m.userUseCases.EnableUser(ctx, actor.Role, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to enable user", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m moduleUser) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	actor, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.WriteUnauthorized(w)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.userUseCases.DeleteUser(ctx, int64(actor.UserID), id)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to delete user", "cause", err)
 		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m moduleUser) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.WriteUnauthorized(w)
+		return
+	}
+
+	var req entities.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.userUseCases.DeleteAccount(ctx, int64(user.UserID), req)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to delete account", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m moduleUser) updateProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	var req entities.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.userUseCases.UpdateProfile(ctx, int64(user.UserID), req)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update profile", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+// updateAvatar takes the image in the "avatar" field of a multipart form
+func (m moduleUser) updateAvatar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAvatarRequestSize)
+	if err := r.ParseMultipartForm(maxAvatarRequestSize); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	_, header, err := r.FormFile("avatar")
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.userUseCases.UpdateAvatar(ctx, int64(user.UserID), header)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update avatar", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m moduleUser) deleteAvatar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	status, err := m.userUseCases.DeleteAvatar(ctx, int64(user.UserID))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to delete avatar", "cause", err)
+		util.WriteInternalError(w)
+		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m moduleUser) getPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	preferences, err := m.consentUseCases.GetPreferences(ctx, int64(user.UserID))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get preferences", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, preferences)
+}
+
+func (m moduleUser) updatePreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	var req entities.UpdateConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.consentUseCases.UpdatePreferences(ctx, int64(user.UserID), req, util.GetSessionClient(r))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update preferences", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m moduleUser) getConsentHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	consents, err := m.consentUseCases.GetHistory(ctx, int64(user.UserID))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get consent history", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, consents)
+}
+
+// exportData delivers the user's personal data as JSON or, with format=zip, as a ZIP archive
+// with one JSON file per section
+func (m moduleUser) exportData(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := util.GetUserFromContext(ctx)
+
+	export, err := m.privacyUseCases.ExportUserData(ctx, int64(user.UserID))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to export user data", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	if export == nil {
+		util.WriteUnauthorized(w)
+		return
+	}
+
+	slog.InfoContext(ctx, "user data exported", "user_id", user.UserID)
+
+	if r.URL.Query().Get("format") != "zip" {
+		w.Header().Set("Content-Disposition", `attachment; filename="meus-dados.json"`)
+		util.Write(w, export)
+		return
+	}
+
+	sections := export.Sections()
+	names := slices.Sorted(maps.Keys(sections))
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="meus-dados.zip"`)
+
+	archive := zip.NewWriter(w)
+	for _, name := range names {
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     name + ".json",
+			Method:   zip.Deflate,
+			Modified: export.GeneratedAt,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to write user data archive", "cause", err)
+			return
+		}
+
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(sections[name]); err != nil {
+			slog.ErrorContext(ctx, "failed to write user data archive", "cause", err)
+			return
+		}
+	}
+
+	if err = archive.Close(); err != nil {
+		slog.ErrorContext(ctx, "failed to write user data archive", "cause", err)
+	}
 }
 
 func (m moduleUser) UpdateUser(w http.ResponseWriter, r *http.Request) {
@@ -107,7 +515,7 @@ func (m moduleUser) UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	token := util.GetAuthTokenFromRequest(r)
 
-	user, err := m.authUseCases.GetUserByAuthToken(token)
+	user, err := m.authUseCases.GetUserByAuthToken(ctx, token)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to get user by auth token", "cause", err)
 		util.WriteInternalError(w)
@@ -128,7 +536,7 @@ func (m moduleUser) UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	req.ID = user.ID
 
-	status, err := m.userUseCases.Update(req)
+	status, err := m.userUseCases.Update(ctx, req)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to update user", "cause", err)
 		util.WriteInternalError(w)
@@ -141,3 +549,33 @@ func (m moduleUser) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	util.Write(w, res)
 }
+
+func (m moduleUser) VerifyAge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token := util.GetAuthTokenFromRequest(r)
+
+	user, err := m.authUseCases.GetUserByAuthToken(ctx, token)
+	if err != nil || user == nil {
+		util.WriteUnauthorized(w)
+		return
+	}
+
+	var req entities.AgeVerificationRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.userUseCases.VerifyAge(ctx, int64(user.ID), req, util.GetClientIP(r), r.UserAgent())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to verify user age", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
@@ -0,0 +1,183 @@
+package modules
+
+import (
+	"cachacariaapi/domain/usecases"
+	"cachacariaapi/infrastructure/middleware"
+	"cachacariaapi/infrastructure/util"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// WishlistModule handles the wishlist endpoints
+type WishlistModule struct {
+	wishlistUseCases usecases.WishlistUseCases
+	authManager      util.AuthManager
+	name             string
+	path             string
+}
+
+func NewWishlistModule(wishlistUseCases usecases.WishlistUseCases, authManager util.AuthManager) Module {
+	return WishlistModule{
+		wishlistUseCases: wishlistUseCases,
+		authManager:      authManager,
+		name:             "wishlist",
+		path:             "/wishlist",
+	}
+}
+
+func (m WishlistModule) Name() string { return m.name }
+func (m WishlistModule) Path() string { return m.path }
+
+func (m WishlistModule) RegisterRoutes(router *mux.Router) {
+	auth := middleware.AuthMiddleware(m.authManager)
+
+	routes := []ModuleRoute{
+		{
+			Name:    "AddToWishlist",
+			Path:    "",
+			Handler: auth(m.add),
+			Methods: []string{http.MethodPost},
+		},
+		{
+			Name:    "GetWishlist",
+			Path:    "",
+			Handler: auth(m.getAll),
+			Methods: []string{http.MethodGet},
+		},
+		{
+			Name:    "RemoveFromWishlist",
+			Path:    "/{product_id:[0-9]+}",
+			Handler: auth(m.remove),
+			Methods: []string{http.MethodDelete},
+		},
+		{
+			Name:    "MoveToCart",
+			Path:    "/{product_id:[0-9]+}/cart",
+			Handler: auth(m.moveToCart),
+			Methods: []string{http.MethodPost},
+		},
+	}
+
+	for _, route := range routes {
+		router.HandleFunc(m.path+route.Path, route.Handler).Methods(route.Methods...)
+	}
+}
+
+func (m WishlistModule) add(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	var req struct {
+		ProductID int64 `json:"product_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.wishlistUseCases.AddToWishlist(ctx, int64(user.UserID), req.ProductID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to add product to wishlist", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m WishlistModule) getAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	items, err := m.wishlistUseCases.GetWishlist(ctx, int64(user.UserID))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get wishlist", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, items)
+}
+
+func (m WishlistModule) remove(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	productID, err := strconv.ParseInt(mux.Vars(r)["product_id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	status, err := m.wishlistUseCases.RemoveFromWishlist(ctx, int64(user.UserID), productID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to remove product from wishlist", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
+
+func (m WishlistModule) moveToCart(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := util.GetUserFromContext(ctx)
+	if !ok {
+		util.Write(w, util.ServerResponse{Status: http.StatusUnauthorized, Message: "Usuário não autenticado"})
+		return
+	}
+
+	productID, err := strconv.ParseInt(mux.Vars(r)["product_id"], 10, 64)
+	if err != nil {
+		util.WriteBadRequest(w)
+		return
+	}
+
+	var req struct {
+		Quantity int `json:"quantity"`
+	}
+	if r.ContentLength != 0 {
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			util.WriteBadRequest(w)
+			return
+		}
+	}
+
+	if req.Quantity <= 0 {
+		req.Quantity = 1
+	}
+
+	status, err := m.wishlistUseCases.MoveToCart(ctx, int64(user.UserID), productID, req.Quantity)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to move wishlist product to cart", "cause", err)
+		util.WriteInternalError(w)
+		return
+	}
+
+	util.Write(w, util.ServerResponse{
+		Status:  status.Int(),
+		Message: status.String(),
+	})
+}
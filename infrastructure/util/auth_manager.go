@@ -1,8 +1,17 @@
 package util
 
 import (
+	"cachacariaapi/domain/entities"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,34 +24,82 @@ var (
 	ErrInvalidToken = errors.New("INVALID_TOKEN")
 )
 
+// APIKeyScheme is the Authorization scheme of API keys, accepted alongside Bearer tokens
+const APIKeyScheme = "ApiKey"
+
 const (
 	DefaultCost          = bcrypt.DefaultCost
-	DefaultTokenDuration = 12 * time.Hour
+	DefaultTokenDuration = 15 * time.Minute
+	RefreshTokenDuration = 30 * 24 * time.Hour
+	ResetTokenDuration   = time.Hour
+	VerifyTokenDuration  = 24 * time.Hour
+	opaqueTokenBytes     = 32
+	apiKeyPrefixBytes    = 8
+	apiKeyTag            = "ck_"
 )
 
+// dummyPasswordHash is compared against when the user does not exist, so a login with an
+// unknown email takes as long as one with a wrong password
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), DefaultCost)
+	return string(hash)
+})
+
+// TokenStore tells whether an access token, or its session, was revoked before it expired
+type TokenStore interface {
+	IsTokenRevoked(ctx context.Context, tokenID, sessionID uuid.UUID) (bool, error)
+}
+
+// APIKeyStore finds API keys and records their use
+type APIKeyStore interface {
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int64, ipAddress string) error
+}
+
 type AuthManager struct {
-	paseto       *paseto.V2
-	symmetricKey string
+	paseto                *paseto.V2
+	symmetricKey          string
+	tokenStore            TokenStore
+	apiKeyStore           APIKeyStore
+	requireAdminTwoFactor bool
 }
 
-// NewAuthManager creates a new manager instance with a given symmetric key.
-func NewAuthManager(symmetricKey string) AuthManager {
+// NewAuthManager creates a new manager instance with a given symmetric key. The token store
+// is checked for revoked access tokens, and the API key store for API keys. When
+// requireAdminTwoFactor is set, the back office routes only accept tokens issued after a
+// two-factor login.
+func NewAuthManager(symmetricKey string, tokenStore TokenStore, apiKeyStore APIKeyStore, requireAdminTwoFactor bool) AuthManager {
 	return AuthManager{
-		paseto:       paseto.NewV2(),
-		symmetricKey: symmetricKey,
+		paseto:                paseto.NewV2(),
+		symmetricKey:          symmetricKey,
+		tokenStore:            tokenStore,
+		apiKeyStore:           apiKeyStore,
+		requireAdminTwoFactor: requireAdminTwoFactor,
 	}
 }
 
+// RequireAdminTwoFactor tells whether staff must use two-factor authentication to access the
+// back office
+func (a *AuthManager) RequireAdminTwoFactor() bool {
+	return a.requireAdminTwoFactor
+}
+
 type Payload struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	UserID    int       `json:"user_id"`
-	IsAdmin   bool      `json:"is_admin"`
-	IssuedAt  time.Time `json:"issued_at"`
-	ExpiresAt time.Time `json:"expires_at"`
+	ID        uuid.UUID     `json:"id"`
+	SessionID uuid.UUID     `json:"session_id"`
+	Email     string        `json:"email"`
+	UserID    int           `json:"user_id"`
+	Role      entities.Role `json:"role"`
+	TwoFactor bool          `json:"two_factor"`
+	IssuedAt  time.Time     `json:"issued_at"`
+	ExpiresAt time.Time     `json:"expires_at"`
+
+	// APIKeyID and Scopes are only set when the request was authenticated with an API key
+	APIKeyID int64                 `json:"-"`
+	Scopes   []entities.Permission `json:"-"`
 }
 
-func NewPayload(email string, userID int, isAdmin bool, duration time.Duration) (*Payload, error) {
+func NewPayload(sessionID uuid.UUID, email string, userID int, role entities.Role, twoFactor bool, duration time.Duration) (*Payload, error) {
 	tokenUUID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, fmt.Errorf("error generating token uuid: %w", err)
@@ -50,9 +107,11 @@ func NewPayload(email string, userID int, isAdmin bool, duration time.Duration)
 
 	return &Payload{
 		ID:        tokenUUID,
+		SessionID: sessionID,
 		Email:     email,
 		UserID:    userID,
-		IsAdmin:   isAdmin,
+		Role:      role,
+		TwoFactor: twoFactor,
 		IssuedAt:  time.Now(),
 		ExpiresAt: time.Now().Add(duration),
 	}, nil
@@ -78,17 +137,119 @@ func (a *AuthManager) CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-func (a *AuthManager) CreateToken(email string, userID int, isAdmin bool) (string, error) {
-	payload, err := NewPayload(email, userID, isAdmin, DefaultTokenDuration)
+// SimulatePasswordCheck takes as long as CheckPasswordHash, to be used when there is no
+// password to check against
+func (a *AuthManager) SimulatePasswordCheck(password string) {
+	a.CheckPasswordHash(password, dummyPasswordHash())
+}
+
+// CreateToken creates a short-lived access token for the session, returning its payload along
+// with it so the caller can keep track of the token ID. twoFactor tells whether the user has
+// two-factor authentication enabled, and so went through it to log in.
+func (a *AuthManager) CreateToken(sessionID uuid.UUID, email string, userID int, role entities.Role, twoFactor bool) (string, *Payload, error) {
+	payload, err := NewPayload(sessionID, email, userID, role, twoFactor, DefaultTokenDuration)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	encrypted, err := a.paseto.Encrypt([]byte(a.symmetricKey), payload, nil)
 	if err != nil {
-		return "", fmt.Errorf("error encrypting token: %w", err)
+		return "", nil, fmt.Errorf("error encrypting token: %w", err)
 	}
-	return encrypted, nil
+	return encrypted, payload, nil
+}
+
+// CreateOpaqueToken creates a random token, such as a refresh or a password reset token, and
+// its hash. Only the hash should be persisted.
+func (a *AuthManager) CreateOpaqueToken() (string, string, error) {
+	bytes := make([]byte, opaqueTokenBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", fmt.Errorf("error generating token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(bytes)
+	return token, a.HashOpaqueToken(token), nil
+}
+
+func (a *AuthManager) HashOpaqueToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// CreateAPIKey creates a random API key, returning the key, its lookup prefix and its hash.
+// Only the prefix and the hash should be persisted.
+func (a *AuthManager) CreateAPIKey() (string, string, string, error) {
+	prefixBytes := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", fmt.Errorf("error generating api key prefix: %w", err)
+	}
+
+	secret, _, err := a.CreateOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	prefix := hex.EncodeToString(prefixBytes)
+	key := apiKeyTag + prefix + "." + secret
+	return key, prefix, a.HashOpaqueToken(key), nil
+}
+
+// VerifyAPIKey checks the API key against the store, returning a payload for its owner. Revoked
+// and expired keys, and keys of disabled users, are refused. The use of the key is recorded.
+func (a *AuthManager) VerifyAPIKey(ctx context.Context, key, ipAddress string) (*Payload, error) {
+	if a.apiKeyStore == nil {
+		return nil, ErrInvalidToken
+	}
+
+	prefix, _, ok := strings.Cut(strings.TrimPrefix(key, apiKeyTag), ".")
+	if !ok || !strings.HasPrefix(key, apiKeyTag) {
+		return nil, ErrInvalidToken
+	}
+
+	apiKey, err := a.apiKeyStore.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("error getting api key"), err)
+	}
+
+	if apiKey == nil || subtle.ConstantTimeCompare([]byte(a.HashOpaqueToken(key)), []byte(apiKey.KeyHash)) != 1 {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	if !apiKey.IsActive(now) {
+		return nil, ErrExpiredToken
+	}
+
+	if apiKey.OwnerStatus == entities.UserStatusDisabled {
+		return nil, ErrInvalidToken
+	}
+
+	if err = a.apiKeyStore.TouchAPIKey(ctx, apiKey.ID, ipAddress); err != nil {
+		return nil, errors.Join(fmt.Errorf("error recording api key use"), err)
+	}
+
+	payload := &Payload{
+		UserID:   int(apiKey.UserID),
+		Role:     apiKey.OwnerRole,
+		IssuedAt: apiKey.CreatedAt,
+		APIKeyID: apiKey.ID,
+		Scopes:   apiKey.Scopes,
+	}
+
+	if apiKey.ExpiresAt != nil {
+		payload.ExpiresAt = *apiKey.ExpiresAt
+	}
+
+	return payload, nil
+}
+
+// IsTokenRevoked checks the token store for the given access token and its session
+func (a *AuthManager) IsTokenRevoked(ctx context.Context, payload *Payload) (bool, error) {
+	if a.tokenStore == nil {
+		return false, nil
+	}
+
+	return a.tokenStore.IsTokenRevoked(ctx, payload.ID, payload.SessionID)
 }
 
 func (a *AuthManager) VerifyToken(token string) (*Payload, error) {
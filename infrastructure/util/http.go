@@ -4,10 +4,16 @@ import (
 	"cachacariaapi/domain/entities"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
+	"slices"
 	"strings"
+
+	"github.com/google/uuid"
 )
 
 type ContextKey string
@@ -15,14 +21,32 @@ type ContextKey string
 const userContextKey ContextKey = "userContext"
 
 type UserContext struct {
-	UserID  int
-	IsAdmin bool
+	UserID    int
+	Role      entities.Role
+	TwoFactor bool
+	SessionID uuid.UUID
+	// APIKeyID and Scopes are only set when the request was authenticated with an API key
+	APIKeyID int64
+	Scopes   []entities.Permission
+}
+
+func (u UserContext) HasPermission(permission entities.Permission) bool {
+	if !u.Role.HasPermission(permission) {
+		return false
+	}
+
+	return u.APIKeyID == 0 || slices.Contains(u.Scopes, permission)
 }
 
-func NewContextWithUser(ctx context.Context, userID int, isAdmin bool) context.Context {
+// NewContextWithUser adds the user authenticated by the token or API key payload to the context
+func NewContextWithUser(ctx context.Context, payload *Payload) context.Context {
 	return context.WithValue(ctx, userContextKey, &UserContext{
-		UserID:  userID,
-		IsAdmin: isAdmin,
+		UserID:    payload.UserID,
+		Role:      payload.Role,
+		TwoFactor: payload.TwoFactor,
+		SessionID: payload.SessionID,
+		APIKeyID:  payload.APIKeyID,
+		Scopes:    payload.Scopes,
 	})
 }
 
@@ -31,6 +55,14 @@ func GetUserFromContext(ctx context.Context) (*UserContext, bool) {
 	return user, ok
 }
 
+// GetSessionClient describes the device making the request, for the sessions it starts
+func GetSessionClient(r *http.Request) entities.SessionClient {
+	return entities.SessionClient{
+		IPAddress: GetClientIP(r),
+		UserAgent: TruncateUserAgent(r.UserAgent()),
+	}
+}
+
 func GetAuthTokenFromRequest(r *http.Request) string {
 	token := ""
 	authHeader := r.Header.Get("Authorization")
@@ -42,6 +74,78 @@ func GetAuthTokenFromRequest(r *http.Request) string {
 	return token
 }
 
+// trustedProxies are the reverse proxies whose X-Forwarded-For header is trusted
+var trustedProxies []netip.Prefix
+
+// SetTrustedProxies sets the addresses or CIDR ranges of the reverse proxies in front of the API
+func SetTrustedProxies(proxies []string) error {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q", proxy)
+		}
+
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+
+	trustedProxies = prefixes
+	return nil
+}
+
+func isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetClientIP returns the address of the client. X-Forwarded-For is only read when the request
+// comes from a trusted proxy, and then the right-most hop that is not a trusted proxy is the
+// client, since the hops to its left are whatever the client sent.
+func GetClientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+
+	if !isTrustedProxy(remote) {
+		return remote
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+
+		if !isTrustedProxy(ip.String()) {
+			return ip.String()
+		}
+	}
+
+	return remote
+}
+
 func ValidateRequestMethod(r *http.Request, allowedMethod string) *entities.ServerError {
 	if r.Method != allowedMethod {
 		return &entities.ServerError{
@@ -0,0 +1,119 @@
+package util
+
+import (
+	"cachacariaapi/domain/entities"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const (
+	OIDCStateDuration  = 10 * time.Minute
+	oidcRequestTimeout = 10 * time.Second
+)
+
+// OIDCProvider logs users in with an OpenID Connect provider, using the authorization code flow
+// with PKCE. The provider metadata is discovered on first use, so the API starts even if the
+// provider is down.
+type OIDCProvider struct {
+	name   string
+	issuer string
+	config oauth2.Config
+
+	mu       sync.Mutex
+	verifier *oidc.IDTokenVerifier
+}
+
+func NewOIDCProvider(name, issuer, clientID, clientSecret, redirectURL string) *OIDCProvider {
+	return &OIDCProvider{
+		name:   name,
+		issuer: issuer,
+		config: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+		},
+	}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+// NewPKCEVerifier creates the code verifier of a PKCE authorization request
+func NewPKCEVerifier() string {
+	return oauth2.GenerateVerifier()
+}
+
+// AuthCodeURL returns the URL of the provider's login page. The state and nonce are echoed back
+// in the callback and the ID token; the code verifier must be presented to exchange the code.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+
+	return p.config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+// Exchange trades the authorization code for the provider's tokens and returns the claims of the
+// ID token, once its signature, issuer, audience and expiry are verified against the provider's
+// JWKS. The caller must still check the nonce.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier string) (*entities.OIDCClaims, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx = oidc.ClientContext(ctx, &http.Client{Timeout: oidcRequestTimeout})
+
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("error exchanging authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response without id_token")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying id token: %w", err)
+	}
+
+	var claims entities.OIDCClaims
+	if err = idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("error reading id token claims: %w", err)
+	}
+
+	return &claims, nil
+}
+
+// discover fetches the provider metadata, once it succeeds
+func (p *OIDCProvider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.verifier != nil {
+		return nil
+	}
+
+	// The key set keeps the context to refresh the keys, so it must outlive the request
+	discoveryCtx := oidc.ClientContext(context.WithoutCancel(ctx), &http.Client{Timeout: oidcRequestTimeout})
+
+	provider, err := oidc.NewProvider(discoveryCtx, p.issuer)
+	if err != nil {
+		return fmt.Errorf("error discovering oidc provider %s: %w", p.name, err)
+	}
+
+	p.config.Endpoint = provider.Endpoint()
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.config.ClientID})
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxUserAgentLen  = 255
+	maxDeviceNameLen = 100
+)
+
+// DeviceName describes the browser and system of a user agent, such as "Chrome no Windows",
+// for the user to recognize their sessions
+func DeviceName(userAgent string) string {
+	if userAgent == "" {
+		return "Dispositivo desconhecido"
+	}
+
+	ua := strings.ToLower(userAgent)
+
+	var browser string
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "samsungbrowser"):
+		browser = "Samsung Internet"
+	case strings.Contains(ua, "firefox/") || strings.Contains(ua, "fxios"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	}
+
+	var system string
+	switch {
+	case strings.Contains(ua, "iphone"):
+		system = "iPhone"
+	case strings.Contains(ua, "ipad"):
+		system = "iPad"
+	case strings.Contains(ua, "android"):
+		system = "Android"
+	case strings.Contains(ua, "windows"):
+		system = "Windows"
+	case strings.Contains(ua, "mac os") || strings.Contains(ua, "macintosh"):
+		system = "macOS"
+	case strings.Contains(ua, "linux"):
+		system = "Linux"
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " no " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+
+	// Apps and scripts, such as "okhttp/4.9.0" or "curl/8.0.1", are named by their product
+	product, _, _ := strings.Cut(userAgent, " ")
+	product, _, _ = strings.Cut(product, "/")
+	return truncate(product, maxDeviceNameLen)
+}
+
+// TruncateUserAgent keeps the user agent within the size stored with sessions
+func TruncateUserAgent(userAgent string) string {
+	return truncate(userAgent, maxUserAgentLen)
+}
+
+// truncate cuts s to at most n bytes without splitting a multi-byte character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}